@@ -4,6 +4,7 @@ go 1.15
 
 require (
 	github.com/MakeNowJust/heredoc v0.0.0-20170808103936-bb23615498cd
+	github.com/Masterminds/sprig/v3 v3.2.0
 	github.com/apex/log v1.9.0
 	github.com/aryann/difflib v0.0.0-20170710044230-e206f873d14a
 	github.com/blang/semver v3.5.1+incompatible
@@ -11,6 +12,7 @@ require (
 	github.com/fatih/color v1.7.0
 	github.com/google/go-github v17.0.0+incompatible // indirect
 	github.com/google/go-github/v29 v29.0.3
+	github.com/mattn/go-isatty v0.0.8
 	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/cobra v1.1.1
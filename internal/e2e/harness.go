@@ -0,0 +1,203 @@
+// Package e2e provides a harness for end-to-end tests of chart-releaser,
+// running its commands against a fake GitHub API server.
+package e2e
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edaniszewski/chart-releaser/internal/fakegithub"
+	"github.com/edaniszewski/chart-releaser/pkg/cmd"
+	"github.com/edaniszewski/chart-releaser/pkg/env"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
+)
+
+// The owner and name of the chart repository created by the harness.
+const (
+	ChartsOwner = "example"
+	ChartsName  = "charts"
+)
+
+// ciEnv holds the environment variables used to detect CI providers and
+// GitHub Actions, which are cleared so that tests behave the same in CI.
+var ciEnv = []string{
+	"CI",
+	"GITHUB_ACTIONS",
+	"GITHUB_EVENT_NAME",
+	"GITHUB_EVENT_PATH",
+	"GITHUB_REF",
+	"GITHUB_OUTPUT",
+	"GITHUB_STEP_SUMMARY",
+	"GITLAB_CI",
+	"CIRCLECI",
+	"BUILDKITE",
+	"DRONE",
+	"JENKINS_URL",
+}
+
+// Harness runs chart-releaser commands from a temporary application git
+// repository, against a fake GitHub API server holding the chart repository.
+type Harness struct {
+	t *testing.T
+
+	// GitHub is the fake GitHub API server.
+	GitHub *fakegithub.Server
+
+	// Charts is the chart repository on the fake server.
+	Charts *fakegithub.Repo
+
+	// Dir is the application git repository.
+	Dir string
+}
+
+// New creates a Harness. The application repository is created with the
+// config file committed as .chartreleaser.yml, and the chart repository is
+// created with the given files.
+func New(t *testing.T, config string, charts map[string]string) *Harness {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found on PATH")
+	}
+
+	s := fakegithub.NewServer()
+	t.Cleanup(s.Close)
+
+	h := &Harness{
+		t:      t,
+		GitHub: s,
+		Charts: s.AddRepo(ChartsOwner, ChartsName, charts),
+		Dir:    t.TempDir(),
+	}
+
+	h.Git("init", "-q")
+	h.WriteFile(".chartreleaser.yml", config)
+	h.Git("add", "-A")
+	h.Git("commit", "-q", "-m", "Initial commit")
+	return h
+}
+
+// Git runs a git command in the application repository, returning its
+// output. The test fails if the command fails.
+func (h *Harness) Git(args ...string) string {
+	h.t.Helper()
+	c := exec.Command("git", append([]string{
+		"-c", "user.name=app",
+		"-c", "user.email=app@example.com",
+		"-c", "commit.gpgsign=false",
+		"-c", "tag.gpgsign=false",
+	}, args...)...)
+	c.Dir = h.Dir
+	out, err := c.CombinedOutput()
+	if err != nil {
+		h.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// WriteFile writes a file in the application repository.
+func (h *Harness) WriteFile(path, contents string) {
+	h.t.Helper()
+	p := filepath.Join(h.Dir, path)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, []byte(contents), 0644); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// Release makes a commit to the application repository and tags it.
+func (h *Harness) Release(tag, msg string) {
+	h.t.Helper()
+	h.Git("commit", "-q", "--allow-empty", "-m", msg)
+	h.Git("tag", tag)
+}
+
+// Result is the result of running a chart-releaser command.
+type Result struct {
+	// ExitCode is the exit code of the command.
+	ExitCode int
+
+	// Report is the report of the update, if the command was update.
+	Report *report.Report
+}
+
+// Update runs `chart-releaser update` with the given arguments, returning
+// its exit code and report.
+func (h *Harness) Update(args ...string) Result {
+	h.t.Helper()
+	reportFile := filepath.Join(h.t.TempDir(), "report.json")
+	res := h.Run(append([]string{"update", "--report-file", reportFile}, args...)...)
+
+	data, err := ioutil.ReadFile(reportFile)
+	if err != nil {
+		h.t.Fatalf("failed to read update report: %v", err)
+	}
+	res.Report = &report.Report{}
+	if err := json.Unmarshal(data, res.Report); err != nil {
+		h.t.Fatalf("failed to parse update report: %v", err)
+	}
+	return res
+}
+
+// Run runs a chart-releaser command from the application repository, using
+// the fake GitHub API server.
+func (h *Harness) Run(args ...string) Result {
+	h.t.Helper()
+	h.setenv(env.GithubToken, "test-token")
+	for _, k := range ciEnv {
+		h.unsetenv(k)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if err := os.Chdir(h.Dir); err != nil {
+		h.t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(wd); err != nil {
+			h.t.Fatal(err)
+		}
+	}()
+
+	res := Result{}
+	cmd.ExecuteWithOptions(func(code int) { res.ExitCode = code }, args, cmd.Options{
+		GitHubAPIURL: h.GitHub.URL,
+	})
+	return res
+}
+
+// setenv sets an environment variable for the duration of the test.
+func (h *Harness) setenv(key, value string) {
+	h.restoreEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// unsetenv unsets an environment variable for the duration of the test.
+func (h *Harness) unsetenv(key string) {
+	h.restoreEnv(key)
+	if err := os.Unsetenv(key); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// restoreEnv restores the current value of an environment variable once the
+// test completes.
+func (h *Harness) restoreEnv(key string) {
+	orig, found := os.LookupEnv(key)
+	h.t.Cleanup(func() {
+		if found {
+			_ = os.Setenv(key, orig)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
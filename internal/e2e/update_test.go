@@ -0,0 +1,253 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edaniszewski/chart-releaser/internal/fakegithub"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
+	"github.com/stretchr/testify/assert"
+)
+
+const config = `version: v1
+chart:
+  name: foo
+  repo: github.com/example/charts
+  path: charts/foo
+commit:
+  author:
+    name: releaser
+    email: releaser@example.com
+`
+
+const chartYAML = `apiVersion: v2
+appVersion: v0.1.0
+name: foo
+version: 0.1.0
+`
+
+const updatedChartYAML = `apiVersion: v2
+appVersion: v0.2.0
+name: foo
+version: 0.1.1
+`
+
+// newHarness creates a Harness for a chart repository holding the foo chart,
+// with releases v0.1.0 and v0.2.0 of the application.
+func newHarness(t *testing.T, extraConfig string, files map[string]string) *Harness {
+	charts := map[string]string{"charts/foo/Chart.yaml": chartYAML}
+	for k, v := range files {
+		charts[k] = v
+	}
+	h := New(t, config+extraConfig, charts)
+	h.Release("v0.1.0", "first release")
+	h.Release("v0.2.0", "add bar (#12)")
+	return h
+}
+
+func TestUpdate_PullRequest(t *testing.T) {
+	h := newHarness(t, "", nil)
+	master, _ := h.Charts.Branch("master")
+
+	res := h.Update()
+	assert.Equal(t, 0, res.ExitCode)
+
+	// The chart is updated on a new branch, leaving master untouched.
+	assert.Equal(t, []string{"chartreleaser/foo/0.1.1", "master"}, h.Charts.Branches())
+	head, _ := h.Charts.Branch("master")
+	assert.Equal(t, master, head)
+
+	chart, _ := h.Charts.File("chartreleaser/foo/0.1.1", "charts/foo/Chart.yaml")
+	assert.Equal(t, updatedChartYAML, chart)
+
+	log := h.Charts.Log("chartreleaser/foo/0.1.1")
+	assert.Len(t, log, 2)
+	assert.Equal(t, "[foo] bump chart to 0.1.1 for new application release (v0.2.0)", log[0].Message)
+	assert.Equal(t, "releaser", log[0].AuthorName)
+	assert.Equal(t, "releaser@example.com", log[0].AuthorEmail)
+	assert.Equal(t, master, log[0].Parent)
+
+	pulls := h.Charts.PullRequests()
+	assert.Len(t, pulls, 1)
+	assert.Equal(t, "Bump foo Chart from 0.1.0 to 0.1.1", pulls[0].Title)
+	assert.Equal(t, "chartreleaser/foo/0.1.1", pulls[0].Head)
+	assert.Equal(t, "master", pulls[0].Base)
+	assert.Equal(t, "open", pulls[0].State)
+	assert.Contains(t, pulls[0].Body, "add bar (#12)")
+
+	assert.True(t, res.Report.Success)
+	assert.Equal(t, report.Versions{Previous: "0.1.0", New: "0.1.1"}, res.Report.Chart)
+	assert.Equal(t, []report.Commit{
+		{Path: "charts/foo/Chart.yaml", SHA: log[0].SHA, Message: log[0].Message},
+	}, res.Report.Commits)
+	assert.Equal(t, &report.PullRequest{Number: 1, URL: h.GitHub.URL + "/example/charts/pull/1"}, res.Report.PullRequest)
+}
+
+func TestUpdate_Commit(t *testing.T) {
+	h := newHarness(t, `publish:
+  commit:
+    branch: master
+`, nil)
+
+	res := h.Update()
+	assert.Equal(t, 0, res.ExitCode)
+
+	assert.Equal(t, []string{"master"}, h.Charts.Branches())
+	assert.Empty(t, h.Charts.PullRequests())
+
+	chart, _ := h.Charts.File("master", "charts/foo/Chart.yaml")
+	assert.Equal(t, updatedChartYAML, chart)
+	assert.Len(t, h.Charts.Log("master"), 2)
+	assert.Nil(t, res.Report.PullRequest)
+}
+
+func TestUpdate_Extras(t *testing.T) {
+	h := newHarness(t, `extras:
+  - path: charts/foo/README.md
+    updates:
+      - search: 'version \S+'
+        replace: 'version {{ .Chart.NewVersion }}'
+`, map[string]string{
+		"charts/foo/README.md": "# foo\n\nversion 0.1.0\n",
+	})
+
+	res := h.Update()
+	assert.Equal(t, 0, res.ExitCode)
+
+	readme, _ := h.Charts.File("chartreleaser/foo/0.1.1", "charts/foo/README.md")
+	assert.Equal(t, "# foo\n\nversion 0.1.1\n", readme)
+
+	log := h.Charts.Log("chartreleaser/foo/0.1.1")
+	assert.Len(t, log, 3)
+	assert.Len(t, res.Report.Commits, 2)
+	assert.Equal(t, "charts/foo/README.md", res.Report.Commits[1].Path)
+}
+
+func TestUpdate_DryRun(t *testing.T) {
+	h := newHarness(t, "", nil)
+
+	res := h.Update("--dry-run")
+	assert.Equal(t, 0, res.ExitCode)
+	assert.True(t, res.Report.DryRun)
+
+	// Nothing is written to the chart repository.
+	assert.Equal(t, []string{"master"}, h.Charts.Branches())
+	assert.Len(t, h.Charts.Log("master"), 1)
+	assert.Empty(t, h.Charts.PullRequests())
+	for _, r := range h.GitHub.Requests() {
+		assert.True(t, strings.HasPrefix(r, "GET "), r)
+	}
+}
+
+func TestUpdate_NoReleaseChange(t *testing.T) {
+	h := newHarness(t, "", nil)
+	h.Git("tag", "-d", "v0.2.0")
+
+	// With the chart already at the released version, the update fails
+	// without writing to the chart repository.
+	res := h.Update("--app-version", "v0.1.0")
+	assert.Equal(t, 1, res.ExitCode)
+	assert.False(t, res.Report.Success)
+	assert.Equal(t, []string{"master"}, h.Charts.Branches())
+}
+
+func TestUpdate_Rollback(t *testing.T) {
+	h := newHarness(t, "", nil)
+	h.GitHub.Fail("POST", "/repos/example/charts/pulls", 422, 1)
+
+	res := h.Update()
+	assert.Equal(t, 1, res.ExitCode)
+	assert.False(t, res.Report.Success)
+
+	// The branch created for the update is deleted.
+	assert.Equal(t, []string{"master"}, h.Charts.Branches())
+	assert.Empty(t, h.Charts.PullRequests())
+	assert.Len(t, res.Report.Journal, 2)
+	for _, m := range res.Report.Journal {
+		assert.True(t, m.RolledBack, m.Kind)
+	}
+}
+
+func TestUpdate_NoRollback(t *testing.T) {
+	h := newHarness(t, "", nil)
+	h.GitHub.Fail("POST", "/repos/example/charts/pulls", 422, 1)
+
+	res := h.Update("--no-rollback")
+	assert.Equal(t, 1, res.ExitCode)
+
+	assert.Equal(t, []string{"chartreleaser/foo/0.1.1", "master"}, h.Charts.Branches())
+	assert.Equal(t, []report.Mutation{
+		{Kind: "create-ref", Ref: "chartreleaser/foo/0.1.1"},
+		{Kind: "update-file", Ref: "chartreleaser/foo/0.1.1", Path: "charts/foo/Chart.yaml", SHA: h.Charts.Log("chartreleaser/foo/0.1.1")[0].SHA},
+	}, res.Report.Journal)
+}
+
+func TestUpdate_Retry(t *testing.T) {
+	h := newHarness(t, "", nil)
+	h.GitHub.Fail("GET", "/repos/example/charts/contents/", 502, 1)
+	h.GitHub.Fail("PUT", "/repos/example/charts/contents/", 429, 1, "Retry-After", "0")
+
+	res := h.Update("--retry-backoff", "1ms")
+	assert.Equal(t, 0, res.ExitCode)
+
+	chart, _ := h.Charts.File("chartreleaser/foo/0.1.1", "charts/foo/Chart.yaml")
+	assert.Equal(t, updatedChartYAML, chart)
+	assert.Len(t, h.Charts.Log("chartreleaser/foo/0.1.1"), 2)
+}
+
+func TestUpdate_ExistingBranch(t *testing.T) {
+	h := newHarness(t, "", nil)
+	master, _ := h.Charts.Branch("master")
+
+	// A branch left behind by an earlier update blocks the next one.
+	res := h.Update("--no-rollback")
+	assert.Equal(t, 0, res.ExitCode)
+	res = h.Update()
+	assert.Equal(t, 1, res.ExitCode)
+
+	head, _ := h.Charts.Branch(fakegithub.DefaultBranch)
+	assert.Equal(t, master, head)
+	assert.Len(t, h.Charts.PullRequests(), 1)
+	assert.Contains(t, strings.Join(res.Report.Errors, "\n"), "Reference already exists")
+}
+
+func TestUpdate_LogFormatJSON(t *testing.T) {
+	h := newHarness(t, "", nil)
+	logFile := filepath.Join(t.TempDir(), "update.log")
+
+	res := h.Update("--log-format", "json", "--log-file", logFile)
+	assert.Equal(t, 0, res.ExitCode)
+
+	data, err := ioutil.ReadFile(logFile)
+	assert.NoError(t, err)
+
+	// Every line is a JSON record, and lines logged by stages identify the
+	// stage and what it operates on.
+	stages := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record struct {
+			Message string                 `json:"message"`
+			Fields  map[string]interface{} `json:"fields"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		assert.NotContains(t, record.Message, "\x1b[")
+
+		stage, ok := record.Fields["stage"]
+		if !ok {
+			continue
+		}
+		stages[stage.(string)] = true
+		for _, field := range []string{"repo", "ref", "path"} {
+			assert.Contains(t, record.Fields, field, line)
+		}
+		if stage == "publish" {
+			assert.Equal(t, "example/charts", record.Fields["repo"])
+			assert.Equal(t, "chartreleaser/foo/0.1.1", record.Fields["ref"])
+		}
+	}
+	assert.True(t, stages["setup"])
+	assert.True(t, stages["publish"])
+}
@@ -0,0 +1,647 @@
+// Package fakegithub provides an in-process fake of the GitHub API, for
+// testing chart-releaser against without network access.
+//
+// The fake implements the subset of the API used by chart-releaser:
+// repository contents, git refs, pull requests, and releases. Each
+// repository holds real state - branches pointing to commits, and commits
+// holding a snapshot of the repository files - so the changes made by an
+// update can be asserted on.
+package fakegithub
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBranch is the default branch of repositories created with AddRepo.
+const DefaultBranch = "master"
+
+// Commit is a commit in a fake repository.
+type Commit struct {
+	SHA     string
+	Parent  string
+	Message string
+
+	AuthorName  string
+	AuthorEmail string
+
+	// Files holds the contents of every file in the repository at the
+	// commit, keyed by path.
+	Files map[string]string
+}
+
+// PullRequest is a pull request in a fake repository.
+type PullRequest struct {
+	Number int
+	Title  string
+	Body   string
+	Head   string
+	Base   string
+	State  string
+}
+
+// Release is a release in a fake repository.
+type Release struct {
+	Tag         string
+	Name        string
+	Body        string
+	Draft       bool
+	Prerelease  bool
+	PublishedAt time.Time
+}
+
+// Repo is a repository held by the fake server. Its methods are safe to
+// call while the server is handling requests.
+type Repo struct {
+	Owner string
+	Name  string
+
+	mu       *sync.Mutex
+	branches map[string]string
+	commits  map[string]*Commit
+	pulls    []*PullRequest
+	releases []*Release
+}
+
+// failure is a failure injected for matching requests.
+type failure struct {
+	method string
+	prefix string
+	status int
+	header http.Header
+	count  int
+}
+
+// Server is a fake GitHub API server.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	repos    map[string]*Repo
+	requests []string
+	failures []*failure
+	commits  int
+}
+
+// NewServer starts a new fake GitHub API server. The server is closed when
+// Close is called.
+func NewServer() *Server {
+	s := &Server{
+		repos: map[string]*Repo{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// AddRepo adds a repository to the server, with an initial commit on the
+// default branch holding the given files.
+func (s *Server) AddRepo(owner, name string, files map[string]string) *Repo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &Repo{
+		Owner:    owner,
+		Name:     name,
+		mu:       &s.mu,
+		branches: map[string]string{},
+		commits:  map[string]*Commit{},
+	}
+	c := s.commit(r, "", "Initial commit", "fake", "fake@example.com", files)
+	r.branches[DefaultBranch] = c.SHA
+	s.repos[owner+"/"+name] = r
+	return r
+}
+
+// Fail makes the next count requests with the method, to paths beginning
+// with the prefix (e.g. "/repos/example/charts/pulls"), fail with the status.
+// Headers may be given as key-value pairs, e.g. to set Retry-After.
+func (s *Server) Fail(method, prefix string, status, count int, headers ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := http.Header{}
+	for i := 0; i+1 < len(headers); i += 2 {
+		h.Set(headers[i], headers[i+1])
+	}
+	s.failures = append(s.failures, &failure{
+		method: method,
+		prefix: prefix,
+		status: status,
+		header: h,
+		count:  count,
+	})
+}
+
+// Requests gets the requests made to the server, in the order they were
+// made, as "METHOD /path".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+// commit creates a commit in the repository. The caller must hold the lock.
+func (s *Server) commit(r *Repo, parent, msg, name, email string, files map[string]string) *Commit {
+	s.commits++
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s", s.commits, parent, msg, name)
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		_, _ = fmt.Fprintf(h, "\x00%s\x00%s", p, BlobSHA(files[p]))
+	}
+
+	c := &Commit{
+		SHA:         hex.EncodeToString(h.Sum(nil)),
+		Parent:      parent,
+		Message:     msg,
+		AuthorName:  name,
+		AuthorEmail: email,
+		Files:       files,
+	}
+	r.commits[c.SHA] = c
+	return c
+}
+
+// BlobSHA gets the git blob SHA for file contents, which GitHub uses as the
+// SHA of a file.
+func BlobSHA(contents string) string {
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "blob %d\x00%s", len(contents), contents)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Branch gets the SHA of the commit the branch points to.
+func (r *Repo) Branch(name string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sha, ok := r.branches[name]
+	return sha, ok
+}
+
+// Branches gets the names of the branches in the repository, sorted.
+func (r *Repo) Branches() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var names []string
+	for name := range r.branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// File gets the contents of a file on a branch.
+func (r *Repo) File(branch, path string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sha, ok := r.branches[branch]
+	if !ok {
+		return "", false
+	}
+	contents, ok := r.commits[sha].Files[path]
+	return contents, ok
+}
+
+// Log gets the commits on a branch, newest first.
+func (r *Repo) Log(branch string) []Commit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var commits []Commit
+	for sha := r.branches[branch]; sha != ""; {
+		c := r.commits[sha]
+		commits = append(commits, *c)
+		sha = c.Parent
+	}
+	return commits
+}
+
+// PullRequests gets the pull requests opened in the repository, in the
+// order they were opened.
+func (r *Repo) PullRequests() []PullRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pulls []PullRequest
+	for _, pr := range r.pulls {
+		pulls = append(pulls, *pr)
+	}
+	return pulls
+}
+
+// AddRelease adds a published release to the repository.
+func (r *Repo) AddRelease(release Release) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if release.PublishedAt.IsZero() {
+		release.PublishedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	r.releases = append(r.releases, &release)
+}
+
+// handle routes a request to the handler for the endpoint.
+func (s *Server) handle(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// GitHub Enterprise Server serves the API under /api/v3.
+	path := strings.TrimPrefix(req.URL.Path, "/api/v3")
+	s.requests = append(s.requests, req.Method+" "+path)
+
+	if f := s.failure(req.Method, path); f != nil {
+		for k, v := range f.header {
+			w.Header()[k] = v
+		}
+		writeError(w, f.status, http.StatusText(f.status))
+		return
+	}
+
+	if auth := req.Header.Get("Authorization"); auth == "" {
+		writeError(w, http.StatusUnauthorized, "Requires authentication")
+		return
+	}
+
+	w.Header().Set("X-RateLimit-Limit", "5000")
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(5000-len(s.requests)))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+
+	// Paths are of the form /repos/{owner}/{repo}/{resource}...
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 4)
+	if len(parts) < 4 || parts[0] != "repos" {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	r, ok := s.repos[parts[1]+"/"+parts[2]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	resource := parts[3]
+
+	switch {
+	case strings.HasPrefix(resource, "contents/"):
+		p := strings.TrimPrefix(resource, "contents/")
+		switch req.Method {
+		case http.MethodGet:
+			s.getContents(w, req, r, p)
+			return
+		case http.MethodPut:
+			s.updateContents(w, req, r, p)
+			return
+		}
+
+	case resource == "git/refs":
+		if req.Method == http.MethodPost {
+			s.createRef(w, req, r)
+			return
+		}
+
+	case strings.HasPrefix(resource, "git/refs/"):
+		ref := strings.TrimPrefix(resource, "git/refs/")
+		switch req.Method {
+		case http.MethodGet:
+			s.getRef(w, r, ref)
+			return
+		case http.MethodDelete:
+			s.deleteRef(w, r, ref)
+			return
+		}
+
+	case resource == "pulls":
+		if req.Method == http.MethodPost {
+			s.createPull(w, req, r)
+			return
+		}
+
+	case strings.HasPrefix(resource, "pulls/"):
+		if req.Method == http.MethodPatch {
+			s.editPull(w, req, r, strings.TrimPrefix(resource, "pulls/"))
+			return
+		}
+
+	case resource == "releases/latest":
+		if req.Method == http.MethodGet {
+			s.getLatestRelease(w, r)
+			return
+		}
+
+	case strings.HasPrefix(resource, "releases/tags/"):
+		if req.Method == http.MethodGet {
+			s.getReleaseByTag(w, r, strings.TrimPrefix(resource, "releases/tags/"))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+// failure gets the injected failure for a request, if any. The caller must
+// hold the lock.
+func (s *Server) failure(method, path string) *failure {
+	for i, f := range s.failures {
+		if f.method == method && strings.HasPrefix(path, f.prefix) {
+			f.count--
+			if f.count <= 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+			return f
+		}
+	}
+	return nil
+}
+
+// branchName gets the branch name from a ref, e.g. "refs/heads/master".
+func branchName(ref string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(ref, "refs/"), "heads/")
+}
+
+func (s *Server) getContents(w http.ResponseWriter, req *http.Request, r *Repo, path string) {
+	branch := DefaultBranch
+	if ref := req.URL.Query().Get("ref"); ref != "" {
+		branch = branchName(ref)
+	}
+	sha, ok := r.branches[branch]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No commit found for the ref %s", branch))
+		return
+	}
+	contents, ok := r.commits[sha].Files[path]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, contentJSON(path, contents))
+}
+
+func (s *Server) updateContents(w http.ResponseWriter, req *http.Request, r *Repo, path string) {
+	var body struct {
+		Message   string `json:"message"`
+		Content   []byte `json:"content"`
+		SHA       string `json:"sha"`
+		Branch    string `json:"branch"`
+		Committer struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"committer"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	branch := DefaultBranch
+	if body.Branch != "" {
+		branch = branchName(body.Branch)
+	}
+	head, ok := r.branches[branch]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Branch %s not found", branch))
+		return
+	}
+
+	// Updating an existing file requires the SHA of the file being replaced,
+	// so that concurrent changes are not overwritten.
+	files := map[string]string{}
+	for p, c := range r.commits[head].Files {
+		files[p] = c
+	}
+	if current, exists := files[path]; exists && body.SHA != BlobSHA(current) {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%s does not match %s", path, body.SHA))
+		return
+	}
+	if _, exists := files[path]; !exists && body.SHA != "" {
+		writeError(w, http.StatusUnprocessableEntity, "sha wasn't supplied for a new file")
+		return
+	}
+	files[path] = string(body.Content)
+
+	c := s.commit(r, head, body.Message, body.Committer.Name, body.Committer.Email, files)
+	r.branches[branch] = c.SHA
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"content": contentJSON(path, files[path]),
+		"commit": map[string]interface{}{
+			"sha":     c.SHA,
+			"message": c.Message,
+			"parents": []map[string]string{{"sha": c.Parent}},
+		},
+	})
+}
+
+func (s *Server) getRef(w http.ResponseWriter, r *Repo, ref string) {
+	sha, ok := r.branches[branchName(ref)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, refJSON(branchName(ref), sha))
+}
+
+func (s *Server) createRef(w http.ResponseWriter, req *http.Request, r *Repo) {
+	var body struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	if !strings.HasPrefix(body.Ref, "refs/heads/") {
+		writeError(w, http.StatusUnprocessableEntity, "Reference name must be a branch")
+		return
+	}
+	branch := branchName(body.Ref)
+	if _, exists := r.branches[branch]; exists {
+		writeError(w, http.StatusUnprocessableEntity, "Reference already exists")
+		return
+	}
+	if _, exists := r.commits[body.SHA]; !exists {
+		writeError(w, http.StatusUnprocessableEntity, "Object does not exist")
+		return
+	}
+	r.branches[branch] = body.SHA
+	writeJSON(w, http.StatusCreated, refJSON(branch, body.SHA))
+}
+
+func (s *Server) deleteRef(w http.ResponseWriter, r *Repo, ref string) {
+	branch := branchName(ref)
+	if _, exists := r.branches[branch]; !exists {
+		writeError(w, http.StatusUnprocessableEntity, "Reference does not exist")
+		return
+	}
+	delete(r.branches, branch)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createPull(w http.ResponseWriter, req *http.Request, r *Repo) {
+	var body struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+		Head  string `json:"head"`
+		Base  string `json:"base"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	head, base := branchName(body.Head), branchName(body.Base)
+	if _, ok := r.branches[head]; !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: head is invalid")
+		return
+	}
+	if _, ok := r.branches[base]; !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: base is invalid")
+		return
+	}
+	if r.branches[head] == r.branches[base] {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Validation Failed: No commits between %s and %s", base, head))
+		return
+	}
+	for _, pr := range r.pulls {
+		if pr.Head == head && pr.State == "open" {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Validation Failed: A pull request already exists for %s:%s.", r.Owner, head))
+			return
+		}
+	}
+
+	pr := &PullRequest{
+		Number: len(r.pulls) + 1,
+		Title:  body.Title,
+		Body:   body.Body,
+		Head:   head,
+		Base:   base,
+		State:  "open",
+	}
+	r.pulls = append(r.pulls, pr)
+	writeJSON(w, http.StatusCreated, s.pullJSON(r, pr))
+}
+
+func (s *Server) editPull(w http.ResponseWriter, req *http.Request, r *Repo, number string) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(r.pulls) {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	var body struct {
+		Title *string `json:"title"`
+		Body  *string `json:"body"`
+		State *string `json:"state"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	pr := r.pulls[n-1]
+	if body.Title != nil {
+		pr.Title = *body.Title
+	}
+	if body.Body != nil {
+		pr.Body = *body.Body
+	}
+	if body.State != nil {
+		pr.State = *body.State
+	}
+	writeJSON(w, http.StatusOK, s.pullJSON(r, pr))
+}
+
+func (s *Server) getLatestRelease(w http.ResponseWriter, r *Repo) {
+	var latest *Release
+	for _, rel := range r.releases {
+		if rel.Draft || rel.Prerelease {
+			continue
+		}
+		if latest == nil || rel.PublishedAt.After(latest.PublishedAt) {
+			latest = rel
+		}
+	}
+	if latest == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.releaseJSON(r, latest))
+}
+
+func (s *Server) getReleaseByTag(w http.ResponseWriter, r *Repo, tag string) {
+	for _, rel := range r.releases {
+		if rel.Tag == tag && !rel.Draft {
+			writeJSON(w, http.StatusOK, s.releaseJSON(r, rel))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func contentJSON(path, contents string) map[string]interface{} {
+	name := path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		name = path[i+1:]
+	}
+	return map[string]interface{}{
+		"type":     "file",
+		"encoding": "base64",
+		"size":     len(contents),
+		"name":     name,
+		"path":     path,
+		"content":  base64.StdEncoding.EncodeToString([]byte(contents)),
+		"sha":      BlobSHA(contents),
+	}
+}
+
+func refJSON(branch, sha string) map[string]interface{} {
+	return map[string]interface{}{
+		"ref": "refs/heads/" + branch,
+		"object": map[string]string{
+			"type": "commit",
+			"sha":  sha,
+		},
+	}
+}
+
+func (s *Server) pullJSON(r *Repo, pr *PullRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"number":   pr.Number,
+		"state":    pr.State,
+		"title":    pr.Title,
+		"body":     pr.Body,
+		"html_url": fmt.Sprintf("%s/%s/%s/pull/%d", s.URL, r.Owner, r.Name, pr.Number),
+		"head":     map[string]string{"ref": pr.Head, "sha": r.branches[pr.Head]},
+		"base":     map[string]string{"ref": pr.Base, "sha": r.branches[pr.Base]},
+	}
+}
+
+func (s *Server) releaseJSON(r *Repo, rel *Release) map[string]interface{} {
+	return map[string]interface{}{
+		"tag_name":     rel.Tag,
+		"name":         rel.Name,
+		"body":         rel.Body,
+		"draft":        rel.Draft,
+		"prerelease":   rel.Prerelease,
+		"published_at": rel.PublishedAt.Format(time.RFC3339),
+		"html_url":     fmt.Sprintf("%s/%s/%s/releases/tag/%s", s.URL, r.Owner, r.Name, rel.Tag),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{
+		"message":           msg,
+		"documentation_url": "https://developer.github.com/v3",
+	})
+}
@@ -6,19 +6,43 @@ import (
 	"github.com/edaniszewski/chart-releaser/pkg/client"
 )
 
+// ReleaseRequest is a request for the release of a repository by tag.
+type ReleaseRequest struct {
+	RepoOwner string
+	RepoName  string
+	Tag       string
+}
+
 // FakeClient implements the Client interface. It is used for testing.
 type FakeClient struct {
-	FileData string
+	FileData        string
+	ReleaseData     *client.Release
+	CommitSHA       string
+	PullRequestData *client.PullRequest
 
 	GetFileError           []error
 	UpdateFileError        []error
 	CreateRefError         []error
+	DeleteRefError         []error
 	CreatePullRequestError []error
+	ClosePullRequestError  []error
+	GetReleaseError        []error
+
+	// DeletedRefs and ClosedPullRequests record the refs deleted and the
+	// pull requests closed through the client.
+	DeletedRefs        []string
+	ClosedPullRequests []int
 
-	getIdx       int
-	updateIdx    int
-	createRefIdx int
-	createPRIdx  int
+	// ReleaseRequests records the releases requested by tag through the client.
+	ReleaseRequests []ReleaseRequest
+
+	getIdx        int
+	updateIdx     int
+	createRefIdx  int
+	deleteRefIdx  int
+	createPRIdx   int
+	closePRIdx    int
+	getReleaseIdx int
 }
 
 func (c *FakeClient) GetFile(ctx context.Context, opts *client.Options, path string) (string, error) {
@@ -30,13 +54,13 @@ func (c *FakeClient) GetFile(ctx context.Context, opts *client.Options, path str
 	return c.FileData, data
 }
 
-func (c *FakeClient) UpdateFile(ctx context.Context, opts *client.Options, path string, msg string, contents []byte) error {
+func (c *FakeClient) UpdateFile(ctx context.Context, opts *client.Options, path string, msg string, contents []byte) (string, error) {
 	if len(c.UpdateFileError) == 0 {
-		return nil
+		return c.CommitSHA, nil
 	}
 	data := c.UpdateFileError[c.updateIdx]
 	c.updateIdx++
-	return data
+	return c.CommitSHA, data
 }
 
 func (c *FakeClient) CreateRef(ctx context.Context, opts *client.Options) error {
@@ -48,11 +72,49 @@ func (c *FakeClient) CreateRef(ctx context.Context, opts *client.Options) error
 	return data
 }
 
-func (c *FakeClient) CreatePullRequest(ctx context.Context, opts *client.Options, title, body string) error {
+func (c *FakeClient) CreatePullRequest(ctx context.Context, opts *client.Options, title, body string) (*client.PullRequest, error) {
 	if len(c.CreatePullRequestError) == 0 {
-		return nil
+		return c.PullRequestData, nil
 	}
 	data := c.CreatePullRequestError[c.createPRIdx]
 	c.createPRIdx++
+	return c.PullRequestData, data
+}
+
+func (c *FakeClient) DeleteRef(ctx context.Context, opts *client.Options) error {
+	c.DeletedRefs = append(c.DeletedRefs, opts.Ref)
+	if len(c.DeleteRefError) == 0 {
+		return nil
+	}
+	data := c.DeleteRefError[c.deleteRefIdx]
+	c.deleteRefIdx++
 	return data
 }
+
+func (c *FakeClient) ClosePullRequest(ctx context.Context, opts *client.Options, number int) error {
+	c.ClosedPullRequests = append(c.ClosedPullRequests, number)
+	if len(c.ClosePullRequestError) == 0 {
+		return nil
+	}
+	data := c.ClosePullRequestError[c.closePRIdx]
+	c.closePRIdx++
+	return data
+}
+
+func (c *FakeClient) GetLatestRelease(ctx context.Context, opts *client.Options) (*client.Release, error) {
+	if len(c.GetReleaseError) == 0 {
+		return c.ReleaseData, nil
+	}
+	data := c.GetReleaseError[c.getReleaseIdx]
+	c.getReleaseIdx++
+	return c.ReleaseData, data
+}
+
+func (c *FakeClient) GetReleaseByTag(ctx context.Context, opts *client.Options, tag string) (*client.Release, error) {
+	c.ReleaseRequests = append(c.ReleaseRequests, ReleaseRequest{
+		RepoOwner: opts.RepoOwner,
+		RepoName:  opts.RepoName,
+		Tag:       tag,
+	})
+	return c.GetLatestRelease(ctx, opts)
+}
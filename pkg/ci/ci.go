@@ -0,0 +1,182 @@
+package ci
+
+import (
+	"os"
+	"strings"
+)
+
+// Provider is the name of a CI provider.
+type Provider string
+
+// The CI providers which can be detected.
+const (
+	GitHubActions Provider = "github-actions"
+	GitLab        Provider = "gitlab"
+	CircleCI      Provider = "circleci"
+	Buildkite     Provider = "buildkite"
+	Drone         Provider = "drone"
+	Jenkins       Provider = "jenkins"
+	Generic       Provider = "generic"
+)
+
+// Environment describes the CI job chart-releaser is running in. Values which
+// the provider does not set are empty.
+type Environment struct {
+	// Provider is the CI provider running the job, or empty if not running
+	// in CI.
+	Provider Provider
+
+	// Tag is the tag being built, if the job was triggered by a tag.
+	Tag string
+
+	// SHA is the commit being built.
+	SHA string
+
+	// Branch is the branch being built.
+	Branch string
+
+	// JobID is the provider's identifier for the job or build.
+	JobID string
+
+	// JobURL is a link to the job or build in the provider's UI.
+	JobURL string
+}
+
+// IsCI checks whether the Environment is for a CI job.
+func (e Environment) IsCI() bool {
+	return e.Provider != ""
+}
+
+// detector detects a CI provider from the environment, returning the
+// Environment for the provider if it is detected.
+type detector func(getenv func(string) string) (Environment, bool)
+
+// detectors for each provider, in the order they are checked. Jenkins is
+// checked last, since its variables have generic names.
+var detectors = []detector{
+	detectGitHubActions,
+	detectGitLab,
+	detectCircleCI,
+	detectBuildkite,
+	detectDrone,
+	detectJenkins,
+}
+
+// Detect the CI provider chart-releaser is running in from the environment.
+func Detect() Environment {
+	return detect(os.Getenv)
+}
+
+// detect the CI provider using the given function to look up environment
+// variables.
+func detect(getenv func(string) string) Environment {
+	for _, d := range detectors {
+		if e, ok := d(getenv); ok {
+			return e
+		}
+	}
+	if getenv("CI") != "" {
+		return Environment{Provider: Generic}
+	}
+	return Environment{}
+}
+
+func detectGitHubActions(getenv func(string) string) (Environment, bool) {
+	if getenv("GITHUB_ACTIONS") != "true" {
+		return Environment{}, false
+	}
+	e := Environment{
+		Provider: GitHubActions,
+		SHA:      getenv("GITHUB_SHA"),
+		JobID:    getenv("GITHUB_RUN_ID"),
+	}
+	ref := getenv("GITHUB_REF")
+	if strings.HasPrefix(ref, "refs/tags/") {
+		e.Tag = strings.TrimPrefix(ref, "refs/tags/")
+	} else if strings.HasPrefix(ref, "refs/heads/") {
+		e.Branch = strings.TrimPrefix(ref, "refs/heads/")
+	}
+	if server, repo := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"); server != "" && repo != "" && e.JobID != "" {
+		e.JobURL = server + "/" + repo + "/actions/runs/" + e.JobID
+	}
+	return e, true
+}
+
+func detectGitLab(getenv func(string) string) (Environment, bool) {
+	if getenv("GITLAB_CI") != "true" {
+		return Environment{}, false
+	}
+	return Environment{
+		Provider: GitLab,
+		Tag:      getenv("CI_COMMIT_TAG"),
+		SHA:      getenv("CI_COMMIT_SHA"),
+		Branch:   getenv("CI_COMMIT_BRANCH"),
+		JobID:    getenv("CI_JOB_ID"),
+		JobURL:   getenv("CI_JOB_URL"),
+	}, true
+}
+
+func detectCircleCI(getenv func(string) string) (Environment, bool) {
+	if getenv("CIRCLECI") != "true" {
+		return Environment{}, false
+	}
+	return Environment{
+		Provider: CircleCI,
+		Tag:      getenv("CIRCLE_TAG"),
+		SHA:      getenv("CIRCLE_SHA1"),
+		Branch:   getenv("CIRCLE_BRANCH"),
+		JobID:    getenv("CIRCLE_BUILD_NUM"),
+		JobURL:   getenv("CIRCLE_BUILD_URL"),
+	}, true
+}
+
+func detectBuildkite(getenv func(string) string) (Environment, bool) {
+	if getenv("BUILDKITE") != "true" {
+		return Environment{}, false
+	}
+	e := Environment{
+		Provider: Buildkite,
+		Tag:      getenv("BUILDKITE_TAG"),
+		SHA:      getenv("BUILDKITE_COMMIT"),
+		Branch:   getenv("BUILDKITE_BRANCH"),
+		JobID:    getenv("BUILDKITE_JOB_ID"),
+		JobURL:   getenv("BUILDKITE_BUILD_URL"),
+	}
+	// Link to the job within the build, rather than the whole build.
+	if e.JobURL != "" && e.JobID != "" {
+		e.JobURL += "#" + e.JobID
+	}
+	return e, true
+}
+
+func detectDrone(getenv func(string) string) (Environment, bool) {
+	if getenv("DRONE") != "true" {
+		return Environment{}, false
+	}
+	return Environment{
+		Provider: Drone,
+		Tag:      getenv("DRONE_TAG"),
+		SHA:      getenv("DRONE_COMMIT_SHA"),
+		Branch:   getenv("DRONE_BRANCH"),
+		JobID:    getenv("DRONE_BUILD_NUMBER"),
+		JobURL:   getenv("DRONE_BUILD_LINK"),
+	}, true
+}
+
+func detectJenkins(getenv func(string) string) (Environment, bool) {
+	if getenv("JENKINS_URL") == "" || getenv("BUILD_ID") == "" {
+		return Environment{}, false
+	}
+	branch := getenv("BRANCH_NAME")
+	if branch == "" {
+		branch = strings.TrimPrefix(getenv("GIT_BRANCH"), "origin/")
+	}
+	return Environment{
+		Provider: Jenkins,
+		Tag:      getenv("TAG_NAME"),
+		SHA:      getenv("GIT_COMMIT"),
+		Branch:   branch,
+		JobID:    getenv("BUILD_ID"),
+		JobURL:   getenv("BUILD_URL"),
+	}, true
+}
@@ -0,0 +1,178 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// env creates a function which looks up environment variables from a map.
+func env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		expected Environment
+	}{
+		{
+			name:     "not ci",
+			vars:     map[string]string{},
+			expected: Environment{},
+		},
+		{
+			name: "generic",
+			vars: map[string]string{
+				"CI": "true",
+			},
+			expected: Environment{Provider: Generic},
+		},
+		{
+			name: "github actions tag",
+			vars: map[string]string{
+				"CI":                "true",
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_REF":        "refs/tags/v1.2.0",
+				"GITHUB_SHA":        "abc123",
+				"GITHUB_RUN_ID":     "42",
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_REPOSITORY": "example/app",
+			},
+			expected: Environment{
+				Provider: GitHubActions,
+				Tag:      "v1.2.0",
+				SHA:      "abc123",
+				JobID:    "42",
+				JobURL:   "https://github.com/example/app/actions/runs/42",
+			},
+		},
+		{
+			name: "github actions branch",
+			vars: map[string]string{
+				"GITHUB_ACTIONS": "true",
+				"GITHUB_REF":     "refs/heads/master",
+				"GITHUB_SHA":     "abc123",
+			},
+			expected: Environment{
+				Provider: GitHubActions,
+				SHA:      "abc123",
+				Branch:   "master",
+			},
+		},
+		{
+			name: "gitlab",
+			vars: map[string]string{
+				"CI":            "true",
+				"GITLAB_CI":     "true",
+				"CI_COMMIT_TAG": "v1.2.0",
+				"CI_COMMIT_SHA": "abc123",
+				"CI_JOB_ID":     "42",
+				"CI_JOB_URL":    "https://gitlab.com/example/app/-/jobs/42",
+			},
+			expected: Environment{
+				Provider: GitLab,
+				Tag:      "v1.2.0",
+				SHA:      "abc123",
+				JobID:    "42",
+				JobURL:   "https://gitlab.com/example/app/-/jobs/42",
+			},
+		},
+		{
+			name: "circleci",
+			vars: map[string]string{
+				"CIRCLECI":         "true",
+				"CIRCLE_TAG":       "v1.2.0",
+				"CIRCLE_SHA1":      "abc123",
+				"CIRCLE_BUILD_NUM": "42",
+				"CIRCLE_BUILD_URL": "https://circleci.com/gh/example/app/42",
+			},
+			expected: Environment{
+				Provider: CircleCI,
+				Tag:      "v1.2.0",
+				SHA:      "abc123",
+				JobID:    "42",
+				JobURL:   "https://circleci.com/gh/example/app/42",
+			},
+		},
+		{
+			name: "buildkite",
+			vars: map[string]string{
+				"BUILDKITE":           "true",
+				"BUILDKITE_TAG":       "v1.2.0",
+				"BUILDKITE_COMMIT":    "abc123",
+				"BUILDKITE_BRANCH":    "v1.2.0",
+				"BUILDKITE_JOB_ID":    "0184-ab",
+				"BUILDKITE_BUILD_URL": "https://buildkite.com/example/app/builds/42",
+			},
+			expected: Environment{
+				Provider: Buildkite,
+				Tag:      "v1.2.0",
+				SHA:      "abc123",
+				Branch:   "v1.2.0",
+				JobID:    "0184-ab",
+				JobURL:   "https://buildkite.com/example/app/builds/42#0184-ab",
+			},
+		},
+		{
+			name: "drone",
+			vars: map[string]string{
+				"DRONE":              "true",
+				"DRONE_TAG":          "v1.2.0",
+				"DRONE_COMMIT_SHA":   "abc123",
+				"DRONE_BUILD_NUMBER": "42",
+				"DRONE_BUILD_LINK":   "https://drone.example.com/example/app/42",
+			},
+			expected: Environment{
+				Provider: Drone,
+				Tag:      "v1.2.0",
+				SHA:      "abc123",
+				JobID:    "42",
+				JobURL:   "https://drone.example.com/example/app/42",
+			},
+		},
+		{
+			name: "jenkins",
+			vars: map[string]string{
+				"JENKINS_URL": "https://jenkins.example.com/",
+				"BUILD_ID":    "42",
+				"BUILD_URL":   "https://jenkins.example.com/job/app/42/",
+				"GIT_COMMIT":  "abc123",
+				"GIT_BRANCH":  "origin/master",
+			},
+			expected: Environment{
+				Provider: Jenkins,
+				SHA:      "abc123",
+				Branch:   "master",
+				JobID:    "42",
+				JobURL:   "https://jenkins.example.com/job/app/42/",
+			},
+		},
+		{
+			name: "jenkins multibranch tag",
+			vars: map[string]string{
+				"JENKINS_URL": "https://jenkins.example.com/",
+				"BUILD_ID":    "42",
+				"BRANCH_NAME": "v1.2.0",
+				"TAG_NAME":    "v1.2.0",
+			},
+			expected: Environment{
+				Provider: Jenkins,
+				Tag:      "v1.2.0",
+				Branch:   "v1.2.0",
+				JobID:    "42",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := detect(env(test.vars))
+			assert.Equal(t, test.expected, e)
+			assert.Equal(t, test.expected.Provider != "", e.IsCI())
+		})
+	}
+}
@@ -3,20 +3,26 @@ package client
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // Errors relating to client operations.
 var (
-	ErrFileNotFound = errors.New("file not found in remote repo")
+	ErrFileNotFound    = errors.New("file not found in remote repo")
+	ErrReleaseNotFound = errors.New("release not found in remote repo")
 )
 
 // The Client interface defines a way to interact with a source repository
 // to be able to perform operations on Helm Charts and other project files.
 type Client interface {
 	GetFile(ctx context.Context, opts *Options, path string) (contents string, err error)
-	UpdateFile(ctx context.Context, opts *Options, path string, msg string, contents []byte) error
+	UpdateFile(ctx context.Context, opts *Options, path string, msg string, contents []byte) (sha string, err error)
 	CreateRef(ctx context.Context, opts *Options) error
-	CreatePullRequest(ctx context.Context, opts *Options, title, body string) error
+	DeleteRef(ctx context.Context, opts *Options) error
+	CreatePullRequest(ctx context.Context, opts *Options, title, body string) (*PullRequest, error)
+	ClosePullRequest(ctx context.Context, opts *Options, number int) error
+	GetLatestRelease(ctx context.Context, opts *Options) (*Release, error)
+	GetReleaseByTag(ctx context.Context, opts *Options, tag string) (*Release, error)
 }
 
 // Options are the configuration options and state required to create a new
@@ -29,3 +35,18 @@ type Options struct {
 	AuthorName  string
 	AuthorEmail string
 }
+
+// Release holds information about a published release of a repository.
+type Release struct {
+	Tag         string
+	Name        string
+	Body        string
+	URL         string
+	PublishedAt time.Time
+}
+
+// PullRequest holds information about a pull request opened against a repository.
+type PullRequest struct {
+	Number int
+	URL    string
+}
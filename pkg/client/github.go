@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/apex/log"
@@ -16,8 +17,16 @@ type githubClient struct {
 	client *github.Client
 }
 
-// NewGitHubClient creates a new GitHub client.
-func NewGitHubClient(ctx context.Context, token string) (Client, error) {
+// NewGitHubClient creates a new GitHub client. Failed requests are retried
+// as configured by the RetryOptions.
+func NewGitHubClient(ctx context.Context, token string, retry RetryOptions) (Client, error) {
+	return NewGitHubClientWithURL(ctx, "", token, retry)
+}
+
+// NewGitHubClientWithURL creates a new GitHub client for the GitHub API at
+// the given URL, e.g. a fake GitHub API server in tests. If the URL is empty,
+// the public GitHub API is used.
+func NewGitHubClientWithURL(ctx context.Context, apiURL, token string, retry RetryOptions) (Client, error) {
 	if token == "" {
 		return nil, fmt.Errorf("no token provided to github client")
 	}
@@ -25,7 +34,20 @@ func NewGitHubClient(ctx context.Context, token string) (Client, error) {
 	tok := oauth2.StaticTokenSource(&oauth2.Token{
 		AccessToken: token,
 	})
-	client := github.NewClient(oauth2.NewClient(ctx, tok))
+	httpClient := oauth2.NewClient(ctx, tok)
+	httpClient.Transport = newRetryTransport(httpClient.Transport, retry)
+	client := github.NewClient(httpClient)
+
+	if apiURL != "" {
+		u, err := url.Parse(apiURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid github api url: %w", err)
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		client.BaseURL = u
+	}
 
 	return githubClient{
 		client: client,
@@ -69,10 +91,10 @@ func (c githubClient) GetFile(ctx context.Context, opts *Options, path string) (
 }
 
 // UpdateFile updates the content of the specified file within the configured
-// chart repository.
-func (c githubClient) UpdateFile(ctx context.Context, opts *Options, path string, msg string, contents []byte) error {
+// chart repository. The SHA of the commit made for the update is returned.
+func (c githubClient) UpdateFile(ctx context.Context, opts *Options, path string, msg string, contents []byte) (string, error) {
 	if err := verifyOptions(opts); err != nil {
-		return err
+		return "", err
 	}
 
 	options := &github.RepositoryContentFileOptions{
@@ -102,21 +124,24 @@ func (c githubClient) UpdateFile(ctx context.Context, opts *Options, path string
 				"file":  path,
 				"ref":   opts.Ref,
 			}).Error("github client: unable to update file (not found)")
-			return ErrFileNotFound
+			return "", ErrFileNotFound
 		}
-		return err
+		return "", err
 	}
 
 	// The file exists -- update it.
 	options.SHA = file.SHA
-	_, _, err = c.client.Repositories.UpdateFile(
+	updated, _, err := c.client.Repositories.UpdateFile(
 		ctx,
 		opts.RepoOwner,
 		opts.RepoName,
 		path,
 		options,
 	)
-	return err
+	if err != nil {
+		return "", err
+	}
+	return updated.Commit.GetSHA(), nil
 }
 
 // CreateRef creates a new ref to stage the commits produced by chart-releaser.
@@ -171,15 +196,43 @@ func (c githubClient) CreateRef(ctx context.Context, opts *Options) error {
 	return nil
 }
 
-// CreatePullRequest creates a new pull request for the changes produced by chart-releaser.
-func (c githubClient) CreatePullRequest(ctx context.Context, opts *Options, title, body string) error {
+// DeleteRef deletes the ref created to stage the commits produced by chart-releaser.
+func (c githubClient) DeleteRef(ctx context.Context, opts *Options) error {
 	if err := verifyOptions(opts); err != nil {
 		return err
 	}
 
+	log.WithFields(log.Fields{
+		"repo": fmt.Sprintf("%s/%s", opts.RepoOwner, opts.RepoName),
+		"ref":  opts.Ref,
+	}).Debug("github client: deleting reference")
+
+	// The request is built directly, since GitService.DeleteRef escapes the
+	// slashes in the ref, which are common in chart-releaser branch names.
+	u := fmt.Sprintf("repos/%v/%v/git/refs/%v", opts.RepoOwner, opts.RepoName, strings.TrimPrefix(opts.Ref, "refs/"))
+	req, err := c.client.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Do(ctx, req, nil); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"ref":   opts.Ref,
+		}).Error("github client: failed to delete ref")
+		return err
+	}
+	return nil
+}
+
+// CreatePullRequest creates a new pull request for the changes produced by chart-releaser.
+func (c githubClient) CreatePullRequest(ctx context.Context, opts *Options, title, body string) (*PullRequest, error) {
+	if err := verifyOptions(opts); err != nil {
+		return nil, err
+	}
+
 	if opts.Base == opts.Ref {
 		// todo: logging
-		return fmt.Errorf("cannot create pull request, ref and base are the same")
+		return nil, fmt.Errorf("cannot create pull request, ref and base are the same")
 	}
 
 	log.WithFields(log.Fields{
@@ -201,9 +254,79 @@ func (c githubClient) CreatePullRequest(ctx context.Context, opts *Options, titl
 	)
 	if err != nil {
 		// todo: logging
-		return err
+		return nil, err
 	}
 
-	log.Infof("created pull request %v %v (%v <- %v)", pr.GetNumber(), pr.GetURL(), pr.GetBase().GetRef(), pr.GetHead().GetRef())
-	return nil
+	log.Infof("created pull request %v %v (%v <- %v)", pr.GetNumber(), pr.GetHTMLURL(), pr.GetBase().GetRef(), pr.GetHead().GetRef())
+	return &PullRequest{
+		Number: pr.GetNumber(),
+		URL:    pr.GetHTMLURL(),
+	}, nil
+}
+
+// ClosePullRequest closes a pull request opened for the changes produced by chart-releaser.
+func (c githubClient) ClosePullRequest(ctx context.Context, opts *Options, number int) error {
+	log.WithFields(log.Fields{
+		"repo":   fmt.Sprintf("%s/%s", opts.RepoOwner, opts.RepoName),
+		"number": number,
+	}).Debug("github client: closing pull request")
+	_, _, err := c.client.PullRequests.Edit(
+		ctx,
+		opts.RepoOwner,
+		opts.RepoName,
+		number,
+		&github.PullRequest{
+			State: github.String("closed"),
+		},
+	)
+	return err
+}
+
+// GetLatestRelease gets the latest published release for the repository.
+func (c githubClient) GetLatestRelease(ctx context.Context, opts *Options) (*Release, error) {
+	log.WithFields(log.Fields{
+		"repo": fmt.Sprintf("%s/%s", opts.RepoOwner, opts.RepoName),
+	}).Debug("github client: getting latest release")
+	release, _, err := c.client.Repositories.GetLatestRelease(
+		ctx,
+		opts.RepoOwner,
+		opts.RepoName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return newRelease(release), nil
+}
+
+// GetReleaseByTag gets the published release for the given tag. If there is no
+// release for the tag, ErrReleaseNotFound is returned.
+func (c githubClient) GetReleaseByTag(ctx context.Context, opts *Options, tag string) (*Release, error) {
+	log.WithFields(log.Fields{
+		"repo": fmt.Sprintf("%s/%s", opts.RepoOwner, opts.RepoName),
+		"tag":  tag,
+	}).Debug("github client: getting release for tag")
+	release, resp, err := c.client.Repositories.GetReleaseByTag(
+		ctx,
+		opts.RepoOwner,
+		opts.RepoName,
+		tag,
+	)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return nil, ErrReleaseNotFound
+		}
+		return nil, err
+	}
+	return newRelease(release), nil
+}
+
+// newRelease converts a GitHub RepositoryRelease into a Release.
+func newRelease(r *github.RepositoryRelease) *Release {
+	return &Release{
+		Tag:         r.GetTagName(),
+		Name:        r.GetName(),
+		Body:        r.GetBody(),
+		URL:         r.GetHTMLURL(),
+		PublishedAt: r.GetPublishedAt().Time,
+	}
 }
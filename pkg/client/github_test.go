@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edaniszewski/chart-releaser/internal/fakegithub"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGitHubClient(t *testing.T) (Client, *fakegithub.Server, *fakegithub.Repo) {
+	s := fakegithub.NewServer()
+	t.Cleanup(s.Close)
+	repo := s.AddRepo("example", "charts", map[string]string{
+		"charts/foo/Chart.yaml": "version: 0.1.0\n",
+		"README.md":             "# charts\n",
+	})
+
+	c, err := NewGitHubClientWithURL(context.Background(), s.URL, "token", RetryOptions{MaxRetries: 2})
+	assert.NoError(t, err)
+	return c, s, repo
+}
+
+func newTestOptions(ref string) *Options {
+	return &Options{
+		RepoOwner:   "example",
+		RepoName:    "charts",
+		Ref:         ref,
+		Base:        "master",
+		AuthorName:  "releaser",
+		AuthorEmail: "releaser@example.com",
+	}
+}
+
+func TestNewGitHubClient_NoToken(t *testing.T) {
+	c, err := NewGitHubClient(context.Background(), "", RetryOptions{})
+	assert.EqualError(t, err, "no token provided to github client")
+	assert.Nil(t, c)
+}
+
+func TestNewGitHubClientWithURL_InvalidURL(t *testing.T) {
+	c, err := NewGitHubClientWithURL(context.Background(), "://", "token", RetryOptions{})
+	assert.EqualError(t, err, "invalid github api url: parse \"://\": missing protocol scheme")
+	assert.Nil(t, c)
+}
+
+func TestGithubClient_GetFile(t *testing.T) {
+	c, _, _ := newTestGitHubClient(t)
+
+	contents, err := c.GetFile(context.Background(), newTestOptions("master"), "charts/foo/Chart.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 0.1.0\n", contents)
+}
+
+func TestGithubClient_GetFileNotFound(t *testing.T) {
+	c, _, _ := newTestGitHubClient(t)
+
+	_, err := c.GetFile(context.Background(), newTestOptions("master"), "charts/bar/Chart.yaml")
+	assert.Error(t, err)
+}
+
+func TestGithubClient_CreateRefUpdateFile(t *testing.T) {
+	c, _, repo := newTestGitHubClient(t)
+	base, _ := repo.Branch("master")
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	sha, err := c.UpdateFile(context.Background(), opts, "charts/foo/Chart.yaml", "bump foo to 0.2.0", []byte("version: 0.2.0\n"))
+	assert.NoError(t, err)
+
+	head, ok := repo.Branch("chartreleaser/foo/0.2.0")
+	assert.True(t, ok)
+	assert.Equal(t, sha, head)
+
+	log := repo.Log("chartreleaser/foo/0.2.0")
+	assert.Len(t, log, 2)
+	assert.Equal(t, "bump foo to 0.2.0", log[0].Message)
+	assert.Equal(t, "releaser", log[0].AuthorName)
+	assert.Equal(t, "releaser@example.com", log[0].AuthorEmail)
+	assert.Equal(t, base, log[0].Parent)
+
+	contents, _ := repo.File("chartreleaser/foo/0.2.0", "charts/foo/Chart.yaml")
+	assert.Equal(t, "version: 0.2.0\n", contents)
+	contents, _ = repo.File("master", "charts/foo/Chart.yaml")
+	assert.Equal(t, "version: 0.1.0\n", contents)
+}
+
+func TestGithubClient_CreateRefExists(t *testing.T) {
+	c, _, _ := newTestGitHubClient(t)
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	err := c.CreateRef(context.Background(), opts)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Reference already exists")
+}
+
+func TestGithubClient_CreateRefNoBase(t *testing.T) {
+	c, _, _ := newTestGitHubClient(t)
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+	opts.Base = "develop"
+
+	assert.Error(t, c.CreateRef(context.Background(), opts))
+}
+
+func TestGithubClient_UpdateFileNotFound(t *testing.T) {
+	c, _, _ := newTestGitHubClient(t)
+
+	_, err := c.UpdateFile(context.Background(), newTestOptions("master"), "charts/bar/Chart.yaml", "msg", []byte("version: 0.2.0\n"))
+	assert.Equal(t, ErrFileNotFound, err)
+}
+
+func TestGithubClient_DeleteRef(t *testing.T) {
+	c, _, repo := newTestGitHubClient(t)
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	assert.NoError(t, c.DeleteRef(context.Background(), opts))
+	assert.Equal(t, []string{"master"}, repo.Branches())
+}
+
+func TestGithubClient_PullRequest(t *testing.T) {
+	c, s, repo := newTestGitHubClient(t)
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	_, err := c.UpdateFile(context.Background(), opts, "charts/foo/Chart.yaml", "bump foo to 0.2.0", []byte("version: 0.2.0\n"))
+	assert.NoError(t, err)
+
+	pr, err := c.CreatePullRequest(context.Background(), opts, "Bump foo", "Bumps foo to 0.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, &PullRequest{Number: 1, URL: s.URL + "/example/charts/pull/1"}, pr)
+	assert.Equal(t, []fakegithub.PullRequest{
+		{Number: 1, Title: "Bump foo", Body: "Bumps foo to 0.2.0", Head: "chartreleaser/foo/0.2.0", Base: "master", State: "open"},
+	}, repo.PullRequests())
+
+	assert.NoError(t, c.ClosePullRequest(context.Background(), opts, pr.Number))
+	assert.Equal(t, "closed", repo.PullRequests()[0].State)
+}
+
+func TestGithubClient_PullRequestNoChanges(t *testing.T) {
+	c, _, _ := newTestGitHubClient(t)
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	_, err := c.CreatePullRequest(context.Background(), opts, "Bump foo", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "No commits between master and chartreleaser/foo/0.2.0")
+}
+
+func TestGithubClient_Releases(t *testing.T) {
+	c, _, repo := newTestGitHubClient(t)
+	repo.AddRelease(fakegithub.Release{Tag: "v0.1.0", PublishedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})
+	repo.AddRelease(fakegithub.Release{Tag: "v0.2.0", Name: "v0.2.0", Body: "notes", PublishedAt: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)})
+	repo.AddRelease(fakegithub.Release{Tag: "v0.3.0-rc.1", Prerelease: true, PublishedAt: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)})
+
+	release, err := c.GetLatestRelease(context.Background(), newTestOptions("master"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.2.0", release.Tag)
+	assert.Equal(t, "notes", release.Body)
+	assert.Equal(t, time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), release.PublishedAt)
+
+	release, err = c.GetReleaseByTag(context.Background(), newTestOptions("master"), "v0.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.1.0", release.Tag)
+
+	_, err = c.GetReleaseByTag(context.Background(), newTestOptions("master"), "v9.9.9")
+	assert.Equal(t, ErrReleaseNotFound, err)
+}
+
+func TestGithubClient_Retry(t *testing.T) {
+	c, s, _ := newTestGitHubClient(t)
+	s.Fail("GET", "/repos/example/charts/contents/", 502, 1)
+	s.Fail("POST", "/repos/example/charts/git/refs", 429, 1, "Retry-After", "0")
+
+	contents, err := c.GetFile(context.Background(), newTestOptions("master"), "charts/foo/Chart.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 0.1.0\n", contents)
+
+	assert.NoError(t, c.CreateRef(context.Background(), newTestOptions("chartreleaser/foo/0.2.0")))
+
+	assert.Equal(t, []string{
+		"GET /repos/example/charts/contents/charts/foo/Chart.yaml",
+		"GET /repos/example/charts/contents/charts/foo/Chart.yaml",
+		"GET /repos/example/charts/git/refs/heads/master",
+		"POST /repos/example/charts/git/refs",
+		"POST /repos/example/charts/git/refs",
+	}, s.Requests())
+}
+
+func TestGithubClient_NoRetryCreatePullRequest(t *testing.T) {
+	c, s, repo := newTestGitHubClient(t)
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	_, err := c.UpdateFile(context.Background(), opts, "charts/foo/Chart.yaml", "bump", []byte("version: 0.2.0\n"))
+	assert.NoError(t, err)
+
+	// The pull request may have been opened, so it is not retried.
+	s.Fail("POST", "/repos/example/charts/pulls", 502, 1)
+	_, err = c.CreatePullRequest(context.Background(), opts, "Bump foo", "")
+	assert.Error(t, err)
+	assert.Empty(t, repo.PullRequests())
+}
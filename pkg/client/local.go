@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+)
+
+// Errors relating to local client operations.
+var (
+	ErrReadOnly    = errors.New("local client is read-only")
+	ErrUnsupported = errors.New("operation not supported by local client")
+)
+
+// localClient implements the Client interface for reading charts from a local
+// checkout of the chart repository. It is read-only, so it can only be used for
+// dry-runs.
+type localClient struct {
+	dir string
+}
+
+// NewLocalClient creates a new client which reads files from a local checkout
+// of the chart repository at the given directory.
+func NewLocalClient(dir string) (Client, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("local chart repo path is not a directory: %s", dir)
+	}
+	return localClient{
+		dir: dir,
+	}, nil
+}
+
+// GetFile gets the data for the specified file from the local chart repository.
+func (c localClient) GetFile(ctx context.Context, opts *Options, path string) (string, error) {
+	log.WithFields(log.Fields{
+		"dir":  c.dir,
+		"path": path,
+	}).Debug("local client: getting file")
+
+	contents, err := ioutil.ReadFile(filepath.Join(c.dir, filepath.FromSlash(path)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrFileNotFound
+		}
+		return "", err
+	}
+	return string(contents), nil
+}
+
+// UpdateFile is not supported by the local client.
+func (c localClient) UpdateFile(ctx context.Context, opts *Options, path string, msg string, contents []byte) (string, error) {
+	return "", ErrReadOnly
+}
+
+// CreateRef is not supported by the local client.
+func (c localClient) CreateRef(ctx context.Context, opts *Options) error {
+	return ErrReadOnly
+}
+
+// DeleteRef is not supported by the local client.
+func (c localClient) DeleteRef(ctx context.Context, opts *Options) error {
+	return ErrReadOnly
+}
+
+// CreatePullRequest is not supported by the local client.
+func (c localClient) CreatePullRequest(ctx context.Context, opts *Options, title, body string) (*PullRequest, error) {
+	return nil, ErrReadOnly
+}
+
+// ClosePullRequest is not supported by the local client.
+func (c localClient) ClosePullRequest(ctx context.Context, opts *Options, number int) error {
+	return ErrReadOnly
+}
+
+// GetLatestRelease is not supported by the local client.
+func (c localClient) GetLatestRelease(ctx context.Context, opts *Options) (*Release, error) {
+	return nil, ErrUnsupported
+}
+
+// GetReleaseByTag is not supported by the local client.
+func (c localClient) GetReleaseByTag(ctx context.Context, opts *Options, tag string) (*Release, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLocalClient(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := NewLocalClient(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, localClient{dir: dir}, c)
+}
+
+func TestNewLocalClient_NotExist(t *testing.T) {
+	_, err := NewLocalClient(filepath.Join(t.TempDir(), "missing"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewLocalClient_NotDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("test"), 0644))
+
+	_, err := NewLocalClient(path)
+	assert.EqualError(t, err, "local chart repo path is not a directory: "+path)
+}
+
+func TestLocalClient_GetFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "charts", "test"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "charts", "test", "Chart.yaml"), []byte("version: 0.1.0"), 0644))
+
+	c, err := NewLocalClient(dir)
+	assert.NoError(t, err)
+
+	contents, err := c.GetFile(context.Background(), &Options{}, "charts/test/Chart.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 0.1.0", contents)
+}
+
+func TestLocalClient_GetFile_NotFound(t *testing.T) {
+	c, err := NewLocalClient(t.TempDir())
+	assert.NoError(t, err)
+
+	_, err = c.GetFile(context.Background(), &Options{}, "Chart.yaml")
+	assert.Equal(t, ErrFileNotFound, err)
+}
+
+func TestLocalClient_ReadOnly(t *testing.T) {
+	c, err := NewLocalClient(t.TempDir())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = c.UpdateFile(ctx, &Options{}, "Chart.yaml", "msg", []byte("test"))
+	assert.Equal(t, ErrReadOnly, err)
+	assert.Equal(t, ErrReadOnly, c.CreateRef(ctx, &Options{}))
+	assert.Equal(t, ErrReadOnly, c.DeleteRef(ctx, &Options{}))
+	_, err = c.CreatePullRequest(ctx, &Options{}, "title", "body")
+	assert.Equal(t, ErrReadOnly, err)
+	assert.Equal(t, ErrReadOnly, c.ClosePullRequest(ctx, &Options{}, 1))
+
+	_, err = c.GetLatestRelease(ctx, &Options{})
+	assert.Equal(t, ErrUnsupported, err)
+	_, err = c.GetReleaseByTag(ctx, &Options{}, "v1.0.0")
+	assert.Equal(t, ErrUnsupported, err)
+}
@@ -0,0 +1,370 @@
+// Package memory provides an in-memory implementation of client.Client.
+//
+// The in-memory client holds a single repository with real state: branches
+// pointing to commits, commits holding a snapshot of the repository files,
+// and pull requests between branches. It can be used to run the update
+// pipeline in-process and inspect exactly what would have been published.
+package memory
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+)
+
+// DefaultBranch is the branch holding the files the Client is created with.
+const DefaultBranch = "master"
+
+// Errors relating to in-memory client operations.
+var (
+	ErrBranchNotFound      = errors.New("branch not found in memory repo")
+	ErrBranchExists        = errors.New("branch already exists in memory repo")
+	ErrPullRequestNotFound = errors.New("pull request not found in memory repo")
+	ErrPullRequestExists   = errors.New("pull request already exists for branch")
+	ErrNoChanges           = errors.New("no changes between branches")
+)
+
+// Commit is a commit made to the repository.
+type Commit struct {
+	SHA     string
+	Parent  string
+	Message string
+
+	AuthorName  string
+	AuthorEmail string
+
+	// Files holds the contents of every file in the repository at the
+	// commit, keyed by slash-separated path.
+	Files map[string]string
+}
+
+// PullRequest is a pull request opened in the repository.
+type PullRequest struct {
+	Number int
+	URL    string
+	Title  string
+	Body   string
+	Head   string
+	Base   string
+	Closed bool
+}
+
+// Client implements the client.Client interface, holding the repository in
+// memory. It is safe for concurrent use.
+type Client struct {
+	mu       sync.Mutex
+	branches map[string]string
+	commits  map[string]*Commit
+	pulls    []*PullRequest
+	releases []client.Release
+	count    int
+}
+
+// New creates a new Client, with an initial commit on the default branch
+// holding the given files.
+func New(files map[string]string) *Client {
+	c := &Client{
+		branches: map[string]string{},
+		commits:  map[string]*Commit{},
+	}
+	snapshot := map[string]string{}
+	for p, contents := range files {
+		snapshot[p] = contents
+	}
+	initial := c.commit("", "Initial commit", "", "", snapshot)
+	c.branches[DefaultBranch] = initial.SHA
+	return c
+}
+
+// FromDir creates a new Client, with an initial commit on the default branch
+// holding the files in the directory, e.g. a checkout of the chart
+// repository. The .git directory is skipped.
+func FromDir(dir string) (*Client, error) {
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return New(files), nil
+}
+
+// AddRelease adds a published release, which is returned by GetLatestRelease
+// and GetReleaseByTag.
+func (c *Client) AddRelease(release client.Release) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.releases = append(c.releases, release)
+}
+
+// Branch gets the SHA of the commit the branch points to.
+func (c *Client) Branch(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sha, ok := c.branches[name]
+	return sha, ok
+}
+
+// Branches gets the names of the branches in the repository, sorted.
+func (c *Client) Branches() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.branches))
+	for name := range c.branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// File gets the contents of a file on a branch.
+func (c *Client) File(branch, path string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sha, ok := c.branches[branch]
+	if !ok {
+		return "", false
+	}
+	contents, ok := c.commits[sha].Files[path]
+	return contents, ok
+}
+
+// Log gets the commits on a branch, newest first.
+func (c *Client) Log(branch string) []Commit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var commits []Commit
+	for sha := c.branches[branch]; sha != ""; {
+		commit := c.commits[sha]
+		commits = append(commits, *commit)
+		sha = commit.Parent
+	}
+	return commits
+}
+
+// PullRequests gets the pull requests opened in the repository, in the order
+// they were opened.
+func (c *Client) PullRequests() []PullRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pulls := make([]PullRequest, 0, len(c.pulls))
+	for _, pr := range c.pulls {
+		pulls = append(pulls, *pr)
+	}
+	return pulls
+}
+
+// branchName gets the branch name from a ref, e.g. "refs/heads/master".
+func branchName(ref string) string {
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
+
+// commit creates a commit. The caller must hold the lock.
+func (c *Client) commit(parent, msg, name, email string, files map[string]string) *Commit {
+	c.count++
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "%d\x00%s\x00%s", c.count, parent, msg)
+	commit := &Commit{
+		SHA:         hex.EncodeToString(h.Sum(nil)),
+		Parent:      parent,
+		Message:     msg,
+		AuthorName:  name,
+		AuthorEmail: email,
+		Files:       files,
+	}
+	c.commits[commit.SHA] = commit
+	return commit
+}
+
+// GetFile gets the contents of the file from the default branch. Like the
+// GitHub client, the Ref and Base of the options are not used.
+func (c *Client) GetFile(ctx context.Context, opts *client.Options, path string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sha := c.branches[DefaultBranch]
+	log.WithFields(log.Fields{
+		"path":   path,
+		"commit": sha,
+	}).Debug("memory client: getting file")
+
+	contents, ok := c.commits[sha].Files[path]
+	if !ok {
+		return "", client.ErrFileNotFound
+	}
+	return contents, nil
+}
+
+// UpdateFile commits new contents for an existing file to the branch for the
+// Ref. The SHA of the commit is returned.
+func (c *Client) UpdateFile(ctx context.Context, opts *client.Options, path string, msg string, contents []byte) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	branch := branchName(opts.Ref)
+	head, ok := c.branches[branch]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrBranchNotFound, branch)
+	}
+	if _, ok := c.commits[head].Files[path]; !ok {
+		return "", client.ErrFileNotFound
+	}
+
+	files := map[string]string{}
+	for p, data := range c.commits[head].Files {
+		files[p] = data
+	}
+	files[path] = string(contents)
+
+	commit := c.commit(head, msg, opts.AuthorName, opts.AuthorEmail, files)
+	c.branches[branch] = commit.SHA
+	log.WithFields(log.Fields{
+		"path":   path,
+		"branch": branch,
+		"commit": commit.SHA,
+	}).Debug("memory client: updated file")
+	return commit.SHA, nil
+}
+
+// CreateRef creates the branch for the Ref from the Base branch.
+func (c *Client) CreateRef(ctx context.Context, opts *client.Options) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	branch, base := branchName(opts.Ref), branchName(opts.Base)
+	sha, ok := c.branches[base]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBranchNotFound, base)
+	}
+	if _, exists := c.branches[branch]; exists {
+		return fmt.Errorf("%w: %s", ErrBranchExists, branch)
+	}
+	c.branches[branch] = sha
+	return nil
+}
+
+// DeleteRef deletes the branch for the Ref.
+func (c *Client) DeleteRef(ctx context.Context, opts *client.Options) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	branch := branchName(opts.Ref)
+	if _, ok := c.branches[branch]; !ok {
+		return fmt.Errorf("%w: %s", ErrBranchNotFound, branch)
+	}
+	delete(c.branches, branch)
+	return nil
+}
+
+// CreatePullRequest opens a pull request to merge the branch for the Ref
+// into the Base branch.
+func (c *Client) CreatePullRequest(ctx context.Context, opts *client.Options, title, body string) (*client.PullRequest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	head, base := branchName(opts.Ref), branchName(opts.Base)
+	for _, b := range []string{head, base} {
+		if _, ok := c.branches[b]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrBranchNotFound, b)
+		}
+	}
+	if c.branches[head] == c.branches[base] {
+		return nil, fmt.Errorf("%w: %s and %s", ErrNoChanges, base, head)
+	}
+	for _, pr := range c.pulls {
+		if pr.Head == head && !pr.Closed {
+			return nil, fmt.Errorf("%w: %s", ErrPullRequestExists, head)
+		}
+	}
+
+	pr := &PullRequest{
+		Number: len(c.pulls) + 1,
+		Title:  title,
+		Body:   body,
+		Head:   head,
+		Base:   base,
+	}
+	pr.URL = fmt.Sprintf("memory://%s/%s/pull/%d", opts.RepoOwner, opts.RepoName, pr.Number)
+	c.pulls = append(c.pulls, pr)
+	return &client.PullRequest{
+		Number: pr.Number,
+		URL:    pr.URL,
+	}, nil
+}
+
+// ClosePullRequest closes the pull request with the number.
+func (c *Client) ClosePullRequest(ctx context.Context, opts *client.Options, number int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if number < 1 || number > len(c.pulls) {
+		return fmt.Errorf("%w: #%d", ErrPullRequestNotFound, number)
+	}
+	c.pulls[number-1].Closed = true
+	return nil
+}
+
+// GetLatestRelease gets the most recently published release.
+func (c *Client) GetLatestRelease(ctx context.Context, opts *client.Options) (*client.Release, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var latest *client.Release
+	for i := range c.releases {
+		r := c.releases[i]
+		if latest == nil || r.PublishedAt.After(latest.PublishedAt) {
+			latest = &r
+		}
+	}
+	if latest == nil {
+		return nil, client.ErrReleaseNotFound
+	}
+	return latest, nil
+}
+
+// GetReleaseByTag gets the published release for the tag.
+func (c *Client) GetReleaseByTag(ctx context.Context, opts *client.Options, tag string) (*client.Release, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range c.releases {
+		if r.Tag == tag {
+			release := r
+			return &release, nil
+		}
+	}
+	return nil, client.ErrReleaseNotFound
+}
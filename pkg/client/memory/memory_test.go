@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOptions(ref string) *client.Options {
+	return &client.Options{
+		RepoOwner:   "example",
+		RepoName:    "charts",
+		Ref:         ref,
+		Base:        "master",
+		AuthorName:  "releaser",
+		AuthorEmail: "releaser@example.com",
+	}
+}
+
+func newTestClient() *Client {
+	return New(map[string]string{
+		"charts/foo/Chart.yaml": "version: 0.1.0\n",
+		"README.md":             "# charts\n",
+	})
+}
+
+func TestNew(t *testing.T) {
+	var c client.Client = newTestClient()
+	assert.NotNil(t, c)
+
+	m := c.(*Client)
+	assert.Equal(t, []string{"master"}, m.Branches())
+	assert.Len(t, m.Log("master"), 1)
+	contents, ok := m.File("master", "README.md")
+	assert.True(t, ok)
+	assert.Equal(t, "# charts\n", contents)
+}
+
+func TestFromDir(t *testing.T) {
+	dir := t.TempDir()
+	for path, contents := range map[string]string{
+		"charts/foo/Chart.yaml": "version: 0.1.0\n",
+		".git/HEAD":             "ref: refs/heads/master\n",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(path))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, ioutil.WriteFile(p, []byte(contents), 0644))
+	}
+
+	c, err := FromDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"charts/foo/Chart.yaml": "version: 0.1.0\n"}, c.Log("master")[0].Files)
+}
+
+func TestFromDir_NotExist(t *testing.T) {
+	_, err := FromDir(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestClient_GetFile(t *testing.T) {
+	c := newTestClient()
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+
+	contents, err := c.GetFile(context.Background(), opts, "charts/foo/Chart.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 0.1.0\n", contents)
+
+	// Like the GitHub client, files are always read from the default branch,
+	// not the branch for the Ref.
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	_, err = c.UpdateFile(context.Background(), opts, "charts/foo/Chart.yaml", "bump", []byte("version: 0.2.0\n"))
+	assert.NoError(t, err)
+
+	contents, err = c.GetFile(context.Background(), opts, "charts/foo/Chart.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 0.1.0\n", contents)
+
+	_, err = c.GetFile(context.Background(), opts, "charts/bar/Chart.yaml")
+	assert.Equal(t, client.ErrFileNotFound, err)
+}
+
+func TestClient_UpdateFile(t *testing.T) {
+	c := newTestClient()
+	base, _ := c.Branch("master")
+	opts := newTestOptions("refs/heads/chartreleaser/foo/0.2.0")
+
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	sha, err := c.UpdateFile(context.Background(), opts, "charts/foo/Chart.yaml", "bump foo", []byte("version: 0.2.0\n"))
+	assert.NoError(t, err)
+
+	head, _ := c.Branch("chartreleaser/foo/0.2.0")
+	assert.Equal(t, sha, head)
+	assert.Equal(t, []Commit{
+		{
+			SHA:         sha,
+			Parent:      base,
+			Message:     "bump foo",
+			AuthorName:  "releaser",
+			AuthorEmail: "releaser@example.com",
+			Files: map[string]string{
+				"charts/foo/Chart.yaml": "version: 0.2.0\n",
+				"README.md":             "# charts\n",
+			},
+		},
+	}, c.Log("chartreleaser/foo/0.2.0")[:1])
+
+	// The base branch is unchanged.
+	contents, _ := c.File("master", "charts/foo/Chart.yaml")
+	assert.Equal(t, "version: 0.1.0\n", contents)
+}
+
+func TestClient_UpdateFileErrors(t *testing.T) {
+	c := newTestClient()
+
+	_, err := c.UpdateFile(context.Background(), newTestOptions("missing"), "README.md", "msg", nil)
+	assert.True(t, errors.Is(err, ErrBranchNotFound))
+
+	_, err = c.UpdateFile(context.Background(), newTestOptions("master"), "missing.md", "msg", nil)
+	assert.Equal(t, client.ErrFileNotFound, err)
+}
+
+func TestClient_Refs(t *testing.T) {
+	c := newTestClient()
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+	assert.Equal(t, []string{"chartreleaser/foo/0.2.0", "master"}, c.Branches())
+
+	err := c.CreateRef(context.Background(), opts)
+	assert.True(t, errors.Is(err, ErrBranchExists))
+
+	assert.NoError(t, c.DeleteRef(context.Background(), opts))
+	assert.Equal(t, []string{"master"}, c.Branches())
+
+	err = c.DeleteRef(context.Background(), opts)
+	assert.True(t, errors.Is(err, ErrBranchNotFound))
+
+	opts.Base = "develop"
+	err = c.CreateRef(context.Background(), opts)
+	assert.EqualError(t, err, "branch not found in memory repo: develop")
+}
+
+func TestClient_PullRequests(t *testing.T) {
+	c := newTestClient()
+	opts := newTestOptions("chartreleaser/foo/0.2.0")
+	assert.NoError(t, c.CreateRef(context.Background(), opts))
+
+	_, err := c.CreatePullRequest(context.Background(), opts, "Bump foo", "")
+	assert.True(t, errors.Is(err, ErrNoChanges))
+
+	_, err = c.UpdateFile(context.Background(), opts, "charts/foo/Chart.yaml", "bump", []byte("version: 0.2.0\n"))
+	assert.NoError(t, err)
+
+	pr, err := c.CreatePullRequest(context.Background(), opts, "Bump foo", "Bumps foo to 0.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, &client.PullRequest{Number: 1, URL: "memory://example/charts/pull/1"}, pr)
+
+	_, err = c.CreatePullRequest(context.Background(), opts, "Bump foo", "")
+	assert.True(t, errors.Is(err, ErrPullRequestExists))
+
+	assert.NoError(t, c.ClosePullRequest(context.Background(), opts, 1))
+	assert.Equal(t, []PullRequest{
+		{
+			Number: 1,
+			URL:    "memory://example/charts/pull/1",
+			Title:  "Bump foo",
+			Body:   "Bumps foo to 0.2.0",
+			Head:   "chartreleaser/foo/0.2.0",
+			Base:   "master",
+			Closed: true,
+		},
+	}, c.PullRequests())
+
+	err = c.ClosePullRequest(context.Background(), opts, 2)
+	assert.True(t, errors.Is(err, ErrPullRequestNotFound))
+}
+
+func TestClient_Releases(t *testing.T) {
+	c := newTestClient()
+
+	_, err := c.GetLatestRelease(context.Background(), newTestOptions("master"))
+	assert.Equal(t, client.ErrReleaseNotFound, err)
+
+	c.AddRelease(client.Release{Tag: "v0.2.0", PublishedAt: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)})
+	c.AddRelease(client.Release{Tag: "v0.1.0", PublishedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})
+
+	release, err := c.GetLatestRelease(context.Background(), newTestOptions("master"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.2.0", release.Tag)
+
+	release, err = c.GetReleaseByTag(context.Background(), newTestOptions("master"), "v0.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.1.0", release.Tag)
+
+	_, err = c.GetReleaseByTag(context.Background(), newTestOptions("master"), "v9.9.9")
+	assert.Equal(t, client.ErrReleaseNotFound, err)
+}
@@ -0,0 +1,315 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// Defaults for retrying requests.
+const (
+	DefaultMaxRetries     = 3
+	DefaultMinBackoff     = 1 * time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+// RetryOptions configures how requests made by a client are retried. The
+// zero value makes each request once, with no timeout other than that of
+// the request context.
+type RetryOptions struct {
+	// MaxRetries is the number of times a failed request is retried.
+	MaxRetries int
+
+	// MinBackoff is the time waited before the first retry. The wait doubles
+	// for each retry after that, up to MaxBackoff, with jitter added so that
+	// concurrent clients do not retry in lockstep. Rate limited requests
+	// which would need to wait longer than MaxBackoff are not retried.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// RequestTimeout limits the time taken by each attempt of a request. It
+	// is bounded by the deadline of the request context, if any.
+	RequestTimeout time.Duration
+}
+
+// DefaultRetryOptions gets the RetryOptions used if none are configured.
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxRetries:     DefaultMaxRetries,
+		MinBackoff:     DefaultMinBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		RequestTimeout: DefaultRequestTimeout,
+	}
+}
+
+// retryTransport is an http.RoundTripper which retries failed requests.
+//
+// Requests which were rate limited are retried for any method, since they
+// were never processed, unless the wait is longer than the MaxBackoff. Server
+// errors and network errors are only retried for requests which are safe to
+// repeat, since the request may have been processed.
+type retryTransport struct {
+	base http.RoundTripper
+	opts RetryOptions
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newRetryTransport creates a retryTransport which makes requests with the
+// given base transport. If base is nil, http.DefaultTransport is used.
+func newRetryTransport(base http.RoundTripper, opts RetryOptions) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:  base,
+		opts:  opts,
+		now:   time.Now,
+		sleep: sleep,
+	}
+}
+
+// RoundTrip makes the request, retrying it if it fails.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A request body can only be sent again if it can be replayed.
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.WithContext(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.roundTrip(req)
+		if resp != nil {
+			logRateLimit(req, resp)
+		}
+
+		wait, retry := t.shouldRetry(req, resp, err, attempt)
+		if !retry || !replayable || attempt >= t.opts.MaxRetries {
+			return resp, err
+		}
+
+		// Fail fast rather than wait out a long rate limit, e.g. for the
+		// primary rate limit, which may not reset for up to an hour.
+		if t.opts.MaxBackoff > 0 && wait > t.opts.MaxBackoff {
+			return resp, err
+		}
+
+		// Do not wait past the deadline for the request, e.g. for a rate
+		// limit which resets after the update has timed out.
+		if deadline, ok := req.Context().Deadline(); ok && t.now().Add(wait).After(deadline) {
+			return resp, err
+		}
+
+		fields := log.Fields{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+		}
+		if err != nil {
+			fields["error"] = err
+		} else {
+			fields["status"] = resp.StatusCode
+			drain(resp)
+		}
+		log.WithFields(fields).Warn("github client: request failed, retrying")
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// roundTrip makes a single attempt of the request, limited by the request
+// timeout. The timeout is released once the response body is closed.
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.opts.RequestTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.opts.RequestTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// shouldRetry checks whether a request should be retried, and if so, how
+// long to wait before retrying it.
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		// The request was cancelled or timed out as a whole, not just the
+		// attempt, so there is no time left to retry it.
+		if req.Context().Err() != nil {
+			return 0, false
+		}
+		return t.backoff(attempt), isRepeatable(req)
+	}
+
+	if wait, limited := t.rateLimitWait(resp); limited {
+		return wait, true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return t.backoff(attempt), isRepeatable(req)
+	}
+	return 0, false
+}
+
+// rateLimitWait checks whether the response is for a rate limited request,
+// and if so, how long to wait before retrying it. The Retry-After header is
+// used if set, e.g. for secondary rate limits, otherwise the time until the
+// rate limit resets is used.
+func (t *retryTransport) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return nonNegative(at.Sub(t.now())), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// Allow a second for clock skew, so the retry is not made just
+			// before the reset.
+			return nonNegative(time.Unix(reset, 0).Sub(t.now())) + time.Second, true
+		}
+	}
+
+	// A 403 without rate limit headers is a permissions error, which will
+	// not succeed if retried.
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return t.opts.MinBackoff, true
+	}
+	return 0, false
+}
+
+// backoff gets the time to wait before retrying after the given attempt,
+// doubling for each attempt, with up to half of it as random jitter.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	d := t.opts.MinBackoff
+	for i := 0; i < attempt && d < t.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if t.opts.MaxBackoff > 0 && d > t.opts.MaxBackoff {
+		d = t.opts.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(jitter(int64(half)+1))
+}
+
+// random is the source of jitter for backoffs. It is seeded so that clients
+// started at the same time do not retry in lockstep.
+var random = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// jitter gets a random duration in [0, n).
+func jitter(n int64) int64 {
+	random.Lock()
+	defer random.Unlock()
+	return random.Int63n(n)
+}
+
+// isRepeatable checks whether the request can safely be made more than once.
+//
+// Updating a file with the contents API is a PUT, but is not idempotent: it
+// sends the sha of the file being replaced, so if the first attempt was
+// processed, a retry fails with a 409 Conflict.
+func isRepeatable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPut:
+		return !strings.Contains(req.URL.Path, "/contents/")
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// logRateLimit logs the remaining rate limit quota reported by the response.
+func logRateLimit(req *http.Request, resp *http.Response) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+	fields := log.Fields{
+		"method":    req.Method,
+		"path":      req.URL.Path,
+		"limit":     resp.Header.Get("X-RateLimit-Limit"),
+		"remaining": remaining,
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		fields["reset"] = time.Unix(reset, 0).Format(time.RFC3339)
+	}
+	log.WithFields(fields).Debug("github client: rate limit")
+}
+
+// drain reads the rest of the response body and closes it, so that the
+// connection can be reused for the retry.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+}
+
+// sleep waits for the duration, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// cancelBody cancels the context for a request once its response body is
+// closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close the body and cancel the request context.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTransport creates a retryTransport which records the time waited
+// before each retry, rather than sleeping.
+func newTestTransport(opts RetryOptions, now time.Time) (*retryTransport, *[]time.Duration) {
+	var waits []time.Duration
+	t := newRetryTransport(nil, opts)
+	t.now = func() time.Time { return now }
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return t, &waits
+}
+
+// newTestServer creates a server which responds with the handler for each
+// attempt in turn, counting the requests made.
+func newTestServer(t *testing.T, handlers ...http.HandlerFunc) (*httptest.Server, *int32) {
+	var count int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := atomic.AddInt32(&count, 1) - 1
+		if int(i) >= len(handlers) {
+			t.Errorf("unexpected request %d", i+1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		handlers[i](w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s, &count
+}
+
+func status(code int, headers ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func do(t *testing.T, rt http.RoundTripper, method, url, body string) *http.Response {
+	var req *http.Request
+	var err error
+	if body != "" {
+		req, err = http.NewRequest(method, url, strings.NewReader(body))
+	} else {
+		req, err = http.NewRequest(method, url, nil)
+	}
+	assert.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	assert.NoError(t, err)
+	return resp
+}
+
+func TestRetryTransport_ServerError(t *testing.T) {
+	s, count := newTestServer(t, status(502), status(503), status(200))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3, MinBackoff: time.Second, MaxBackoff: 10 * time.Second}, time.Now())
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, int32(3), *count)
+	assert.Len(t, *waits, 2)
+	assert.True(t, (*waits)[0] >= 500*time.Millisecond && (*waits)[0] <= time.Second, (*waits)[0])
+	assert.True(t, (*waits)[1] >= time.Second && (*waits)[1] <= 2*time.Second, (*waits)[1])
+}
+
+func TestRetryTransport_ServerErrorNotIdempotent(t *testing.T) {
+	s, count := newTestServer(t, status(502))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3}, time.Now())
+
+	resp := do(t, rt, http.MethodPost, s.URL, "{}")
+	assert.Equal(t, 502, resp.StatusCode)
+	assert.Equal(t, int32(1), *count)
+	assert.Empty(t, *waits)
+}
+
+func TestRetryTransport_ServerErrorUpdateFile(t *testing.T) {
+	s, count := newTestServer(t, status(502))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3}, time.Now())
+
+	resp := do(t, rt, http.MethodPut, s.URL+"/repos/example/charts/contents/charts/foo/Chart.yaml", "{}")
+	assert.Equal(t, 502, resp.StatusCode)
+	assert.Equal(t, int32(1), *count)
+	assert.Empty(t, *waits)
+}
+
+func TestRetryTransport_MaxRetries(t *testing.T) {
+	s, count := newTestServer(t, status(502), status(502), status(502))
+	rt, _ := newTestTransport(RetryOptions{MaxRetries: 2}, time.Now())
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 502, resp.StatusCode)
+	assert.Equal(t, int32(3), *count)
+}
+
+func TestRetryTransport_NoRetries(t *testing.T) {
+	s, count := newTestServer(t, status(502))
+	rt, _ := newTestTransport(RetryOptions{}, time.Now())
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 502, resp.StatusCode)
+	assert.Equal(t, int32(1), *count)
+}
+
+func TestRetryTransport_RetryAfter(t *testing.T) {
+	s, count := newTestServer(t, status(403, "Retry-After", "7"), status(201))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3}, time.Now())
+
+	// Rate limited requests are retried for any method.
+	resp := do(t, rt, http.MethodPost, s.URL, "{}")
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, int32(2), *count)
+	assert.Equal(t, []time.Duration{7 * time.Second}, *waits)
+}
+
+func TestRetryTransport_RetryAfterDate(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, _ := newTestServer(t, status(429, "Retry-After", now.Add(time.Minute).Format(http.TimeFormat)), status(200))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3}, now)
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, []time.Duration{time.Minute}, *waits)
+}
+
+func TestRetryTransport_RateLimitReset(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	reset := strconv.FormatInt(now.Add(30*time.Second).Unix(), 10)
+	s, _ := newTestServer(t, status(403, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", reset), status(200))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3}, now)
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, []time.Duration{31 * time.Second}, *waits)
+}
+
+func TestRetryTransport_RateLimitPastMaxBackoff(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	reset := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+	s, count := newTestServer(t, status(403, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", reset))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3, MaxBackoff: 30 * time.Second}, now)
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 403, resp.StatusCode)
+	assert.Equal(t, int32(1), *count)
+	assert.Empty(t, *waits)
+}
+
+func TestRetryTransport_RetryAfterPastMaxBackoff(t *testing.T) {
+	s, count := newTestServer(t, status(429, "Retry-After", "60"))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3, MaxBackoff: 30 * time.Second}, time.Now())
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, int32(1), *count)
+	assert.Empty(t, *waits)
+}
+
+func TestRetryTransport_Forbidden(t *testing.T) {
+	s, count := newTestServer(t, status(403))
+	rt, _ := newTestTransport(RetryOptions{MaxRetries: 3}, time.Now())
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 403, resp.StatusCode)
+	assert.Equal(t, int32(1), *count)
+}
+
+func TestRetryTransport_RateLimitPastDeadline(t *testing.T) {
+	now := time.Now()
+	s, count := newTestServer(t, status(403, "Retry-After", "3600"))
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3}, now)
+
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(time.Minute))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	assert.NoError(t, err)
+
+	resp, err := rt.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+	assert.Equal(t, int32(1), *count)
+	assert.Empty(t, *waits)
+}
+
+func TestRetryTransport_ReplaysBody(t *testing.T) {
+	var bodies []string
+	record := func(code int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			data, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(data))
+			w.WriteHeader(code)
+		}
+	}
+	s, _ := newTestServer(t, record(502), record(200))
+	rt, _ := newTestTransport(RetryOptions{MaxRetries: 3}, time.Now())
+
+	resp := do(t, rt, http.MethodPut, s.URL, `{"content":"abc"}`)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, []string{`{"content":"abc"}`, `{"content":"abc"}`}, bodies)
+}
+
+func TestRetryTransport_RequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	hang := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}
+	s, count := newTestServer(t, hang, status(200))
+	rt, _ := newTestTransport(RetryOptions{MaxRetries: 1, RequestTimeout: 50 * time.Millisecond}, time.Now())
+
+	resp := do(t, rt, http.MethodGet, s.URL, "")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, int32(2), *count)
+	assert.NoError(t, resp.Body.Close())
+}
+
+func TestRetryTransport_Cancelled(t *testing.T) {
+	rt, waits := newTestTransport(RetryOptions{MaxRetries: 3}, time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:0", nil)
+	assert.NoError(t, err)
+
+	_, err = rt.RoundTrip(req)
+	assert.Error(t, err)
+	assert.Empty(t, *waits)
+}
+
+func TestRetryTransport_Backoff(t *testing.T) {
+	rt := newRetryTransport(nil, RetryOptions{MinBackoff: time.Second, MaxBackoff: 4 * time.Second})
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		d := rt.backoff(attempt)
+		assert.True(t, d >= max/2 && d <= max, "attempt %d: %s", attempt, d)
+	}
+}
@@ -52,7 +52,8 @@ func newCheckCommand() *checkCmd {
 			switch v.GetVersion() {
 			case v1.ConfigVersion():
 				err = v1.NewChecker(v.GetData()).Run(v1.CheckerOptions{
-					SkipEnv: root.skipEnv,
+					ConfigPath: v.GetPath(),
+					SkipEnv:    root.skipEnv,
 				})
 			default:
 				err = fmt.Errorf("unsupported config version: %s", v.GetVersion())
@@ -6,7 +6,6 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"text/template"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/apex/log"
@@ -75,7 +74,7 @@ func newInitCommand() *initCmd {
 				return err
 			}
 
-			tmpl, err := template.New("init").Parse(templates.ConfigFileTemplate)
+			tmpl, err := templates.New("init").Parse(templates.ConfigFileTemplate)
 			if err != nil {
 				return err
 			}
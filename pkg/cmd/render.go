@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/config"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1"
+	"github.com/spf13/cobra"
+)
+
+type renderCmd struct {
+	c *cobra.Command
+
+	appVersion           string
+	chartVersion         string
+	previousAppVersion   string
+	previousChartVersion string
+	output               string
+}
+
+func newRenderCommand() *renderCmd {
+	root := &renderCmd{}
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Preview the templated outputs for a hypothetical release",
+		Long: heredoc.Doc(`
+			This command renders the branch, base, commit messages, and pull request
+			title and body which would be used when updating the Helm Chart for a
+			release, without making any changes.
+
+			Versions which are not provided are discovered from the local git
+			repository where possible. The previous chart version is normally read
+			from the remote chart repository; since this command runs offline, it
+			defaults to 0.1.0 unless set with --previous-chart-version.
+
+			If no path is specified, this will look for .chartreleaser.yml in the
+			current working directory.
+		`),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.WithFields(log.Fields{
+				"cmd": "render",
+			}).Debug("running command")
+
+			var p string
+			if len(args) == 1 {
+				p = args[0]
+			}
+
+			v, err := config.Load(p)
+			if err != nil {
+				return err
+			}
+
+			switch v.GetVersion() {
+			case v1.ConfigVersion():
+				return v1.NewRenderer(v.GetData()).Run(v1.RenderOptions{
+					AppVersion:           root.appVersion,
+					ChartVersion:         root.chartVersion,
+					ConfigPath:           v.GetPath(),
+					PreviousAppVersion:   root.previousAppVersion,
+					PreviousChartVersion: root.previousChartVersion,
+					Output:               root.output,
+					Out:                  cmd.OutOrStdout(),
+				})
+			default:
+				return fmt.Errorf("unsupported config version: %s", v.GetVersion())
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&root.appVersion, "app-version", "", "the new application version, overriding the configured version source")
+	cmd.Flags().StringVar(&root.chartVersion, "chart-version", "", "the new chart version (default: determined by the release strategy)")
+	cmd.Flags().StringVar(&root.previousAppVersion, "previous-app-version", "", "the previous application version (default: determined from git tags)")
+	cmd.Flags().StringVar(&root.previousChartVersion, "previous-chart-version", "", "the previous chart version (default: 0.1.0)")
+	cmd.Flags().StringVarP(&root.output, "output", "o", "text", "the output format (text, json)")
+
+	root.c = cmd
+	return root
+}
@@ -1,16 +1,39 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/logging"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+// Options for running the command line tool which are not exposed as flags.
+type Options struct {
+	// GitHubAPIURL is the URL of the GitHub API used by commands. If empty,
+	// the public GitHub API is used. End-to-end tests set it to the URL of a
+	// fake GitHub API server. It is deliberately not configurable by users,
+	// since the GitHub token is sent to it.
+	GitHubAPIURL string
+}
+
 // Execute is the entry point for the command line tool. It runs the root command.
 func Execute(exiter func(int), args []string) {
-	root := newRootCommand(exiter)
+	ExecuteWithOptions(exiter, args, Options{})
+}
+
+// ExecuteWithOptions runs the root command with the given options.
+func ExecuteWithOptions(exiter func(int), args []string, opts Options) {
+	root := newRootCommand(exiter, opts)
 	root.SetArgs(args)
 
-	if err := root.Execute(); err != nil {
+	err := root.Execute()
+	if root.logFile != nil {
+		_ = root.logFile.Close()
+	}
+	if err != nil {
 		root.exiter(1)
 	}
 }
@@ -18,11 +41,14 @@ func Execute(exiter func(int), args []string) {
 type rootCmd struct {
 	c *cobra.Command
 
-	exiter func(int)
-	debug  bool
+	exiter    func(int)
+	debug     bool
+	logFormat string
+	logPath   string
+	logFile   *os.File
 }
 
-func newRootCommand(exiter func(int)) *rootCmd {
+func newRootCommand(exiter func(int), opts Options) *rootCmd {
 	root := &rootCmd{
 		exiter: exiter,
 	}
@@ -30,21 +56,29 @@ func newRootCommand(exiter func(int)) *rootCmd {
 	cmd := &cobra.Command{
 		Use:   "chart-releaser",
 		Short: "Update Helm Chart versions for new application releases",
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := root.setupLogging(); err != nil {
+				return err
+			}
 			if root.debug {
 				log.SetLevel(log.DebugLevel)
 				log.Debug("debug logging enabled")
 			}
+			return nil
 		},
 	}
 
 	cmd.PersistentFlags().BoolVar(&root.debug, "debug", false, "run chart-releaser with debug logging")
+	cmd.PersistentFlags().StringVar(&root.logFormat, "log-format", logging.FormatText, fmt.Sprintf("the format of log output, one of: %v", logging.Formats))
+	cmd.PersistentFlags().StringVar(&root.logPath, "log-file", "", "write log output to a file instead of stderr, appending to it if it exists")
 
 	cmd.AddCommand(
 		newCheckCommand().c,
 		newFmtCommand().c,
 		newInitCommand().c,
-		newUpdateCommand().c,
+		newRenderCommand().c,
+		newServeCommand(opts.GitHubAPIURL).c,
+		newUpdateCommand(opts.GitHubAPIURL).c,
 		newVersionCommand().c,
 	)
 
@@ -52,6 +86,40 @@ func newRootCommand(exiter func(int)) *rootCmd {
 	return root
 }
 
+// setupLogging sets the log handler for the configured log format and file.
+func (c *rootCmd) setupLogging() error {
+	if !logging.IsValidFormat(c.logFormat) {
+		return fmt.Errorf("unsupported log format '%s', should be one of: %v", c.logFormat, logging.Formats)
+	}
+
+	out := os.Stderr
+	if c.logPath != "" {
+		f, err := os.OpenFile(c.logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		c.logFile = f
+		out = f
+
+		// Colors are only meant for the terminal, not the log file.
+		color.NoColor = true
+	}
+
+	h, err := logging.NewHandler(c.logFormat, out)
+	if err != nil {
+		return err
+	}
+	log.SetHandler(h)
+	return nil
+}
+
+// structuredLogs checks whether the command logs in a structured format, in
+// which case output written to the console should not be colored.
+func structuredLogs(cmd *cobra.Command) bool {
+	format, err := cmd.Flags().GetString("log-format")
+	return err == nil && format != logging.FormatText
+}
+
 // SetArgs is an alias to the underlying cobra.Command's SetArgs.
 func (c *rootCmd) SetArgs(a []string) {
 	c.c.SetArgs(a)
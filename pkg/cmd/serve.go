@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/env"
+	"github.com/edaniszewski/chart-releaser/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+type serveCmd struct {
+	c *cobra.Command
+
+	apiURL  string
+	addr    string
+	secret  string
+	dryRun  bool
+	history int
+	timeout time.Duration
+}
+
+func newServeCommand(apiURL string) *serveCmd {
+	root := &serveCmd{
+		apiURL: apiURL,
+	}
+	cmd := &cobra.Command{
+		Use:   "serve CONFIG",
+		Short: "Run a server which updates Helm Charts for release webhooks",
+		Long: heredoc.Doc(`
+			This command runs an HTTP server which accepts GitHub 'release' and 'create'
+			(tag) webhooks at /webhook, and updates the Helm Chart for the released tag.
+
+			The server configuration maps the source repositories which send webhooks
+			to their chart-releaser configuration, which is either set inline or fetched
+			from the repository's .chartreleaser.yml. Fetching the configuration requires
+			GITHUB_TOKEN to be set.
+
+			Webhook signatures are verified with the secret set by --secret, or the
+			CR_WEBHOOK_SECRET environment variable.
+
+			Updates for the same chart are run one at a time. The most recent runs are
+			reported at /status.
+		`),
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.WithFields(log.Fields{
+				"cmd": "serve",
+			}).Debug("running command")
+
+			cfg, err := server.LoadConfig(args[0])
+			if err != nil {
+				return err
+			}
+			if root.secret == "" {
+				root.secret = os.Getenv(env.WebhookSecret)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			s := server.New(cfg, server.Options{
+				Addr:    root.addr,
+				Secret:  root.secret,
+				DryRun:  root.dryRun,
+				History: root.history,
+				NoColor: structuredLogs(cmd),
+				Timeout: root.timeout,
+			})
+			if token := os.Getenv(env.GithubToken); token != "" {
+				c, err := client.NewGitHubClientWithURL(ctx, root.apiURL, token, client.DefaultRetryOptions())
+				if err != nil {
+					return err
+				}
+				s.WithClient(c)
+			}
+
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sig
+				cancel()
+			}()
+
+			return s.ListenAndServe(ctx)
+		},
+	}
+
+	cmd.Flags().StringVar(&root.addr, "addr", server.DefaultAddr, "the address to listen on")
+	cmd.Flags().StringVar(&root.secret, "secret", "", "the secret used to verify webhook signatures (default $"+env.WebhookSecret+")")
+	cmd.Flags().BoolVar(&root.dryRun, "dry-run", false, "run updates without side effects")
+	cmd.Flags().IntVar(&root.history, "history", server.DefaultHistory, "the number of recent runs to report at /status")
+	cmd.Flags().DurationVar(&root.timeout, "timeout", 5*time.Minute, "timeout for each update")
+
+	root.c = cmd
+	return root
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
 	"github.com/edaniszewski/chart-releaser/pkg/config"
 	v1 "github.com/edaniszewski/chart-releaser/pkg/v1"
 	"github.com/spf13/cobra"
@@ -14,14 +15,33 @@ import (
 type updateCmd struct {
 	c *cobra.Command
 
-	timeout    time.Duration
-	dryRun     bool
-	allowDirty bool
-	diff       bool
+	apiURL      string
+	timeout     time.Duration
+	retries     int
+	backoff     time.Duration
+	reqTimeout  time.Duration
+	dryRun      bool
+	dryRunHooks bool
+	allowDirty  bool
+	diff        bool
+	noRollback  bool
+	confirm     bool
+	yes         bool
+	skipStages  []string
+	onlyStages  []string
+	diffFormat  string
+	diffOutput  string
+	diffContext int
+	appVersion  string
+	localChart  string
+	output      string
+	reportFile  string
 }
 
-func newUpdateCommand() *updateCmd {
-	root := &updateCmd{}
+func newUpdateCommand(apiURL string) *updateCmd {
+	root := &updateCmd{
+		apiURL: apiURL,
+	}
 	cmd := &cobra.Command{
 		Use:   "update",
 		Short: "Update the Helm Chart for a new project release",
@@ -46,9 +66,31 @@ func newUpdateCommand() *updateCmd {
 			switch v.GetVersion() {
 			case v1.ConfigVersion():
 				err = v1.NewUpdater(v.GetData()).Run(v1.UpdateOptions{
-					AllowDirty: root.allowDirty,
-					DryRun:     root.dryRun,
+					AllowDirty:     root.allowDirty,
+					APIURL:         root.apiURL,
+					AppVersion:     root.appVersion,
+					AssumeYes:      root.yes,
+					ConfigPath:     v.GetPath(),
+					Confirm:        root.confirm,
+					DiffContext:    root.diffContext,
+					DiffFormat:     root.diffFormat,
+					DiffOutput:     root.diffOutput,
+					DryRun:         root.dryRun,
+					DryRunHooks:    root.dryRunHooks,
+					LocalChartRepo: root.localChart,
+					NoColor:        structuredLogs(cmd),
+					NoRollback:     root.noRollback,
+					Output:         root.output,
+					ReportFile:     root.reportFile,
+					Retry: client.RetryOptions{
+						MaxRetries:     root.retries,
+						MinBackoff:     root.backoff,
+						MaxBackoff:     client.DefaultMaxBackoff,
+						RequestTimeout: root.reqTimeout,
+					},
 					ShowDiff:   root.diff,
+					SkipStages: root.skipStages,
+					OnlyStages: root.onlyStages,
 					Timeout:    root.timeout,
 				})
 			default:
@@ -63,9 +105,25 @@ func newUpdateCommand() *updateCmd {
 	}
 
 	cmd.Flags().BoolVar(&root.dryRun, "dry-run", false, "run the command without side effects")
+	cmd.Flags().BoolVar(&root.dryRunHooks, "dry-run-hooks", false, "run configured hooks in dry-run mode, which are skipped by default")
 	cmd.Flags().BoolVar(&root.allowDirty, "allow-dirty", false, "do not fail if the git repo is in a dirty state")
+	cmd.Flags().BoolVar(&root.noRollback, "no-rollback", false, "do not roll back changes to the chart repo if publishing fails")
 	cmd.Flags().BoolVar(&root.diff, "diff", false, "show the diff for files modified by the command")
+	cmd.Flags().BoolVar(&root.confirm, "confirm", false, "show the diff and prompt for confirmation before publishing changes")
+	cmd.Flags().BoolVarP(&root.yes, "yes", "y", false, "publish without prompting when --confirm is set, e.g. when not running interactively")
+	cmd.Flags().StringVar(&root.diffFormat, "diff-format", "color", "the format to show the diff in (unified, color, json)")
+	cmd.Flags().StringVar(&root.diffOutput, "diff-output", "", "write the diff to the given file, e.g. a patch which can be applied with 'git apply'")
+	cmd.Flags().IntVar(&root.diffContext, "diff-context", 3, "the number of unchanged lines to show around each change in the diff")
+	cmd.Flags().StringVar(&root.appVersion, "app-version", "", "the new application version, overriding the configured version source")
+	cmd.Flags().StringVar(&root.localChart, "local-chart-repo", "", "read the chart from a local checkout of the chart repo instead of the remote (requires --dry-run)")
+	cmd.Flags().StringVarP(&root.output, "output", "o", "text", "the output format (text, json). json writes a report of the run to stdout")
+	cmd.Flags().StringVar(&root.reportFile, "report-file", "", "write a JSON report of the run to the given file")
+	cmd.Flags().StringSliceVar(&root.skipStages, "skip-stage", nil, "skip the named stage of the update pipeline (may be repeated)")
+	cmd.Flags().StringSliceVar(&root.onlyStages, "only-stage", nil, "only run the named stage of the update pipeline (may be repeated)")
 	cmd.Flags().DurationVar(&root.timeout, "timeout", 5*time.Minute, "timeout for the entire update process")
+	cmd.Flags().IntVar(&root.retries, "retries", client.DefaultMaxRetries, "the number of times a failed request to the chart repo is retried")
+	cmd.Flags().DurationVar(&root.backoff, "retry-backoff", client.DefaultMinBackoff, "the time to wait before the first retry, doubling for each retry after that")
+	cmd.Flags().DurationVar(&root.reqTimeout, "request-timeout", client.DefaultRequestTimeout, "timeout for each request to the chart repo, bounded by --timeout")
 
 	root.c = cmd
 	return root
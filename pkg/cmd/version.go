@@ -37,7 +37,7 @@ func newVersionCommand() *versionCmd {
 			var versionBuffer = new(bytes.Buffer)
 			var latestBuffer = new(bytes.Buffer)
 
-			t := template.Must(template.New("version").Parse(templates.CommandVersionTemplate))
+			t := template.Must(templates.New("version").Parse(templates.CommandVersionTemplate))
 			if err := t.Execute(versionBuffer, pkg.NewVersionInfo()); err != nil {
 				return err
 			}
@@ -66,7 +66,7 @@ func newVersionCommand() *versionCmd {
 					Installed: pkg.Version,
 				}
 
-				t := template.Must(template.New("latest-version").Parse(templates.FlagLatestVersionTemplate))
+				t := template.Must(templates.New("latest-version").Parse(templates.FlagLatestVersionTemplate))
 				if err := t.Execute(latestBuffer, &vs); err != nil {
 					return err
 				}
@@ -74,6 +74,18 @@ func Load(p string) (*VersionedConfig, error) {
 		return nil, err
 	}
 
+	v, err := LoadFromBytes(contents)
+	if err != nil {
+		return nil, err
+	}
+	v.path = path
+
+	return v, nil
+}
+
+// LoadFromBytes loads config data into a VersionedConfig. Since the config
+// is not loaded from a file, it has no path.
+func LoadFromBytes(contents []byte) (*VersionedConfig, error) {
 	var v VersionedConfig
 	if err := yaml.Unmarshal(contents, &v); err != nil {
 		return nil, err
@@ -82,7 +94,6 @@ func Load(p string) (*VersionedConfig, error) {
 		return nil, ErrNoConfigVersion
 	}
 	v.data = contents
-	v.path = path
 
 	return &v, nil
 }
@@ -96,3 +96,17 @@ func TestLoad_NoVersion(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, ErrNoConfigVersion, err)
 }
+
+func TestLoadFromBytes(t *testing.T) {
+	vc, err := LoadFromBytes([]byte("version: v1\nchart:\n  name: chart\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "v1", vc.GetVersion())
+	assert.Equal(t, "", vc.GetPath())
+	assert.Equal(t, "version: v1\nchart:\n  name: chart\n", string(vc.GetData()))
+}
+
+func TestLoadFromBytes_NoVersion(t *testing.T) {
+	_, err := LoadFromBytes([]byte("chart:\n  name: chart\n"))
+	assert.Equal(t, ErrNoConfigVersion, err)
+}
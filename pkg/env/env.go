@@ -4,4 +4,8 @@ const (
 	// GithubToken is the name of the environment variable used to hold
 	// the value for the GitHub API token.
 	GithubToken = "GITHUB_TOKEN"
+
+	// WebhookSecret is the name of the environment variable used to hold
+	// the secret for verifying webhook signatures in server mode.
+	WebhookSecret = "CR_WEBHOOK_SECRET"
 )
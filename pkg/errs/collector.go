@@ -35,6 +35,11 @@ func (c *Collector) Add(err error) {
 	}
 }
 
+// Errors gets the errors which have been added to the Collector.
+func (c *Collector) Errors() []error {
+	return c.errors
+}
+
 // Error returns the error string for the collector.
 func (c *Collector) Error() string {
 	if c.HasErrors() {
@@ -0,0 +1,95 @@
+// Package logging configures the format and destination of chart-releaser's
+// log output.
+package logging
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/cli"
+	"github.com/apex/log/handlers/json"
+	"github.com/apex/log/handlers/logfmt"
+)
+
+// Log formats supported by NewHandler.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// Formats holds all supported log formats.
+var Formats = []string{FormatText, FormatJSON, FormatLogfmt}
+
+// Fields which are set on every line logged by a stage of the update pipeline.
+const (
+	FieldStage = "stage"
+	FieldRepo  = "repo"
+	FieldRef   = "ref"
+	FieldPath  = "path"
+)
+
+// stageValue is the value of a field set by StageFields. Its type allows the
+// text handler to tell stage fields from fields set by the log line itself.
+type stageValue string
+
+// StageFields gets the fields which identify the stage of the update pipeline
+// a line was logged by, and the repository, ref and path it operates on.
+// Fields which are not known are set to an empty string, so that every line
+// has the same set of fields.
+func StageFields(stage, repo, ref, path string) log.Fields {
+	return log.Fields{
+		FieldStage: stageValue(stage),
+		FieldRepo:  stageValue(repo),
+		FieldRef:   stageValue(ref),
+		FieldPath:  stageValue(path),
+	}
+}
+
+// IsValidFormat checks whether the log format is supported.
+func IsValidFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// NewHandler creates a log handler which writes to w in the given format.
+//
+// The text format is meant to be read on the console, so it leaves out the
+// fields set by StageFields, since each stage is already announced when it
+// starts. The json and logfmt formats write one record per line.
+func NewHandler(format string, w io.Writer) (log.Handler, error) {
+	switch format {
+	case FormatText, "":
+		return &textHandler{handler: cli.New(w)}, nil
+	case FormatJSON:
+		return json.New(w), nil
+	case FormatLogfmt:
+		return logfmt.New(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format '%s', should be one of: %v", format, Formats)
+	}
+}
+
+// textHandler wraps a handler, removing the fields set by StageFields from
+// each entry.
+type textHandler struct {
+	handler log.Handler
+}
+
+// HandleLog implements log.Handler.
+func (h *textHandler) HandleLog(e *log.Entry) error {
+	fields := log.Fields{}
+	for k, v := range e.Fields {
+		if _, ok := v.(stageValue); !ok {
+			fields[k] = v
+		}
+	}
+	entry := *e
+	entry.Fields = fields
+	return h.handler.HandleLog(&entry)
+}
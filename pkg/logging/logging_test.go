@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger(t *testing.T, format string) (*log.Logger, *bytes.Buffer) {
+	buf := bytes.Buffer{}
+	h, err := NewHandler(format, &buf)
+	assert.NoError(t, err)
+	return &log.Logger{Handler: h, Level: log.InfoLevel}, &buf
+}
+
+func TestIsValidFormat(t *testing.T) {
+	for _, f := range Formats {
+		assert.True(t, IsValidFormat(f), f)
+	}
+	assert.False(t, IsValidFormat("xml"))
+	assert.False(t, IsValidFormat(""))
+}
+
+func TestNewHandler_Unsupported(t *testing.T) {
+	h, err := NewHandler("xml", &bytes.Buffer{})
+	assert.EqualError(t, err, "unsupported log format 'xml', should be one of: [text json logfmt]")
+	assert.Nil(t, h)
+}
+
+func TestNewHandler_Text(t *testing.T) {
+	l, buf := newTestLogger(t, FormatText)
+
+	l.WithFields(StageFields("chart", "example/charts", "master", "charts/foo")).
+		WithField("version", "0.1.1").
+		Info("updated chart")
+	assert.Contains(t, buf.String(), "updated chart")
+	assert.Contains(t, buf.String(), "version")
+	assert.NotContains(t, buf.String(), "example/charts")
+}
+
+func TestNewHandler_TextOverridesStageField(t *testing.T) {
+	l, buf := newTestLogger(t, FormatText)
+
+	// Fields set by the line itself are kept, even if they share the name of
+	// a stage field.
+	l.WithFields(StageFields("extras", "example/charts", "master", "charts/foo")).
+		WithField(FieldPath, "charts/foo/README.md").
+		Info("updated file")
+	assert.Contains(t, buf.String(), "charts/foo/README.md")
+	assert.NotContains(t, buf.String(), "extras")
+}
+
+func TestNewHandler_JSON(t *testing.T) {
+	l, buf := newTestLogger(t, FormatJSON)
+
+	l.WithFields(StageFields("chart", "example/charts", "", "charts/foo")).Info("updated chart")
+
+	var line struct {
+		Level   string                 `json:"level"`
+		Message string                 `json:"message"`
+		Fields  map[string]interface{} `json:"fields"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "info", line.Level)
+	assert.Equal(t, "updated chart", line.Message)
+	assert.Equal(t, map[string]interface{}{
+		"stage": "chart",
+		"repo":  "example/charts",
+		"ref":   "",
+		"path":  "charts/foo",
+	}, line.Fields)
+}
+
+func TestNewHandler_Logfmt(t *testing.T) {
+	l, buf := newTestLogger(t, FormatLogfmt)
+
+	l.WithFields(StageFields("chart", "example/charts", "", "charts/foo")).Info("updated chart")
+	assert.Contains(t, buf.String(), `level=info message="updated chart" path=charts/foo ref= repo=example/charts stage=chart`)
+}
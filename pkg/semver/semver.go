@@ -28,6 +28,24 @@ const (
 	LevelMajor
 )
 
+// String returns the name of the Level.
+func (l Level) String() string {
+	switch l {
+	case LevelNone:
+		return "none"
+	case LevelPrerelease:
+		return "prerelease"
+	case LevelPatch:
+		return "patch"
+	case LevelMinor:
+		return "minor"
+	case LevelMajor:
+		return "major"
+	default:
+		return fmt.Sprintf("Level(%d)", uint8(l))
+	}
+}
+
 // Semver represents a parsed semantic version.
 type Semver struct {
 	Major      uint64
@@ -86,8 +104,59 @@ func Load(version string) (Semver, error) {
 	}, nil
 }
 
+// Normalize attempts to coerce a loosely formatted version string into a valid
+// semantic version string. Missing minor and patch components are padded with
+// zeros (2024.3 -> 2024.3.0), leading zeros are stripped from numeric components
+// (2024.03.1 -> 2024.3.1), and any components beyond the patch version are moved
+// into the build metadata (1.2.3.4 -> 1.2.3+4).
+//
+// A leading "v" is preserved. If the version core contains non-numeric components,
+// the string is returned unchanged so that parsing fails with a meaningful error.
+func Normalize(version string) string {
+	version = strings.TrimSpace(version)
+
+	var prefix string
+	if strings.HasPrefix(version, "v") || strings.HasPrefix(version, "V") {
+		prefix = "v"
+		version = version[1:]
+	}
+
+	// Separate the version core from any pre-release or build suffixes.
+	core, suffix := version, ""
+	if idx := strings.IndexAny(version, "-+"); idx != -1 {
+		core, suffix = version[:idx], version[idx:]
+	}
+
+	parts := strings.Split(core, ".")
+	for i, p := range parts {
+		if p == "" || strings.Trim(p, "0123456789") != "" {
+			return prefix + version
+		}
+		parts[i] = strings.TrimLeft(p, "0")
+		if parts[i] == "" {
+			parts[i] = "0"
+		}
+	}
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+
+	normalized := prefix + strings.Join(parts[:3], ".")
+	if len(parts) > 3 {
+		// Extra components are not valid in a semantic version core. Preserve
+		// them as build metadata so the information is not lost.
+		extra := strings.Join(parts[3:], ".")
+		if strings.Contains(suffix, "+") {
+			suffix += "." + extra
+		} else {
+			suffix += "+" + extra
+		}
+	}
+	return normalized + suffix
+}
+
 // String returns the string representation of the semantic version.
-func (s *Semver) String() string {
+func (s Semver) String() string {
 	str := fmt.Sprintf("%d.%d.%d", s.Major, s.Minor, s.Patch)
 	if s.Prerelease != "" {
 		str += "-"
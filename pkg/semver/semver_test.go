@@ -213,6 +213,36 @@ func TestLoad_PRVersionError(t *testing.T) {
 	assert.EqualError(t, err, "Numeric PreRelease version must not contain leading zeroes \"000\"")
 }
 
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{in: "1.2.3", expected: "1.2.3"},
+		{in: "v1.2.3", expected: "v1.2.3"},
+		{in: "V1.2.3", expected: "v1.2.3"},
+		{in: " 1.2.3\n", expected: "1.2.3"},
+		{in: "1", expected: "1.0.0"},
+		{in: "2024.3", expected: "2024.3.0"},
+		{in: "v2024.3", expected: "v2024.3.0"},
+		{in: "2024.03.01", expected: "2024.3.1"},
+		{in: "1.0.00", expected: "1.0.0"},
+		{in: "1.2-rc.1", expected: "1.2.0-rc.1"},
+		{in: "1.2+build.5", expected: "1.2.0+build.5"},
+		{in: "1.2.3.4", expected: "1.2.3+4"},
+		{in: "1.2.3.4-rc.1", expected: "1.2.3-rc.1+4"},
+		{in: "1.2.3.4+build", expected: "1.2.3+build.4"},
+		{in: "latest", expected: "latest"},
+		{in: "1.x", expected: "1.x"},
+		{in: "", expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			assert.Equal(t, test.expected, Normalize(test.in))
+		})
+	}
+}
+
 func TestSemver_String(t *testing.T) {
 	s := Semver{
 		Major:      1,
@@ -676,3 +706,12 @@ func TestSemver_IncrementPrerelease_ExistingWithoutNumeric(t *testing.T) {
 	assert.Equal(t, uint64(3), v.Patch)
 	assert.Equal(t, "alpha.1", v.Prerelease)
 }
+
+func TestLevel_String(t *testing.T) {
+	assert.Equal(t, "none", LevelNone.String())
+	assert.Equal(t, "prerelease", LevelPrerelease.String())
+	assert.Equal(t, "patch", LevelPatch.String())
+	assert.Equal(t, "minor", LevelMinor.String())
+	assert.Equal(t, "major", LevelMajor.String())
+	assert.Equal(t, "Level(9)", Level(9).String())
+}
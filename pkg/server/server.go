@@ -0,0 +1,595 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/config"
+	"github.com/edaniszewski/chart-releaser/pkg/errs"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1"
+	v1cfg "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"sigs.k8s.io/yaml"
+)
+
+// Defaults for the webhook server.
+const (
+	DefaultAddr    = ":8080"
+	DefaultHistory = 50
+)
+
+// MaxPayloadSize is the maximum size of a webhook payload accepted by the
+// server. This matches the limit GitHub puts on the payloads it sends.
+const MaxPayloadSize = 25 << 20
+
+// The statuses of an update run.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Errors for running the webhook server.
+var (
+	ErrNoSecret = errors.New("a webhook secret is required to verify webhook signatures")
+	ErrNoClient = errors.New("no repository client to fetch the config with, GITHUB_TOKEN may not be set")
+)
+
+// Config is the configuration for the webhook server. It maps the source
+// repositories which send webhooks to the chart-releaser configuration used
+// to update their charts.
+type Config struct {
+	Repos map[string]*RepoConfig `yaml:"repos,omitempty" json:"repos,omitempty"`
+
+	// path is the path of the file the Config was loaded from. Template files
+	// referenced by chart-releaser configurations are resolved relative to it.
+	path string
+}
+
+// RepoConfig defines the chart-releaser configuration for a source repository.
+// If no inline configuration is set, it is fetched from the repository.
+//
+// A fetched configuration is not trusted, since anyone who can push to the
+// repository can change it. It may only use the features listed in Allow
+// (hooks, template files, and reading environment variables in templates),
+// and may only update the chart repositories listed in ChartRepos.
+type RepoConfig struct {
+	Config     json.RawMessage `yaml:"config,omitempty" json:"config,omitempty"`
+	Path       string          `yaml:"path,omitempty" json:"path,omitempty"`
+	Allow      []string        `yaml:"allow,omitempty" json:"allow,omitempty"`
+	ChartRepos []string        `yaml:"chart_repos,omitempty" json:"chart_repos,omitempty"`
+}
+
+// fetched checks whether the configuration is fetched from the repository,
+// rather than set inline.
+func (c *RepoConfig) fetched() bool {
+	return c == nil || len(c.Config) == 0
+}
+
+// allowsChartRepo checks whether a fetched configuration may update the
+// chart repository. Repository names are not case sensitive.
+func (c *RepoConfig) allowsChartRepo(repo string) bool {
+	for _, r := range c.ChartRepos {
+		if strings.EqualFold(r, repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadConfig loads the webhook server configuration from the file at the
+// given path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	cfg.path = path
+	return &cfg, nil
+}
+
+// validate the Config is correct.
+func (c *Config) validate() error {
+	collector := errs.NewCollector()
+
+	if len(c.Repos) == 0 {
+		collector.Add(errors.New("no repos configured"))
+	}
+	// Repos are validated in order, so the errors are reported consistently.
+	var names []string
+	for name := range c.Repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		repo := c.Repos[name]
+		if parts := strings.Split(name, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			collector.Add(fmt.Errorf("invalid repo '%s', should be of the form 'owner/name'", name))
+		}
+		if repo != nil && len(repo.Config) != 0 && repo.Path != "" {
+			collector.Add(fmt.Errorf("repo '%s' may set only one of 'config' and 'path'", name))
+		}
+		if repo.fetched() && (repo == nil || len(repo.ChartRepos) == 0) {
+			collector.Add(fmt.Errorf("repo '%s' fetches its config, so must list the chart repos it may update in 'chart_repos'", name))
+		}
+		if repo != nil {
+			for _, f := range repo.Allow {
+				if !isFeature(f) {
+					collector.Add(fmt.Errorf("repo '%s' allows unsupported feature '%s', should be one of: %v", name, f, v1cfg.Features))
+				}
+			}
+		}
+	}
+
+	if collector.HasErrors() {
+		return collector
+	}
+	return nil
+}
+
+// isFeature checks whether a name is a feature which may be allowed for a
+// fetched configuration.
+func isFeature(name string) bool {
+	for _, f := range v1cfg.Features {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup the configuration for a source repository. Repository names are
+// not case sensitive.
+func (c *Config) lookup(repo string) (*RepoConfig, bool) {
+	for name, cfg := range c.Repos {
+		if strings.EqualFold(name, repo) {
+			if cfg == nil {
+				cfg = &RepoConfig{}
+			}
+			return cfg, true
+		}
+	}
+	return nil, false
+}
+
+// Options for running the webhook server.
+type Options struct {
+	Addr    string
+	Secret  string
+	DryRun  bool
+	History int
+	NoColor bool
+	Timeout time.Duration
+}
+
+// UpdateFunc runs a chart update, given the chart-releaser configuration
+// data and the options for the update.
+type UpdateFunc func(data []byte, opts v1.UpdateOptions) error
+
+// Run records an update run triggered by a webhook.
+type Run struct {
+	ID        int        `json:"id"`
+	Delivery  string     `json:"delivery,omitempty"`
+	Event     string     `json:"event"`
+	Repo      string     `json:"repo"`
+	Tag       string     `json:"tag"`
+	Chart     string     `json:"chart,omitempty"`
+	ChartRepo string     `json:"chart_repo,omitempty"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	Queued    time.Time  `json:"queued"`
+	Started   *time.Time `json:"started,omitempty"`
+	Finished  *time.Time `json:"finished,omitempty"`
+}
+
+// Server is an HTTP server which runs chart updates for release webhooks
+// sent by the configured source repositories.
+//
+// Updates for the same chart are run one at a time, in the order the webhooks
+// were received, so that they do not conflict. Updates for different charts
+// run concurrently.
+type Server struct {
+	cfg    *Config
+	opts   Options
+	client client.Client
+	update UpdateFunc
+
+	mu     sync.Mutex
+	nextID int
+	runs   []*Run
+	charts map[string]*sync.Mutex
+	wg     sync.WaitGroup
+}
+
+// New creates a new Server.
+func New(cfg *Config, opts Options) *Server {
+	if opts.Addr == "" {
+		opts.Addr = DefaultAddr
+	}
+	if opts.History <= 0 {
+		opts.History = DefaultHistory
+	}
+	return &Server{
+		cfg:    cfg,
+		opts:   opts,
+		update: update,
+		charts: map[string]*sync.Mutex{},
+	}
+}
+
+// WithClient sets the client used to fetch configuration from source
+// repositories which do not have it configured inline.
+func (s *Server) WithClient(c client.Client) *Server {
+	s.client = c
+	return s
+}
+
+// WithUpdateFunc sets the function used to run chart updates. By default,
+// updates are run with the Updater for the version of the configuration.
+func (s *Server) WithUpdateFunc(fn UpdateFunc) *Server {
+	s.update = fn
+	return s
+}
+
+// Handler gets the HTTP handler for the server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", s.handleWebhook)
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return mux
+}
+
+// ListenAndServe runs the server until the context is cancelled, after which
+// it stops accepting webhooks and waits for any updates in progress.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	if s.opts.Secret == "" {
+		return ErrNoSecret
+	}
+
+	srv := &http.Server{
+		Addr:    s.opts.Addr,
+		Handler: s.Handler(),
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	log.WithField("addr", s.opts.Addr).Info("listening for webhooks")
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	log.Info("waiting for updates in progress")
+	s.Wait()
+	return nil
+}
+
+// Wait for all runs to finish.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+// Runs gets the most recent runs, newest first.
+func (s *Server) Runs() []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := make([]Run, 0, len(s.runs))
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		runs = append(runs, *s.runs[i])
+	}
+	return runs
+}
+
+// handleWebhook handles a webhook sent by GitHub.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMessage(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, MaxPayloadSize))
+	if err != nil {
+		writeMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := verify(s.opts.Secret, r.Header.Get(HeaderSignature), payload); err != nil {
+		log.WithError(err).Warn("rejecting webhook")
+		writeMessage(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	name := r.Header.Get(HeaderEvent)
+	delivery := r.Header.Get(HeaderDelivery)
+	logger := log.WithFields(log.Fields{
+		"event":    name,
+		"delivery": delivery,
+	})
+	if name == EventPing {
+		writeMessage(w, http.StatusOK, "pong")
+		return
+	}
+
+	repo, tag, ignored, err := parseEvent(name, payload)
+	if err != nil {
+		logger.WithError(err).Warn("failed to parse webhook")
+		writeMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ignored != "" {
+		logger.WithField("reason", ignored).Debug("ignoring webhook")
+		writeMessage(w, http.StatusOK, "ignored: "+ignored)
+		return
+	}
+
+	repoCfg, ok := s.cfg.lookup(repo)
+	if !ok {
+		logger.WithField("repo", repo).Info("ignoring webhook for unconfigured repo")
+		writeMessage(w, http.StatusOK, fmt.Sprintf("ignored: repo '%s' is not configured", repo))
+		return
+	}
+
+	run := s.queue(name, delivery, repo, tag)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(run, repoCfg)
+	}()
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"id":     run.ID,
+		"status": StatusQueued,
+	})
+}
+
+// handleStatus reports the most recent runs.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMessage(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"runs": s.Runs(),
+	})
+}
+
+// queue records a new run, dropping the oldest runs beyond the history limit.
+func (s *Server) queue(event, delivery, repo, tag string) *Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	run := &Run{
+		ID:       s.nextID,
+		Delivery: delivery,
+		Event:    event,
+		Repo:     repo,
+		Tag:      tag,
+		Status:   StatusQueued,
+		Queued:   time.Now().UTC(),
+	}
+	s.runs = append(s.runs, run)
+	if len(s.runs) > s.opts.History {
+		s.runs = s.runs[len(s.runs)-s.opts.History:]
+	}
+	return run
+}
+
+// set updates the fields of a run.
+func (s *Server) set(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+}
+
+// run the update for a queued run, once no other update for the same chart
+// is running.
+func (s *Server) run(run *Run, repoCfg *RepoConfig) {
+	logger := log.WithFields(log.Fields{
+		"id":   run.ID,
+		"repo": run.Repo,
+		"tag":  run.Tag,
+	})
+
+	err := func() (err error) {
+		// A panic fails the run, rather than the whole server.
+		defer func() {
+			if r := recover(); r != nil {
+				logger.WithField("panic", r).Errorf("update panicked\n%s", debug.Stack())
+				err = fmt.Errorf("update panicked: %v", r)
+			}
+		}()
+
+		data, err := s.loadConfig(run.Repo, repoCfg)
+		if err != nil {
+			return err
+		}
+		chartRepo, chart, err := chartKey(data)
+		if err != nil {
+			return err
+		}
+		// The chart repository of a fetched configuration is chosen by
+		// whoever can push to the source repository, so it is limited to
+		// those allowed by the server configuration.
+		if repoCfg.fetched() && !repoCfg.allowsChartRepo(chartRepo) {
+			return fmt.Errorf("chart repo '%s' is not allowed for repo '%s', should be one of: %v", chartRepo, run.Repo, repoCfg.ChartRepos)
+		}
+		s.set(func() {
+			run.Chart = chart
+			run.ChartRepo = chartRepo
+		})
+
+		lock := s.chartLock(chartRepo + "/" + chart)
+		lock.Lock()
+		defer lock.Unlock()
+
+		s.set(func() {
+			now := time.Now().UTC()
+			run.Started = &now
+			run.Status = StatusRunning
+		})
+		logger.WithField("chart", chart).Info("running update")
+
+		return s.update(data, v1.UpdateOptions{
+			AllowFeatures: repoCfg.Allow,
+			AppVersion:    run.Tag,
+			ConfigPath:    s.cfg.path,
+			DryRun:        s.opts.DryRun,
+			NoColor:       s.opts.NoColor,
+			// There is no local checkout of the source repository, so the
+			// pre-flight checks of the local git repository are skipped, and
+			// the release history is not read from it.
+			NoGitHistory: true,
+			SkipStages:   []string{"setup"},
+			Timeout:      s.opts.Timeout,
+			// Only the inline configuration is set by the server operator.
+			Untrusted: repoCfg.fetched(),
+		})
+	}()
+
+	s.set(func() {
+		now := time.Now().UTC()
+		run.Finished = &now
+		if err != nil {
+			run.Status = StatusFailed
+			run.Error = err.Error()
+		} else {
+			run.Status = StatusSucceeded
+		}
+	})
+	if err != nil {
+		logger.WithError(err).Error("update failed")
+		return
+	}
+	logger.Info("update succeeded")
+}
+
+// chartLock gets the lock which serializes updates to a chart.
+func (s *Server) chartLock(key string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, ok := s.charts[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.charts[key] = lock
+	}
+	return lock
+}
+
+// loadConfig gets the chart-releaser configuration for a source repository,
+// either inline or fetched from the repository.
+func (s *Server) loadConfig(repo string, repoCfg *RepoConfig) ([]byte, error) {
+	if len(repoCfg.Config) != 0 {
+		// The inline configuration may be set as a YAML mapping, or as a
+		// string holding the configuration file contents.
+		var contents string
+		if err := json.Unmarshal(repoCfg.Config, &contents); err == nil {
+			return []byte(contents), nil
+		}
+		return repoCfg.Config, nil
+	}
+	if s.client == nil {
+		return nil, ErrNoClient
+	}
+
+	path := repoCfg.Path
+	if path == "" {
+		path = config.DefaultFile
+	}
+	parts := strings.SplitN(repo, "/", 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data, err := s.client.GetFile(ctx, &client.Options{
+		RepoOwner: parts[0],
+		RepoName:  parts[1],
+	}, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config '%s' from repo '%s': %w", path, repo, err)
+	}
+	return []byte(data), nil
+}
+
+// chartKey gets the chart repository and name for a chart-releaser
+// configuration, which identify the chart it updates.
+func chartKey(data []byte) (string, string, error) {
+	v, err := config.LoadFromBytes(data)
+	if err != nil {
+		return "", "", err
+	}
+	switch v.GetVersion() {
+	case v1.ConfigVersion():
+		cfg, err := v1cfg.LoadFromBytes(v.GetData())
+		if err != nil {
+			return "", "", err
+		}
+		if cfg.Chart == nil {
+			return "", "", v1cfg.ErrNoChart
+		}
+		return cfg.Chart.Repo, cfg.Chart.Name, nil
+	default:
+		return "", "", fmt.Errorf("unsupported config version: %s", v.GetVersion())
+	}
+}
+
+// update runs a chart update with the Updater for the version of the
+// configuration.
+func update(data []byte, opts v1.UpdateOptions) error {
+	v, err := config.LoadFromBytes(data)
+	if err != nil {
+		return err
+	}
+	switch v.GetVersion() {
+	case v1.ConfigVersion():
+		return v1.NewUpdater(v.GetData()).Run(opts)
+	default:
+		return fmt.Errorf("unsupported config version: %s", v.GetVersion())
+	}
+}
+
+// writeMessage writes a JSON response with a message.
+func writeMessage(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"message": msg})
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Debug("failed to write response")
+	}
+}
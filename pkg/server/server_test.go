@@ -0,0 +1,512 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1"
+	v1cfg "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSecret = "secret"
+	testConfig = `{"version":"v1","chart":{"name":"app","repo":"github.com/example/charts"}}`
+)
+
+// post sends a signed webhook to the server.
+func post(t *testing.T, s *Server, event, payload string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(payload))
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, "delivery-1")
+	req.Header.Set(HeaderSignature, Sign(testSecret, []byte(payload)))
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func releaseEvent(repo, tag string) string {
+	return `{"action":"published","release":{"tag_name":"` + tag + `"},"repository":{"full_name":"` + repo + `"}}`
+}
+
+func newTestServer(repos map[string]*RepoConfig, fn UpdateFunc) *Server {
+	return New(&Config{Repos: repos}, Options{Secret: testSecret}).WithUpdateFunc(fn)
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.yml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`
+repos:
+  example/app:
+    config:
+      version: v1
+      chart:
+        name: app
+        repo: github.com/example/charts
+  example/other:
+    path: deploy/.chartreleaser.yml
+    allow: [hooks, env]
+    chart_repos: [github.com/example/charts]
+  example/fetched:
+    chart_repos: [github.com/example/charts]
+`), 0644))
+
+	cfg, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Len(t, cfg.Repos, 3)
+	assert.JSONEq(t, testConfig, string(cfg.Repos["example/app"].Config))
+	assert.Equal(t, "deploy/.chartreleaser.yml", cfg.Repos["example/other"].Path)
+	assert.Equal(t, []string{"hooks", "env"}, cfg.Repos["example/other"].Allow)
+	assert.Equal(t, []string{"github.com/example/charts"}, cfg.Repos["example/other"].ChartRepos)
+	assert.Equal(t, path, cfg.path)
+	assert.Empty(t, cfg.Repos["example/fetched"].Config)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.yml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`
+repos:
+  example:
+    path: .chartreleaser.yml
+    config:
+      version: v1
+    allow: [network]
+  example/fetched:
+`), 0644))
+
+	_, err := LoadConfig(path)
+	assert.EqualError(t, err, `
+Errors:
+ • invalid repo 'example', should be of the form 'owner/name'
+ • repo 'example' may set only one of 'config' and 'path'
+ • repo 'example' allows unsupported feature 'network', should be one of: [hooks files env]
+ • repo 'example/fetched' fetches its config, so must list the chart repos it may update in 'chart_repos'
+
+`)
+}
+
+func TestLoadConfig_NoRepos(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.yml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("repos: {}\n"), 0644))
+
+	_, err := LoadConfig(path)
+	assert.EqualError(t, err, "\nErrors:\n • no repos configured\n\n")
+}
+
+func TestServer_Webhook(t *testing.T) {
+	var got v1.UpdateOptions
+	var data []byte
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(testConfig)},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		data = d
+		got = opts
+		return nil
+	})
+
+	w := post(t, s, EventRelease, releaseEvent("Example/App", "v1.2.0"))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.JSONEq(t, `{"id":1,"status":"queued"}`, w.Body.String())
+
+	s.Wait()
+	assert.Equal(t, testConfig, string(data))
+	assert.Equal(t, "v1.2.0", got.AppVersion)
+	assert.Equal(t, []string{"setup"}, got.SkipStages)
+	assert.True(t, got.NoGitHistory)
+	assert.False(t, got.Untrusted)
+
+	runs := s.Runs()
+	assert.Len(t, runs, 1)
+	assert.Equal(t, 1, runs[0].ID)
+	assert.Equal(t, "delivery-1", runs[0].Delivery)
+	assert.Equal(t, EventRelease, runs[0].Event)
+	assert.Equal(t, "Example/App", runs[0].Repo)
+	assert.Equal(t, "v1.2.0", runs[0].Tag)
+	assert.Equal(t, "app", runs[0].Chart)
+	assert.Equal(t, "github.com/example/charts", runs[0].ChartRepo)
+	assert.Equal(t, StatusSucceeded, runs[0].Status)
+	assert.NotNil(t, runs[0].Started)
+	assert.NotNil(t, runs[0].Finished)
+}
+
+func TestServer_WebhookInlineString(t *testing.T) {
+	var data []byte
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(`"version: v1\nchart:\n  name: app\n"`)},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		data = d
+		return nil
+	})
+
+	w := post(t, s, EventCreate, `{"ref":"v1.2.0","ref_type":"tag","repository":{"full_name":"example/app"}}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	s.Wait()
+	assert.Equal(t, "version: v1\nchart:\n  name: app\n", string(data))
+}
+
+func TestServer_WebhookFetchConfig(t *testing.T) {
+	var data []byte
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {ChartRepos: []string{"GitHub.com/Example/Charts"}},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		data = d
+		return nil
+	}).WithClient(&testutils.FakeClient{
+		FileData: testConfig,
+	})
+
+	w := post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	s.Wait()
+	assert.Equal(t, testConfig, string(data))
+	assert.Equal(t, StatusSucceeded, s.Runs()[0].Status)
+}
+
+func TestServer_WebhookFetchConfigUntrusted(t *testing.T) {
+	var got v1.UpdateOptions
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Allow: []string{"hooks"}, ChartRepos: []string{"github.com/example/charts"}},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		got = opts
+		return nil
+	}).WithClient(&testutils.FakeClient{
+		FileData: testConfig,
+	})
+	s.cfg.path = "/etc/chart-releaser/server.yml"
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	s.Wait()
+	assert.True(t, got.Untrusted)
+	assert.Equal(t, []string{"hooks"}, got.AllowFeatures)
+	assert.Equal(t, "/etc/chart-releaser/server.yml", got.ConfigPath)
+}
+
+func TestServer_WebhookFetchConfigNotAllowed(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "hooks",
+			config: `{"version":"v1","chart":{"name":"app","repo":"github.com/example/charts"},"hooks":{"publish":{"before":["env"]}}}`,
+			err:    "hooks are not allowed for this config",
+		},
+		{
+			name:   "template file",
+			config: `{"version":"v1","chart":{"name":"app","repo":"github.com/example/charts"},"publish":{"pr":{"body_template":"file:/etc/passwd"}}}`,
+			err:    "template file for 'publish.pr.body_template' is not allowed for this config",
+		},
+		{
+			name:   "includes",
+			config: `{"version":"v1","chart":{"name":"app","repo":"github.com/example/charts"},"includes":["partials.tmpl"]}`,
+			err:    "includes are not allowed for this config",
+		},
+		{
+			name:   "env",
+			config: `{"version":"v1","chart":{"name":"app","repo":"github.com/example/charts"},"publish":{"pr":{"title_template":"{{ env \"GITHUB_TOKEN\" }}"}}}`,
+			err:    `function "env" not defined`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(&Config{Repos: map[string]*RepoConfig{
+				"example/app": {ChartRepos: []string{"github.com/example/charts"}},
+			}}, Options{Secret: testSecret}).WithClient(&testutils.FakeClient{
+				FileData: test.config,
+			})
+
+			post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+			s.Wait()
+
+			runs := s.Runs()
+			assert.Equal(t, StatusFailed, runs[0].Status)
+			assert.Contains(t, runs[0].Error, test.err)
+		})
+	}
+}
+
+func TestServer_WebhookFetchConfigChartRepoNotAllowed(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		repos []string
+		err   string
+	}{
+		{
+			name:  "other chart repo",
+			repos: []string{"github.com/example/other-charts"},
+			err:   "chart repo 'github.com/example/charts' is not allowed for repo 'example/app', should be one of: [github.com/example/other-charts]",
+		},
+		{
+			name: "no chart repos",
+			err:  "chart repo 'github.com/example/charts' is not allowed for repo 'example/app', should be one of: []",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(map[string]*RepoConfig{
+				"example/app": {ChartRepos: test.repos},
+			}, func(d []byte, opts v1.UpdateOptions) error {
+				t.Error("update should not run")
+				return nil
+			}).WithClient(&testutils.FakeClient{
+				FileData: testConfig,
+			})
+
+			post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+			s.Wait()
+
+			runs := s.Runs()
+			assert.Equal(t, StatusFailed, runs[0].Status)
+			assert.Equal(t, test.err, runs[0].Error)
+		})
+	}
+}
+
+func TestServer_WebhookFetchConfigError(t *testing.T) {
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": nil,
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		t.Error("update should not run")
+		return nil
+	}).WithClient(&testutils.FakeClient{
+		GetFileError: []error{errors.New("not found")},
+	})
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	s.Wait()
+
+	runs := s.Runs()
+	assert.Equal(t, StatusFailed, runs[0].Status)
+	assert.Equal(t, "failed to fetch config '.chartreleaser.yml' from repo 'example/app': not found", runs[0].Error)
+}
+
+func TestServer_WebhookNoClient(t *testing.T) {
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": nil,
+	}, nil)
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	s.Wait()
+
+	runs := s.Runs()
+	assert.Equal(t, StatusFailed, runs[0].Status)
+	assert.Equal(t, ErrNoClient.Error(), runs[0].Error)
+}
+
+func TestServer_WebhookUpdateError(t *testing.T) {
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(testConfig)},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		return errors.New("no changes to publish")
+	})
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	s.Wait()
+
+	runs := s.Runs()
+	assert.Equal(t, StatusFailed, runs[0].Status)
+	assert.Equal(t, "no changes to publish", runs[0].Error)
+}
+
+func TestServer_WebhookUpdatePanic(t *testing.T) {
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(testConfig)},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		panic("boom")
+	})
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	s.Wait()
+
+	runs := s.Runs()
+	assert.Equal(t, StatusFailed, runs[0].Status)
+	assert.Equal(t, "update panicked: boom", runs[0].Error)
+	assert.NotNil(t, runs[0].Finished)
+}
+
+func TestServer_WebhookNoChart(t *testing.T) {
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(`{"version":"v1"}`)},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		t.Error("update should not run")
+		return nil
+	})
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	s.Wait()
+
+	runs := s.Runs()
+	assert.Equal(t, StatusFailed, runs[0].Status)
+	assert.Equal(t, v1cfg.ErrNoChart.Error(), runs[0].Error)
+}
+
+func TestServer_WebhookUnsupportedConfigVersion(t *testing.T) {
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(`{"version":"v0"}`)},
+	}, nil)
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	s.Wait()
+	assert.Equal(t, "unsupported config version: v0", s.Runs()[0].Error)
+}
+
+func TestServer_WebhookIgnored(t *testing.T) {
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(testConfig)},
+	}, nil)
+
+	w := post(t, s, EventRelease, releaseEvent("example/other", "v1.2.0"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"ignored: repo 'example/other' is not configured"}`, w.Body.String())
+
+	w = post(t, s, EventCreate, `{"ref":"feature","ref_type":"branch","repository":{"full_name":"example/app"}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"ignored: created ref is a branch, not a tag"}`, w.Body.String())
+
+	w = post(t, s, EventPing, `{"zen":"Keep it logically awesome."}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"pong"}`, w.Body.String())
+
+	assert.Empty(t, s.Runs())
+}
+
+func TestServer_WebhookBadRequest(t *testing.T) {
+	s := newTestServer(nil, nil)
+
+	w := post(t, s, EventRelease, `{"action":"published"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"message":"release event has no release"}`, w.Body.String())
+}
+
+func TestServer_WebhookInvalidSignature(t *testing.T) {
+	s := newTestServer(nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(`{}`))
+	req.Header.Set(HeaderEvent, EventPing)
+	req.Header.Set(HeaderSignature, Sign("wrong", []byte(`{}`)))
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"message":"request signature does not match"}`, w.Body.String())
+}
+
+func TestServer_WebhookMethodNotAllowed(t *testing.T) {
+	s := newTestServer(nil, nil)
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhook", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServer_SerializesChartUpdates(t *testing.T) {
+	var mu sync.Mutex
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+	var order []string
+
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app":   {Config: json.RawMessage(testConfig)},
+		"example/app2":  {Config: json.RawMessage(testConfig)},
+		"example/other": {Config: json.RawMessage(`{"version":"v1","chart":{"name":"other","repo":"github.com/example/charts"}}`)},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		chart := "other"
+		if bytes.Contains(d, []byte(`"app"`)) {
+			chart = "app"
+		}
+		mu.Lock()
+		running[chart]++
+		if running[chart] > maxRunning[chart] {
+			maxRunning[chart] = running[chart]
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running[chart]--
+		if chart == "app" {
+			order = append(order, opts.AppVersion)
+		}
+		mu.Unlock()
+		return nil
+	})
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.0.0"))
+	post(t, s, EventRelease, releaseEvent("example/app2", "v2.0.0"))
+	post(t, s, EventRelease, releaseEvent("example/other", "v3.0.0"))
+	s.Wait()
+
+	// Both source repos update the same chart, so their updates do not overlap.
+	assert.Equal(t, 1, maxRunning["app"])
+	assert.Len(t, order, 2)
+	assert.ElementsMatch(t, []string{"v1.0.0", "v2.0.0"}, order)
+	assert.Len(t, s.Runs(), 3)
+}
+
+func TestServer_History(t *testing.T) {
+	s := New(&Config{Repos: map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(testConfig)},
+	}}, Options{Secret: testSecret, History: 2}).WithUpdateFunc(func(d []byte, opts v1.UpdateOptions) error {
+		return nil
+	})
+
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.0.0"))
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.1.0"))
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.2.0"))
+	s.Wait()
+
+	runs := s.Runs()
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "v1.2.0", runs[0].Tag)
+	assert.Equal(t, "v1.1.0", runs[1].Tag)
+}
+
+func TestServer_Status(t *testing.T) {
+	s := newTestServer(map[string]*RepoConfig{
+		"example/app": {Config: json.RawMessage(testConfig)},
+	}, func(d []byte, opts v1.UpdateOptions) error {
+		return nil
+	})
+	post(t, s, EventRelease, releaseEvent("example/app", "v1.0.0"))
+	s.Wait()
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var status struct {
+		Runs []Run `json:"runs"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Len(t, status.Runs, 1)
+	assert.Equal(t, StatusSucceeded, status.Runs[0].Status)
+	assert.Equal(t, "v1.0.0", status.Runs[0].Tag)
+}
+
+func TestServer_Healthz(t *testing.T) {
+	s := newTestServer(nil, nil)
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestServer_ListenAndServeNoSecret(t *testing.T) {
+	s := New(&Config{}, Options{})
+	assert.Equal(t, ErrNoSecret, s.ListenAndServe(context.Background()))
+}
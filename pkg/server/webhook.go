@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// GitHub webhook request headers.
+const (
+	HeaderEvent     = "X-GitHub-Event"
+	HeaderDelivery  = "X-GitHub-Delivery"
+	HeaderSignature = "X-Hub-Signature-256"
+)
+
+// The GitHub webhook events handled by the server.
+const (
+	EventPing    = "ping"
+	EventRelease = "release"
+	EventCreate  = "create"
+)
+
+// Errors for verifying and parsing webhooks.
+var (
+	ErrNoSignature      = errors.New("request is not signed")
+	ErrInvalidSignature = errors.New("request signature does not match")
+)
+
+// event holds the fields of GitHub webhook payloads used by the server.
+type event struct {
+	Action  string `json:"action"`
+	Ref     string `json:"ref"`
+	RefType string `json:"ref_type"`
+	Release *struct {
+		TagName string `json:"tag_name"`
+		Draft   bool   `json:"draft"`
+	} `json:"release"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// Sign computes the value of the signature header for a webhook payload,
+// given the webhook secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify the signature of a webhook payload.
+func verify(secret, signature string, payload []byte) error {
+	if secret == "" {
+		return ErrNoSecret
+	}
+	if signature == "" {
+		return ErrNoSignature
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, payload))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// parseEvent parses a webhook payload, returning the full name of the source
+// repository and the release tag. If the event does not release a tag, the
+// reason it is ignored is returned instead.
+func parseEvent(name string, payload []byte) (repo, tag, ignored string, err error) {
+	var e event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return "", "", "", err
+	}
+	repo = e.Repository.FullName
+
+	switch name {
+	case EventRelease:
+		if e.Release == nil {
+			return repo, "", "", errors.New("release event has no release")
+		}
+		if e.Action != "published" {
+			return repo, "", "release action '" + e.Action + "' is not 'published'", nil
+		}
+		if e.Release.Draft {
+			return repo, "", "release is a draft", nil
+		}
+		tag = e.Release.TagName
+
+	case EventCreate:
+		if e.RefType != "tag" {
+			return repo, "", "created ref is a " + e.RefType + ", not a tag", nil
+		}
+		tag = e.Ref
+
+	default:
+		return repo, "", "unsupported event '" + name + "'", nil
+	}
+
+	if repo == "" {
+		return "", "", "", errors.New("event has no repository")
+	}
+	if tag == "" {
+		return "", "", "", errors.New("event has no tag")
+	}
+	return repo, tag, "", nil
+}
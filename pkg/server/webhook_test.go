@@ -0,0 +1,131 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// Example from the GitHub webhook documentation.
+	assert.Equal(t,
+		"sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+		Sign("It's a Secret to Everybody", []byte("Hello, World!")),
+	)
+}
+
+func TestVerify(t *testing.T) {
+	payload := []byte(`{"action":"published"}`)
+
+	assert.NoError(t, verify("secret", Sign("secret", payload), payload))
+	assert.Equal(t, ErrNoSecret, verify("", Sign("", payload), payload))
+	assert.Equal(t, ErrNoSignature, verify("secret", "", payload))
+	assert.Equal(t, ErrInvalidSignature, verify("secret", Sign("other", payload), payload))
+	assert.Equal(t, ErrInvalidSignature, verify("secret", "sha1=abc", payload))
+	assert.Equal(t, ErrInvalidSignature, verify("secret", Sign("secret", payload), []byte(`{}`)))
+}
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		payload string
+		repo    string
+		tag     string
+		ignored string
+	}{
+		{
+			name:    "release published",
+			event:   EventRelease,
+			payload: `{"action":"published","release":{"tag_name":"v1.2.0"},"repository":{"full_name":"example/app"}}`,
+			repo:    "example/app",
+			tag:     "v1.2.0",
+		},
+		{
+			name:    "release created",
+			event:   EventRelease,
+			payload: `{"action":"created","release":{"tag_name":"v1.2.0"},"repository":{"full_name":"example/app"}}`,
+			repo:    "example/app",
+			ignored: "release action 'created' is not 'published'",
+		},
+		{
+			name:    "release draft",
+			event:   EventRelease,
+			payload: `{"action":"published","release":{"tag_name":"v1.2.0","draft":true},"repository":{"full_name":"example/app"}}`,
+			repo:    "example/app",
+			ignored: "release is a draft",
+		},
+		{
+			name:    "create tag",
+			event:   EventCreate,
+			payload: `{"ref":"v1.2.0","ref_type":"tag","repository":{"full_name":"example/app"}}`,
+			repo:    "example/app",
+			tag:     "v1.2.0",
+		},
+		{
+			name:    "create branch",
+			event:   EventCreate,
+			payload: `{"ref":"feature","ref_type":"branch","repository":{"full_name":"example/app"}}`,
+			repo:    "example/app",
+			ignored: "created ref is a branch, not a tag",
+		},
+		{
+			name:    "push",
+			event:   "push",
+			payload: `{"ref":"refs/heads/master","repository":{"full_name":"example/app"}}`,
+			repo:    "example/app",
+			ignored: "unsupported event 'push'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo, tag, ignored, err := parseEvent(test.event, []byte(test.payload))
+			assert.NoError(t, err)
+			assert.Equal(t, test.repo, repo)
+			assert.Equal(t, test.tag, tag)
+			assert.Equal(t, test.ignored, ignored)
+		})
+	}
+}
+
+func TestParseEvent_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		payload string
+		err     string
+	}{
+		{
+			name:    "invalid json",
+			event:   EventRelease,
+			payload: `{`,
+			err:     "unexpected end of JSON input",
+		},
+		{
+			name:    "no release",
+			event:   EventRelease,
+			payload: `{"action":"published","repository":{"full_name":"example/app"}}`,
+			err:     "release event has no release",
+		},
+		{
+			name:    "no repository",
+			event:   EventCreate,
+			payload: `{"ref":"v1.2.0","ref_type":"tag"}`,
+			err:     "event has no repository",
+		},
+		{
+			name:    "no tag",
+			event:   EventCreate,
+			payload: `{"ref_type":"tag","repository":{"full_name":"example/app"}}`,
+			err:     "event has no tag",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, _, err := parseEvent(test.event, []byte(test.payload))
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
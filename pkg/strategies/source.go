@@ -0,0 +1,84 @@
+package strategies
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VersionSource defines where chart-releaser gets the version of the
+// application being released.
+type VersionSource string
+
+// The version sources supported by chart-releaser.
+const (
+	SourceGit     VersionSource = "git"
+	SourceFlag    VersionSource = "flag"
+	SourceEnv     VersionSource = "env"
+	SourceFile    VersionSource = "file"
+	SourceCommand VersionSource = "command"
+	SourceGitHub  VersionSource = "github"
+)
+
+// ListVersionSources returns a slice of all supported VersionSources.
+func ListVersionSources() []VersionSource {
+	return []VersionSource{
+		SourceGit,
+		SourceFlag,
+		SourceEnv,
+		SourceFile,
+		SourceCommand,
+		SourceGitHub,
+	}
+}
+
+// VersionSourceFromString returns the VersionSource corresponding to the
+// provided string, if there is one.
+func VersionSourceFromString(s string) (VersionSource, error) {
+	switch strings.ToLower(s) {
+	case "git":
+		return SourceGit, nil
+	case "flag":
+		return SourceFlag, nil
+	case "env":
+		return SourceEnv, nil
+	case "file":
+		return SourceFile, nil
+	case "command":
+		return SourceCommand, nil
+	case "github":
+		return SourceGitHub, nil
+	default:
+		return "", fmt.Errorf("unsupported version source: %s", s)
+	}
+}
+
+// PreviousSource defines where chart-releaser gets the previous version of the
+// application, which is used to determine the version drift for a release.
+type PreviousSource string
+
+// The previous version sources supported by chart-releaser.
+const (
+	PreviousChart PreviousSource = "chart"
+	PreviousGit   PreviousSource = "git"
+)
+
+// ListPreviousSources returns a slice of all supported PreviousSources.
+func ListPreviousSources() []PreviousSource {
+	return []PreviousSource{
+		PreviousChart,
+		PreviousGit,
+	}
+}
+
+// PreviousSourceFromString returns the PreviousSource corresponding to the
+// provided string, if there is one.
+func PreviousSourceFromString(s string) (PreviousSource, error) {
+	switch strings.ToLower(s) {
+	case "chart":
+		return PreviousChart, nil
+	case "git":
+		return PreviousGit, nil
+	default:
+		return "", fmt.Errorf("unsupported previous version source: %s", s)
+	}
+}
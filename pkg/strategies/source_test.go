@@ -0,0 +1,107 @@
+package strategies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListVersionSources(t *testing.T) {
+	sources := ListVersionSources()
+	assert.Len(t, sources, 6)
+	assert.Equal(t, SourceGit, sources[0])
+	assert.Equal(t, SourceFlag, sources[1])
+	assert.Equal(t, SourceEnv, sources[2])
+	assert.Equal(t, SourceFile, sources[3])
+	assert.Equal(t, SourceCommand, sources[4])
+	assert.Equal(t, SourceGitHub, sources[5])
+}
+
+func TestVersionSourceFromString(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected VersionSource
+	}{
+		{
+			str:      "git",
+			expected: SourceGit,
+		},
+		{
+			str:      "Flag",
+			expected: SourceFlag,
+		},
+		{
+			str:      "ENV",
+			expected: SourceEnv,
+		},
+		{
+			str:      "file",
+			expected: SourceFile,
+		},
+		{
+			str:      "command",
+			expected: SourceCommand,
+		},
+		{
+			str:      "GitHub",
+			expected: SourceGitHub,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.str, func(t *testing.T) {
+			actual, err := VersionSourceFromString(test.str)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestVersionSourceFromString_Error(t *testing.T) {
+	source, err := VersionSourceFromString("test-string")
+	assert.EqualError(t, err, "unsupported version source: test-string")
+	assert.Empty(t, source)
+}
+
+func TestListPreviousSources(t *testing.T) {
+	sources := ListPreviousSources()
+	assert.Len(t, sources, 2)
+	assert.Equal(t, PreviousChart, sources[0])
+	assert.Equal(t, PreviousGit, sources[1])
+}
+
+func TestPreviousSourceFromString(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected PreviousSource
+	}{
+		{
+			str:      "chart",
+			expected: PreviousChart,
+		},
+		{
+			str:      "Chart",
+			expected: PreviousChart,
+		},
+		{
+			str:      "git",
+			expected: PreviousGit,
+		},
+		{
+			str:      "GIT",
+			expected: PreviousGit,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.str, func(t *testing.T) {
+			actual, err := PreviousSourceFromString(test.str)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestPreviousSourceFromString_Error(t *testing.T) {
+	source, err := PreviousSourceFromString("test-string")
+	assert.EqualError(t, err, "unsupported previous version source: test-string")
+	assert.Empty(t, source)
+}
@@ -0,0 +1,225 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/edaniszewski/chart-releaser/pkg/env"
+	version "github.com/edaniszewski/chart-releaser/pkg/semver"
+)
+
+// shortShaLength is the number of characters kept by the shortSha function.
+const shortShaLength = 7
+
+// secretEnv holds the environment variables which can not be read by
+// templates, since they hold credentials. Rendered templates end up in
+// commits and pull requests, and may include text from outside of the
+// config, such as release notes.
+var secretEnv = []string{
+	env.GithubToken,
+	env.WebhookSecret,
+	"GH_TOKEN",
+	"ACTIONS_RUNTIME_TOKEN",
+	"ACTIONS_ID_TOKEN_REQUEST_TOKEN",
+}
+
+// New creates a new template with the given name which has the chart-releaser
+// function map available. All templates rendered by chart-releaser should be
+// created via New so the same functions are available everywhere.
+func New(name string) *template.Template {
+	return template.New(name).Funcs(FuncMap())
+}
+
+// NewRestricted creates a new template with the given name which has the
+// restricted chart-releaser function map available. It should be used for
+// templates from configs which are not trusted.
+func NewRestricted(name string) *template.Template {
+	return template.New(name).Funcs(RestrictedFuncMap())
+}
+
+// FuncMap gets the functions available to chart-releaser templates. This includes
+// the sprig template functions (as used by helm) in addition to chart-releaser
+// specific helpers.
+func FuncMap() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+
+	// Versions are stored on the context as Semver values rather than strings.
+	// Allow them to be piped directly into the string functions, e.g.
+	// {{ .App.NewVersion | trimPrefix "v" }}, without needing toString first.
+	for name, fn := range funcs {
+		funcs[name] = acceptStringers(fn)
+	}
+
+	funcs["semverMajor"] = semverMajor
+	funcs["semverMinor"] = semverMinor
+	funcs["semverPatch"] = semverPatch
+	funcs["semverBump"] = semverBump
+	funcs["shortSha"] = acceptStringers(shortSha)
+	funcs["env"] = getenv
+	funcs["expandenv"] = acceptStringers(expandenv)
+
+	return funcs
+}
+
+// RestrictedFuncMap gets the functions available to chart-releaser templates,
+// without the functions which read environment variables. Since the
+// environment may hold secrets, such as the GitHub token, templates from
+// configs which are not trusted can not read it.
+func RestrictedFuncMap() template.FuncMap {
+	funcs := FuncMap()
+	delete(funcs, "env")
+	delete(funcs, "expandenv")
+	return funcs
+}
+
+// getenv gets the value of the environment variable, or an empty string if
+// it is not set or holds a secret.
+func getenv(key string) string {
+	for _, secret := range secretEnv {
+		if key == secret {
+			return ""
+		}
+	}
+	return os.Getenv(key)
+}
+
+// expandenv replaces ${var} or $var in the string with the values of the
+// environment variables, as read by getenv.
+func expandenv(s string) string {
+	return os.Expand(s, getenv)
+}
+
+var (
+	stringType   = reflect.TypeOf("")
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// acceptStringers wraps a template function whose final (piped) argument is a
+// string so that it also accepts fmt.Stringer values. Functions which do not
+// take a string as their final argument are returned unchanged.
+func acceptStringers(fn interface{}) interface{} {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.IsVariadic() || ft.NumIn() == 0 || ft.In(ft.NumIn()-1) != stringType {
+		return fn
+	}
+
+	in := make([]reflect.Type, ft.NumIn())
+	for i := range in {
+		in[i] = ft.In(i)
+	}
+	in[len(in)-1] = reflect.TypeOf((*interface{})(nil)).Elem()
+	out := make([]reflect.Type, ft.NumOut())
+	for i := range out {
+		out[i] = ft.Out(i)
+	}
+
+	wrapped := reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		last := args[len(args)-1]
+		if last.IsNil() {
+			panic(fmt.Errorf("expected string argument, got nil"))
+		}
+		last = last.Elem()
+		switch {
+		case last.Type() == stringType:
+		case last.Type().Implements(stringerType):
+			last = reflect.ValueOf(last.Interface().(fmt.Stringer).String())
+		default:
+			// Template execution recovers from the panic and returns it as an error,
+			// matching the behavior of passing the wrong type to the unwrapped function.
+			panic(fmt.Errorf("expected string argument, got %s", last.Type()))
+		}
+		args[len(args)-1] = last
+		return fv.Call(args)
+	})
+	return wrapped.Interface()
+}
+
+// toSemver converts a template value into a Semver. Both version strings and
+// Semver values (e.g. .App.NewVersion) are supported.
+func toSemver(v interface{}) (version.Semver, error) {
+	switch val := v.(type) {
+	case version.Semver:
+		return val, nil
+	case *version.Semver:
+		if val == nil {
+			return version.Semver{}, fmt.Errorf("unable to get version from nil value")
+		}
+		return *val, nil
+	case string:
+		return version.Load(val)
+	default:
+		return version.Semver{}, fmt.Errorf("unable to get version from value of type %T", v)
+	}
+}
+
+// semverMajor gets the major component of a version.
+func semverMajor(v interface{}) (uint64, error) {
+	s, err := toSemver(v)
+	if err != nil {
+		return 0, err
+	}
+	return s.Major, nil
+}
+
+// semverMinor gets the minor component of a version.
+func semverMinor(v interface{}) (uint64, error) {
+	s, err := toSemver(v)
+	if err != nil {
+		return 0, err
+	}
+	return s.Minor, nil
+}
+
+// semverPatch gets the patch component of a version.
+func semverPatch(v interface{}) (uint64, error) {
+	s, err := toSemver(v)
+	if err != nil {
+		return 0, err
+	}
+	return s.Patch, nil
+}
+
+// semverBump increments a version at the given level (major, minor, patch,
+// or prerelease). The level comes first so the function can be used in a
+// pipeline, e.g. {{ .App.NewVersion | semverBump "minor" }}.
+func semverBump(level string, v interface{}) (string, error) {
+	s, err := toSemver(v)
+	if err != nil {
+		return "", err
+	}
+
+	var l version.Level
+	switch strings.ToLower(level) {
+	case "major":
+		l = version.LevelMajor
+	case "minor":
+		l = version.LevelMinor
+	case "patch":
+		l = version.LevelPatch
+	case "prerelease":
+		l = version.LevelPrerelease
+	default:
+		return "", fmt.Errorf("unsupported semver level '%s', should be one of: major, minor, patch, prerelease", level)
+	}
+
+	// Incrementing creates a new Semver which does not retain the "v" prefix,
+	// so it is added back here to keep the bumped version in the same format.
+	bumped := s.IncrementNew(l)
+	if strings.HasPrefix(s.String(), "v") {
+		return "v" + bumped.String(), nil
+	}
+	return bumped.String(), nil
+}
+
+// shortSha shortens a git commit SHA to its abbreviated form.
+func shortSha(sha string) string {
+	if len(sha) > shortShaLength {
+		return sha[:shortShaLength]
+	}
+	return sha
+}
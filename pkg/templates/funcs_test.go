@@ -0,0 +1,119 @@
+package templates
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	version "github.com/edaniszewski/chart-releaser/pkg/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func render(t *testing.T, tmpl string, data interface{}) string {
+	tpl, err := New("test").Parse(tmpl)
+	assert.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, tpl.Execute(&buf, data))
+	return buf.String()
+}
+
+func TestFuncMap(t *testing.T) {
+	v, err := version.Load("v1.2.3")
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.Setenv("CHART_RELEASER_TEST_ENV", "test-value"))
+	defer os.Unsetenv("CHART_RELEASER_TEST_ENV")
+
+	data := map[string]interface{}{
+		"Version": v,
+		"SHA":     "0123456789abcdef0123456789abcdef01234567",
+	}
+
+	tests := []struct {
+		tmpl     string
+		expected string
+	}{
+		{tmpl: `{{ .Version | trimPrefix "v" }}`, expected: "1.2.3"},
+		{tmpl: `{{ .Version | toString }}`, expected: "v1.2.3"},
+		{tmpl: `{{ now | date "2006" | len }}`, expected: "4"},
+		{tmpl: `{{ "chart" | upper }}`, expected: "CHART"},
+		{tmpl: `{{ semverMajor .Version }}`, expected: "1"},
+		{tmpl: `{{ semverMinor .Version }}`, expected: "2"},
+		{tmpl: `{{ semverPatch "4.5.6" }}`, expected: "6"},
+		{tmpl: `{{ .Version | semverBump "major" }}`, expected: "v2.0.0"},
+		{tmpl: `{{ .Version | semverBump "minor" }}`, expected: "v1.3.0"},
+		{tmpl: `{{ "1.2.3" | semverBump "patch" }}`, expected: "1.2.4"},
+		{tmpl: `{{ "1.2.3" | semverBump "prerelease" }}`, expected: "1.2.3-pre.1"},
+		{tmpl: `{{ .SHA | shortSha }}`, expected: "0123456"},
+		{tmpl: `{{ "abc" | shortSha }}`, expected: "abc"},
+		{tmpl: `{{ env "CHART_RELEASER_TEST_ENV" }}`, expected: "test-value"},
+		{tmpl: `{{ expandenv "value: $CHART_RELEASER_TEST_ENV" }}`, expected: "value: test-value"},
+	}
+	for _, test := range tests {
+		t.Run(test.tmpl, func(t *testing.T) {
+			assert.Equal(t, test.expected, render(t, test.tmpl, data))
+		})
+	}
+}
+
+func TestFuncMap_SecretEnv(t *testing.T) {
+	assert.NoError(t, os.Setenv("GITHUB_TOKEN", "ghp_secret"))
+	defer os.Unsetenv("GITHUB_TOKEN")
+
+	for _, tmpl := range []string{
+		`{{ env "GITHUB_TOKEN" }}`,
+		`{{ expandenv "$GITHUB_TOKEN" }}`,
+		`{{ expandenv "${GITHUB_TOKEN}" }}`,
+	} {
+		t.Run(tmpl, func(t *testing.T) {
+			assert.Equal(t, "", render(t, tmpl, nil))
+		})
+	}
+}
+
+func TestFuncMap_SemverBumpError(t *testing.T) {
+	tpl, err := New("test").Parse(`{{ "1.2.3" | semverBump "build" }}`)
+	assert.NoError(t, err)
+
+	err = tpl.Execute(&bytes.Buffer{}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported semver level 'build'")
+}
+
+func TestFuncMap_SemverInvalid(t *testing.T) {
+	tpl, err := New("test").Parse(`{{ semverMajor 3 }}`)
+	assert.NoError(t, err)
+
+	err = tpl.Execute(&bytes.Buffer{}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to get version from value of type int")
+}
+
+func TestFuncMap_WrongType(t *testing.T) {
+	tpl, err := New("test").Parse(`{{ 3 | trimPrefix "v" }}`)
+	assert.NoError(t, err)
+
+	err = tpl.Execute(&bytes.Buffer{}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expected string argument, got int")
+}
+
+func TestRestrictedFuncMap(t *testing.T) {
+	assert.NoError(t, os.Setenv("CHART_RELEASER_TEST_ENV", "test-value"))
+	defer os.Unsetenv("CHART_RELEASER_TEST_ENV")
+
+	for _, tmpl := range []string{
+		`{{ env "CHART_RELEASER_TEST_ENV" }}`,
+		`{{ expandenv "$CHART_RELEASER_TEST_ENV" }}`,
+	} {
+		_, err := NewRestricted("test").Parse(tmpl)
+		assert.Error(t, err)
+	}
+
+	tpl, err := NewRestricted("test").Parse(`{{ "1.2.3" | semverBump "patch" | upper }}`)
+	assert.NoError(t, err)
+	buf := bytes.Buffer{}
+	assert.NoError(t, tpl.Execute(&buf, nil))
+	assert.Equal(t, "1.2.4", buf.String())
+}
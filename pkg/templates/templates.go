@@ -23,6 +23,29 @@ var FlagLatestVersionTemplate = heredoc.Doc(`
 	installed: {{ .Installed }}
 `)
 
+// RenderOutputTemplate is the template for the text output of the `render` command.
+var RenderOutputTemplate = heredoc.Doc(`
+	app version:    {{ .PreviousAppVersion }} -> {{ .AppVersion }}
+	chart version:  {{ .PreviousChartVersion }} -> {{ .ChartVersion }}
+	strategy:       {{ .PublishStrategy }}
+	branch:         {{ .Branch }}
+	base:           {{ .Base }}
+
+	=== chart commit message ===
+	{{ .ChartCommitMessage }}
+	{{ range .ExtrasCommitMessages }}
+	=== extras commit message ({{ .Path }}) ===
+	{{ .Message }}
+	{{ end }}
+	{{- if .PRTitle }}
+	=== pull request title ===
+	{{ .PRTitle }}
+
+	=== pull request body ===
+	{{ .PRBody }}
+	{{ end -}}
+`)
+
 // ConfigFileTemplate is the template used to generate a new basic configuration file for
 // chart-releaser from the `init` command.
 var ConfigFileTemplate = heredoc.Doc(`
@@ -61,7 +84,8 @@ var DefaultExtrasCommitMessage = `[{{ .Chart.Name }}] update {{ .CurrentFile.Pat
 var DefaultPullRequestTitle = `Bump {{ .Chart.Name }} Chart from {{ .Chart.PreviousVersion }} to {{ .Chart.NewVersion }}`
 
 // DefaultPullRequestBody is a template for the default comment used when opening
-// a new pull request, summarizing the changes.
+// a new pull request, summarizing the changes. If commits were collected for the
+// application release, they are listed in a collapsible changelog section.
 //
 // This is only used when the configuration specifies that a pull request should be
 // opened after committing any updates.
@@ -70,7 +94,15 @@ var DefaultPullRequestBody = heredoc.Doc(`
 
 	{{ if .Files }}The following files have also been updated:
 	{{ range .Files }}- {{ .Path }}
-	{{ end }}{{ end }}
+	{{ end }}{{ end }}{{ if .Git.Commits }}
+	<details>
+	<summary>Application changes since {{ .App.PreviousTag }}</summary>
+
+	{{ range .Git.Commits }}- {{ .ShortSHA }} {{ .Subject }} ({{ .Author }})
+	{{ end }}{{ if .Git.OmittedCommits }}- *...and {{ .Git.OmittedCommits }} more*
+	{{ end }}
+	</details>
+	{{ end }}
 	---
 	*This PR was generated with [chart-releaser](https://github.com/edaniszewski/chart-releaser)*
 `)
@@ -78,3 +110,7 @@ var DefaultPullRequestBody = heredoc.Doc(`
 // DefaultBranchName is a template specifying the default name of the branch to create
 // when updating under the "pull request" strategy.
 var DefaultBranchName = `chartreleaser/{{ .Chart.Name }}/{{ .Chart.NewVersion }}`
+
+// DefaultNotificationMessage is a template specifying the default message sent
+// to Slack and Microsoft Teams when an update is published or fails.
+var DefaultNotificationMessage = `{{ if .Result.Success }}Published {{ .Chart.Name }} chart {{ .Chart.NewVersion }} for application release {{ .App.NewVersion }}{{ with .Publish.PullRequest }}: {{ .URL }}{{ end }}{{ else }}Failed to update {{ .Chart.Name }} chart{{ with .Git.Tag }} for application release {{ . }}{{ end }}: {{ .Result.Error }}{{ end }}`
@@ -3,9 +3,11 @@ package utils
 import (
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/aryann/difflib"
+	"github.com/mattn/go-isatty"
 	"github.com/mgutz/ansi"
 )
 
@@ -36,3 +38,152 @@ func PrintDiff(out io.Writer, filename, old, new string) {
 		}
 	}
 }
+
+// DefaultDiffContext is the default number of unchanged lines shown around each
+// change in a unified diff.
+const DefaultDiffContext = 3
+
+// UnifiedDiff generates a unified diff (as produced by `diff -u` or `git diff`)
+// of the changes between the old and new contents of the file at the given path.
+// Each change is shown with the given number of lines of context. If there are no
+// changes, an empty string is returned.
+func UnifiedDiff(path, old, new string, context int) string {
+	if old == new {
+		return ""
+	}
+	if context < 0 {
+		context = 0
+	}
+
+	records := difflib.Diff(splitLines(old), splitLines(new))
+
+	// Track the number of lines from each side which precede each record, so
+	// hunk ranges can be computed.
+	oldPos := make([]int, len(records)+1)
+	newPos := make([]int, len(records)+1)
+	var changes []int
+	for i, r := range records {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if r.Delta != difflib.RightOnly {
+			oldPos[i+1]++
+		}
+		if r.Delta != difflib.LeftOnly {
+			newPos[i+1]++
+		}
+		if r.Delta != difflib.Common {
+			changes = append(changes, i)
+		}
+	}
+
+	buf := strings.Builder{}
+	fmt.Fprintf(&buf, "--- a/%s\n+++ b/%s\n", path, path)
+
+	for i := 0; i < len(changes); {
+		// Group changes which are close enough that their context would overlap.
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context+1 {
+			j++
+		}
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + context + 1
+		if end > len(records) {
+			end = len(records)
+		}
+
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			hunkRange(oldPos[start], oldPos[end]-oldPos[start]),
+			hunkRange(newPos[start], newPos[end]-newPos[start]),
+		)
+		for _, r := range records[start:end] {
+			switch r.Delta {
+			case difflib.RightOnly:
+				buf.WriteString("+" + r.Payload + "\n")
+			case difflib.LeftOnly:
+				buf.WriteString("-" + r.Payload + "\n")
+			case difflib.Common:
+				buf.WriteString(" " + r.Payload + "\n")
+			}
+		}
+		i = j + 1
+	}
+	return buf.String()
+}
+
+// hunkRange formats the range of lines for a unified diff hunk header. The
+// start is the number of lines preceding the hunk.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// noNewline is appended to the last line of a file which does not end in a
+// newline. Including it in the line itself means a change to only the trailing
+// newline is still detected, and the marker is written out with the line as
+// `diff` and `git apply` expect.
+const noNewline = "\n\\ No newline at end of file"
+
+// splitLines splits file contents into lines. A trailing newline does not
+// produce an additional empty line.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if !strings.HasSuffix(s, "\n") {
+		lines[len(lines)-1] += noNewline
+	}
+	return lines
+}
+
+// ColorDiff adds terminal colors to a unified diff, in the style of `git diff`.
+func ColorDiff(diff string) string {
+	if diff == "" {
+		return ""
+	}
+
+	buf := strings.Builder{}
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(diff, "\n"), "\n") {
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "---"), strings.HasPrefix(text, "+++"):
+			text = ansi.Color(text, "white+b")
+		case strings.HasPrefix(text, "@@"):
+			text = ansi.Color(text, "cyan")
+		case strings.HasPrefix(text, "+"):
+			text = ansi.Color(text, "green")
+		case strings.HasPrefix(text, "-"):
+			text = ansi.Color(text, "red")
+		}
+		buf.WriteString(text + "\n")
+	}
+	return buf.String()
+}
+
+// IsTerminal checks whether the writer is a terminal. Only files (e.g. os.Stdout)
+// can be terminals; any other writer is assumed not to be.
+func IsTerminal(out io.Writer) bool {
+	return isTerminalFile(out)
+}
+
+// IsInteractive checks whether the reader is a terminal which a user can
+// respond to prompts from, e.g. os.Stdin when not redirected.
+func IsInteractive(in io.Reader) bool {
+	return isTerminalFile(in)
+}
+
+// isTerminalFile checks whether the value is a file which is a terminal.
+func isTerminalFile(v interface{}) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
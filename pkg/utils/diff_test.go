@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,3 +62,83 @@ func TestPrintDiff_MultiLine(t *testing.T) {
 		buf.String(),
 	)
 }
+
+func TestUnifiedDiff_NoChanges(t *testing.T) {
+	assert.Equal(t, "", UnifiedDiff("Chart.yaml", "a\nb\n", "a\nb\n", 3))
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "apiVersion: v2\nappVersion: v1.0.0\nname: foo\nversion: 0.3.0\n"
+	new := "apiVersion: v2\nappVersion: v1.1.0\nname: foo\nversion: 0.3.1\n"
+
+	assert.Equal(
+		t,
+		"--- a/charts/foo/Chart.yaml\n+++ b/charts/foo/Chart.yaml\n"+
+			"@@ -1,4 +1,4 @@\n"+
+			" apiVersion: v2\n"+
+			"-appVersion: v1.0.0\n"+
+			"+appVersion: v1.1.0\n"+
+			" name: foo\n"+
+			"-version: 0.3.0\n"+
+			"+version: 0.3.1\n",
+		UnifiedDiff("charts/foo/Chart.yaml", old, new, 3),
+	)
+}
+
+func TestUnifiedDiff_MultipleHunks(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	new := "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n"
+
+	assert.Equal(
+		t,
+		"--- a/file.txt\n+++ b/file.txt\n"+
+			"@@ -1,2 +1,2 @@\n"+
+			"-1\n"+
+			"+one\n"+
+			" 2\n"+
+			"@@ -9,2 +9,2 @@\n"+
+			" 9\n"+
+			"-10\n"+
+			"+ten\n",
+		UnifiedDiff("file.txt", old, new, 1),
+	)
+}
+
+func TestUnifiedDiff_NewFile(t *testing.T) {
+	assert.Equal(
+		t,
+		"--- a/file.txt\n+++ b/file.txt\n@@ -0,0 +1 @@\n+a\n",
+		UnifiedDiff("file.txt", "", "a\n", 3),
+	)
+}
+
+func TestUnifiedDiff_NoNewlineAtEOF(t *testing.T) {
+	assert.Equal(
+		t,
+		"--- a/file.txt\n+++ b/file.txt\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		UnifiedDiff("file.txt", "a\nb", "a\nb\n", 3),
+	)
+}
+
+func TestColorDiff(t *testing.T) {
+	diff := "--- a/file.txt\n+++ b/file.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+
+	assert.Equal(
+		t,
+		"\x1b[0;1;37m--- a/file.txt\x1b[0m\n\x1b[0;1;37m+++ b/file.txt\x1b[0m\n\x1b[0;36m@@ -1,2 +1,2 @@\x1b[0m\n a\n\x1b[0;31m-b\x1b[0m\n\x1b[0;32m+c\x1b[0m\n",
+		ColorDiff(diff),
+	)
+}
+
+func TestColorDiff_Empty(t *testing.T) {
+	assert.Equal(t, "", ColorDiff(""))
+}
+
+func TestIsTerminal(t *testing.T) {
+	assert.False(t, IsTerminal(&bytes.Buffer{}))
+
+	f, err := ioutil.TempFile(t.TempDir(), "out")
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.False(t, IsTerminal(f))
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -33,3 +34,14 @@ func RunCommand(name string, args ...string) (string, error) {
 
 	return string(output), err
 }
+
+// RunShellCommand is a helper to run a command string using the system shell
+// and collect its output from stdout. Output to stderr is not collected, so
+// diagnostic messages do not pollute the result.
+func RunShellCommand(ctx context.Context, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+
+	return string(output), err
+}
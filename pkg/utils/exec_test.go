@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,3 +43,14 @@ func TestNormalize(t *testing.T) {
 		})
 	}
 }
+
+func TestRunShellCommand(t *testing.T) {
+	out, err := RunShellCommand(context.Background(), "echo v1.2.3; echo ignored >&2")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.3\n", out)
+}
+
+func TestRunShellCommand_Error(t *testing.T) {
+	_, err := RunShellCommand(context.Background(), "exit 3")
+	assert.EqualError(t, err, "exit status 3")
+}
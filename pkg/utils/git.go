@@ -1,6 +1,17 @@
 package utils
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
+
+// Commit holds basic information about a git commit.
+type Commit struct {
+	SHA      string
+	ShortSHA string
+	Author   string
+	Subject  string
+}
 
 // IsDirty checks whether a repository is in a dirty state (has uncommitted changes).
 func IsDirty() (bool, string) {
@@ -20,6 +31,55 @@ func GetTag() (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// GetTags gets all of the git tags for a repository.
+func GetTags() ([]string, error) {
+	out, err := RunCommand("git", "tag", "--list")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, line := range strings.Split(out, "\n") {
+		if tag := strings.TrimSpace(line); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// RefExists checks whether the given ref (e.g. a tag or branch name) resolves
+// to a commit in the repository.
+func RefExists(ref string) bool {
+	_, err := RunCommand("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return err == nil
+}
+
+// GetCommits gets the commits which are reachable from the 'to' ref but not
+// from the 'from' ref, ordered from most to least recent.
+func GetCommits(from, to string) ([]Commit, error) {
+	// Use the ASCII unit and record separators to delimit fields and commits,
+	// as they will not appear in commit subjects or author names.
+	out, err := RunCommand("git", "log", "--format=%H%x1f%h%x1f%an%x1f%s%x1e", fmt.Sprintf("%s..%s", from, to))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(out))
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, Commit{
+			SHA:      fields[0],
+			ShortSHA: fields[1],
+			Author:   fields[2],
+			Subject:  fields[3],
+		})
+	}
+	return commits, nil
+}
+
 // InRepo checks whether the directory which chart-release is run from is
 // a git repository.
 func InRepo() bool {
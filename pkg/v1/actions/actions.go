@@ -0,0 +1,192 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/edaniszewski/chart-releaser/pkg/utils"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
+)
+
+// Environment variables set by GitHub Actions.
+const (
+	EnvActions     = "GITHUB_ACTIONS"
+	EnvEventName   = "GITHUB_EVENT_NAME"
+	EnvEventPath   = "GITHUB_EVENT_PATH"
+	EnvOutput      = "GITHUB_OUTPUT"
+	EnvStepSummary = "GITHUB_STEP_SUMMARY"
+)
+
+// The step outputs set for the update.
+const (
+	OutputChartVersion = "chart_version"
+	OutputPRURL        = "pr_url"
+	OutputBranch       = "branch"
+)
+
+// event holds the fields of GitHub Actions event payloads used to find the
+// release tag.
+type event struct {
+	Ref     string `json:"ref"`
+	RefType string `json:"ref_type"`
+	Release *struct {
+		TagName string `json:"tag_name"`
+	} `json:"release"`
+}
+
+// Detect whether chart-releaser is running in GitHub Actions.
+func Detect() context.Actions {
+	if os.Getenv(EnvActions) != "true" {
+		return context.Actions{}
+	}
+	return context.Actions{
+		Enabled:   true,
+		EventName: os.Getenv(EnvEventName),
+	}
+}
+
+// EventTag gets the release tag from the event which triggered the workflow.
+// If there is no event payload, or the event is not for a release or tag, the
+// tag is empty.
+func EventTag(a context.Actions) (string, error) {
+	path := os.Getenv(EnvEventPath)
+	if !a.Enabled || path == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return TagFromEvent(a.EventName, data)
+}
+
+// TagFromEvent gets the release tag from a GitHub Actions event payload. If
+// the event is not for a release or tag, the tag is empty.
+func TagFromEvent(name string, data []byte) (string, error) {
+	var e event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return "", fmt.Errorf("failed to parse %s event: %w", name, err)
+	}
+
+	switch name {
+	case "release":
+		if e.Release != nil {
+			return e.Release.TagName, nil
+		}
+	case "push":
+		if strings.HasPrefix(e.Ref, "refs/tags/") {
+			return strings.TrimPrefix(e.Ref, "refs/tags/"), nil
+		}
+	case "create":
+		if e.RefType == "tag" {
+			return e.Ref, nil
+		}
+	}
+	return "", nil
+}
+
+// Write the step outputs and job summary for the update, if running in
+// GitHub Actions. The error the update failed with, if any, is included in
+// the summary.
+func Write(ctx *context.Context, err error) error {
+	if !ctx.Actions.Enabled {
+		return nil
+	}
+	if path := os.Getenv(EnvOutput); path != "" {
+		if err := appendFile(path, Outputs(ctx)); err != nil {
+			return err
+		}
+	}
+	if path := os.Getenv(EnvStepSummary); path != "" {
+		if err := appendFile(path, Summary(ctx, err)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Outputs gets the step outputs for the update, in the format of the
+// GITHUB_OUTPUT file. Outputs which are not known are empty.
+func Outputs(ctx *context.Context) string {
+	var chartVersion, prURL string
+	if ctx.Chart.File.PreviousContents != nil {
+		chartVersion = ctx.Chart.NewVersion.String()
+	}
+	if pr := ctx.Publish.PullRequest; pr != nil {
+		prURL = pr.URL
+	}
+
+	b := strings.Builder{}
+	for _, o := range [][2]string{
+		{OutputChartVersion, chartVersion},
+		{OutputPRURL, prURL},
+		{OutputBranch, ctx.Git.Ref},
+	} {
+		b.WriteString(fmt.Sprintf("%s=%s\n", o[0], o[1]))
+	}
+	return b.String()
+}
+
+// Summary gets the job summary markdown for the update, including the diff
+// of any changed files.
+func Summary(ctx *context.Context, err error) string {
+	b := strings.Builder{}
+	b.WriteString("## chart-releaser\n\n")
+
+	switch {
+	case err != nil:
+		b.WriteString(fmt.Sprintf("Failed to update chart **%s**: %s\n\n", ctx.Chart.Name, err))
+	case ctx.DryRun:
+		b.WriteString(fmt.Sprintf("Dry-run of update for chart **%s** - no changes were published.\n\n", ctx.Chart.Name))
+	case ctx.Publish.SkipReason != "":
+		b.WriteString(fmt.Sprintf("Skipped update of chart **%s**: %s\n\n", ctx.Chart.Name, ctx.Publish.SkipReason))
+	default:
+		b.WriteString(fmt.Sprintf("Updated chart **%s**.\n\n", ctx.Chart.Name))
+	}
+
+	b.WriteString("| | |\n| --- | --- |\n")
+	if ctx.Git.Tag != "" {
+		b.WriteString(fmt.Sprintf("| Application version | `%s` |\n", ctx.App.NewVersion.String()))
+	}
+	if ctx.Chart.File.PreviousContents != nil {
+		b.WriteString(fmt.Sprintf("| Chart version | `%s` → `%s` |\n", ctx.Chart.PreviousVersion.String(), ctx.Chart.NewVersion.String()))
+	}
+	if ctx.Git.Ref != "" {
+		b.WriteString(fmt.Sprintf("| Branch | `%s` |\n", ctx.Git.Ref))
+	}
+	if pr := ctx.Publish.PullRequest; pr != nil {
+		b.WriteString(fmt.Sprintf("| Pull request | [#%d](%s) |\n", pr.Number, pr.URL))
+	}
+
+	var changed []report.File
+	for _, f := range report.Files(ctx, utils.DefaultDiffContext) {
+		if f.Changed {
+			changed = append(changed, f)
+		}
+	}
+	if len(changed) != 0 {
+		b.WriteString("\n### Changes\n")
+		for _, f := range changed {
+			b.WriteString(fmt.Sprintf("\n<details><summary><code>%s</code></summary>\n\n```diff\n%s```\n\n</details>\n", f.Path, f.Diff))
+		}
+	}
+	return b.String()
+}
+
+// appendFile appends the data to the file at the path. GitHub Actions files
+// are shared by all steps of a job, so they are never truncated.
+func appendFile(path, data string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
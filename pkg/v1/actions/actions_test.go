@@ -0,0 +1,252 @@
+package actions
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/stretchr/testify/assert"
+)
+
+// setenv sets an environment variable for the duration of a test.
+func setenv(t *testing.T, key, value string) {
+	orig, found := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if found {
+			_ = os.Setenv(key, orig)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+// writeEvent writes an event payload to a temporary file, returning its path.
+func writeEvent(t *testing.T, payload string) string {
+	path := filepath.Join(t.TempDir(), "event.json")
+	if err := ioutil.WriteFile(path, []byte(payload), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDetect_NotActions(t *testing.T) {
+	setenv(t, EnvActions, "")
+
+	assert.Equal(t, ctx.Actions{}, Detect())
+}
+
+func TestDetect(t *testing.T) {
+	setenv(t, EnvActions, "true")
+	setenv(t, EnvEventName, "release")
+
+	assert.Equal(t, ctx.Actions{Enabled: true, EventName: "release"}, Detect())
+}
+
+func TestEventTag(t *testing.T) {
+	setenv(t, EnvEventPath, writeEvent(t, `{"action":"published","release":{"tag_name":"v1.3.0"}}`))
+
+	tag, err := EventTag(ctx.Actions{Enabled: true, EventName: "release"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", tag)
+}
+
+func TestEventTag_NotActions(t *testing.T) {
+	setenv(t, EnvEventPath, writeEvent(t, `{"action":"published","release":{"tag_name":"v1.3.0"}}`))
+
+	tag, err := EventTag(ctx.Actions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", tag)
+}
+
+func TestEventTag_NoEvent(t *testing.T) {
+	setenv(t, EnvEventPath, "")
+
+	tag, err := EventTag(ctx.Actions{Enabled: true, EventName: "workflow_dispatch"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", tag)
+}
+
+func TestEventTag_Error(t *testing.T) {
+	setenv(t, EnvEventPath, writeEvent(t, `{`))
+
+	_, err := EventTag(ctx.Actions{Enabled: true, EventName: "release"})
+	assert.EqualError(t, err, "failed to parse release event: unexpected end of JSON input")
+}
+
+func TestTagFromEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		payload string
+		tag     string
+	}{
+		{"release", "release", `{"release":{"tag_name":"v1.0.0"}}`, "v1.0.0"},
+		{"push tag", "push", `{"ref":"refs/tags/v1.0.0"}`, "v1.0.0"},
+		{"push branch", "push", `{"ref":"refs/heads/master"}`, ""},
+		{"create tag", "create", `{"ref":"v1.0.0","ref_type":"tag"}`, "v1.0.0"},
+		{"create branch", "create", `{"ref":"feature","ref_type":"branch"}`, ""},
+		{"other", "pull_request", `{"number":3}`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tag, err := TagFromEvent(test.event, []byte(test.payload))
+			assert.NoError(t, err)
+			assert.Equal(t, test.tag, tag)
+		})
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	context := ctx.Context{
+		Chart: ctx.Chart{
+			File: ctx.File{
+				PreviousContents: []byte("version: 0.1.0\n"),
+			},
+			NewVersion: testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Ref: "chartreleaser/foo/0.2.0",
+		},
+		Publish: ctx.Publish{
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/example/charts/pull/7",
+			},
+		},
+	}
+
+	assert.Equal(t,
+		"chart_version=0.2.0\npr_url=https://github.com/example/charts/pull/7\nbranch=chartreleaser/foo/0.2.0\n",
+		Outputs(&context),
+	)
+}
+
+func TestOutputs_Empty(t *testing.T) {
+	assert.Equal(t, "chart_version=\npr_url=\nbranch=\n", Outputs(&ctx.Context{}))
+}
+
+func TestSummary(t *testing.T) {
+	context := ctx.Context{
+		Actions: ctx.Actions{
+			Enabled:   true,
+			EventName: "release",
+		},
+		App: ctx.App{
+			NewVersion: testutils.NewSemver(t, "v1.3.0"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+			Ref: "chartreleaser/foo/0.2.0",
+		},
+		Publish: ctx.Publish{
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/example/charts/pull/7",
+			},
+		},
+	}
+
+	assert.Equal(t, "## chart-releaser\n\n"+
+		"Updated chart **foo**.\n\n"+
+		"| | |\n| --- | --- |\n"+
+		"| Application version | `v1.3.0` |\n"+
+		"| Chart version | `0.1.0` → `0.2.0` |\n"+
+		"| Branch | `chartreleaser/foo/0.2.0` |\n"+
+		"| Pull request | [#7](https://github.com/example/charts/pull/7) |\n"+
+		"\n### Changes\n"+
+		"\n<details><summary><code>charts/foo/Chart.yaml</code></summary>\n\n"+
+		"```diff\n"+
+		"--- a/charts/foo/Chart.yaml\n"+
+		"+++ b/charts/foo/Chart.yaml\n"+
+		"@@ -1 +1 @@\n"+
+		"-version: 0.1.0\n"+
+		"+version: 0.2.0\n"+
+		"```\n\n</details>\n",
+		Summary(&context, nil),
+	)
+}
+
+func TestSummary_Failed(t *testing.T) {
+	assert.Equal(t, "## chart-releaser\n\n"+
+		"Failed to update chart **foo**: no token\n\n"+
+		"| | |\n| --- | --- |\n",
+		Summary(&ctx.Context{Chart: ctx.Chart{Name: "foo"}}, errors.New("no token")),
+	)
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+	summary := filepath.Join(dir, "summary")
+	assert.NoError(t, ioutil.WriteFile(output, []byte("previous=step\n"), 0644))
+	setenv(t, EnvOutput, output)
+	setenv(t, EnvStepSummary, summary)
+
+	context := &ctx.Context{
+		Actions: ctx.Actions{
+			Enabled:   true,
+			EventName: "release",
+		},
+		App: ctx.App{
+			NewVersion: testutils.NewSemver(t, "v1.3.0"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+			Ref: "chartreleaser/foo/0.2.0",
+		},
+		Publish: ctx.Publish{
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/example/charts/pull/7",
+			},
+		},
+	}
+	err := Write(context, nil)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "previous=step\n"+Outputs(context), string(data))
+
+	data, err = ioutil.ReadFile(summary)
+	assert.NoError(t, err)
+	assert.Equal(t, Summary(context, nil), string(data))
+}
+
+func TestWrite_NotActions(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "output")
+	setenv(t, EnvOutput, output)
+
+	err := Write(&ctx.Context{}, nil)
+	assert.NoError(t, err)
+	assert.NoFileExists(t, output)
+}
@@ -3,6 +3,8 @@ package v1
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/edaniszewski/chart-releaser/pkg/errs"
@@ -14,6 +16,10 @@ import (
 // This is always expected to be "v1" for v1 configs.
 const ConfigVersion = "v1"
 
+// TagPatternGroup is the name of the capture group in the release tag_pattern
+// which holds the application version.
+const TagPatternGroup = "version"
+
 // Errors for v1 configuration parsing and validation.
 var (
 	ErrNoChart         = errors.New("required option 'chart' missing from config")
@@ -25,12 +31,26 @@ var (
 // Config contains the configuration options for chart-releaser's
 // v1 configuration scheme.
 type Config struct {
-	Version string          `yaml:"version,omitempty"`
-	Chart   *ChartConfig    `yaml:"chart,omitempty"`
-	Publish *PublishConfig  `yaml:"publish,omitempty"`
-	Commit  *CommitConfig   `yaml:"commit,omitempty"`
-	Release *ReleaseConfig  `yaml:"release,omitempty"`
-	Extras  []*ExtrasConfig `yaml:"extras,omitempty"`
+	Version  string                 `yaml:"version,omitempty" json:"version,omitempty"`
+	Chart    *ChartConfig           `yaml:"chart,omitempty" json:"chart,omitempty"`
+	Publish  *PublishConfig         `yaml:"publish,omitempty" json:"publish,omitempty"`
+	Commit   *CommitConfig          `yaml:"commit,omitempty" json:"commit,omitempty"`
+	Release  *ReleaseConfig         `yaml:"release,omitempty" json:"release,omitempty"`
+	Extras   []*ExtrasConfig        `yaml:"extras,omitempty" json:"extras,omitempty"`
+	Includes []string               `yaml:"includes,omitempty" json:"includes,omitempty"`
+	Pipeline *PipelineConfig        `yaml:"pipeline,omitempty" json:"pipeline,omitempty"`
+	Hooks    map[string]*HookConfig `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+
+	Notifications []*NotificationConfig `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+
+	// templateFiles holds the contents of the template files loaded for the
+	// Config. Named templates defined in these files are available to all
+	// templates in the Config.
+	templateFiles []Field
+
+	// restricted is set if the Config comes from a source which is not
+	// trusted; see Restrict.
+	restricted bool
 }
 
 // LoadFromBytes attempts to load raw bytes into a Config struct.
@@ -42,6 +62,22 @@ func LoadFromBytes(b []byte) (*Config, error) {
 	return &c, nil
 }
 
+// Load attempts to load raw bytes into a Config struct, along with the
+// template files it references. Paths are resolved relative to dir, which
+// should be the directory containing the config file. If the config was not
+// loaded from a file, dir should be empty; any template file references are
+// then an error.
+func Load(b []byte, dir string) (*Config, error) {
+	c, err := LoadFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.LoadTemplateFiles(dir); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 // GetVersion returns the version for the configuration format.
 func (c *Config) GetVersion() string {
 	return c.Version
@@ -93,19 +129,133 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for _, stage := range hookStages(c.Hooks) {
+		if err := c.Hooks[stage].validate(stage); err != nil {
+			collector.Add(err)
+		}
+	}
+
+	for i, n := range c.Notifications {
+		if err := n.validate(i); err != nil {
+			collector.Add(err)
+		}
+	}
+
+	// Check that all templates parse and that all regular expressions compile
+	// so errors are surfaced upfront, rather than partway through an update.
+	if _, err := c.NewTemplate(""); err != nil {
+		collector.Add(fmt.Errorf("invalid template in includes: %v", err))
+	}
+	for _, t := range c.Templates() {
+		if _, err := c.newTemplate(t.Location).Parse(t.Value); err != nil {
+			collector.Add(fmt.Errorf("invalid template at '%s': %v", t.Location, err))
+		}
+	}
+	for _, p := range c.Patterns() {
+		if _, err := regexp.Compile(p.Value); err != nil {
+			collector.Add(fmt.Errorf("invalid regex at '%s': %v", p.Location, err))
+		}
+	}
+
 	if collector.HasErrors() {
 		return collector
 	}
 	return nil
 }
 
+// Field is a value set in the Config along with its location in the config,
+// e.g. "publish.pr.title_template".
+type Field struct {
+	Location string
+	Value    string
+}
+
+// Templates gets all of the templates set in the Config, along with their
+// locations. Templates which are not set are not included.
+func (c *Config) Templates() []Field {
+	var fields []Field
+	for _, ref := range c.templateRefs() {
+		if *ref.value != "" {
+			fields = append(fields, Field{Location: ref.location, Value: *ref.value})
+		}
+	}
+	return fields
+}
+
+// templateRef is a reference to a template field in the Config.
+type templateRef struct {
+	location string
+	value    *string
+}
+
+// templateRefs gets references to all of the template fields in the Config,
+// along with their locations.
+func (c *Config) templateRefs() []templateRef {
+	var refs []templateRef
+	add := func(location string, value *string) {
+		refs = append(refs, templateRef{location: location, value: value})
+	}
+
+	if c.Commit != nil && c.Commit.Templates != nil {
+		add("commit.templates.update", &c.Commit.Templates.Update)
+		add("commit.templates.extras", &c.Commit.Templates.Extras)
+	}
+	if c.Publish != nil {
+		if c.Publish.Commit != nil {
+			add("publish.commit.branch", &c.Publish.Commit.Branch)
+			add("publish.commit.base", &c.Publish.Commit.Base)
+		}
+		if c.Publish.PR != nil {
+			add("publish.pr.branch_template", &c.Publish.PR.BranchTemplate)
+			add("publish.pr.base", &c.Publish.PR.Base)
+			add("publish.pr.title_template", &c.Publish.PR.TitleTemplate)
+			add("publish.pr.body_template", &c.Publish.PR.BodyTemplate)
+		}
+	}
+	for i, extra := range c.Extras {
+		for j, u := range extra.Updates {
+			add(fmt.Sprintf("extras[%d].updates[%d].replace", i, j), &u.Replace)
+		}
+	}
+	for i, n := range c.Notifications {
+		if n != nil {
+			add(fmt.Sprintf("notifications[%d].url", i), &n.URL)
+			add(fmt.Sprintf("notifications[%d].template", i), &n.Template)
+		}
+	}
+	return refs
+}
+
+// Patterns gets all of the regular expressions set in the Config, along with
+// their locations. The release tag_pattern is not included, as it is validated
+// separately.
+func (c *Config) Patterns() []Field {
+	var fields []Field
+	if c.Release != nil {
+		for i, m := range c.Release.Matches {
+			fields = append(fields, Field{Location: fmt.Sprintf("release.matches[%d]", i), Value: m})
+		}
+		for i, ig := range c.Release.Ignores {
+			fields = append(fields, Field{Location: fmt.Sprintf("release.ignores[%d]", i), Value: ig})
+		}
+	}
+	for i, extra := range c.Extras {
+		for j, u := range extra.Updates {
+			if u.Search != "" {
+				fields = append(fields, Field{Location: fmt.Sprintf("extras[%d].updates[%d].search", i, j), Value: u.Search})
+			}
+		}
+	}
+	return fields
+}
+
 // ChartConfig contains the options for the v1 configuration's "chart"
 // section. These options provide definitions for where chart-releaser
 // can locate the Helm Chart for the configured project.
 type ChartConfig struct {
-	Name string `yaml:"name,omitempty"`
-	Repo string `yaml:"repo,omitempty"`
-	Path string `yaml:"path,omitempty"`
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	Repo string `yaml:"repo,omitempty" json:"repo,omitempty"`
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
 }
 
 // validate the ChartConfig is correct.
@@ -134,8 +284,8 @@ func (c *ChartConfig) validate() error {
 // section. These options provide definitions for how chart-releaser should
 // behave when publishing changes to the chart repo for a new application version.
 type PublishConfig struct {
-	Commit *PublishCommitConfig `yaml:"commit,omitempty"`
-	PR     *PublishPRConfig     `yaml:"pr,omitempty"`
+	Commit *PublishCommitConfig `yaml:"commit,omitempty" json:"commit,omitempty"`
+	PR     *PublishPRConfig     `yaml:"pr,omitempty" json:"pr,omitempty"`
 }
 
 // validate the PublishConfig is correct.
@@ -171,8 +321,8 @@ func (c *PublishConfig) validate() error {
 // section. These options provide definitions for how chart-releaser should
 // format commits as well as metadata about the committer.
 type CommitConfig struct {
-	Author    *CommitAuthorConfig   `yaml:"author,omitempty"`
-	Templates *CommitTemplateConfig `yaml:"templates,omitempty"`
+	Author    *CommitAuthorConfig   `yaml:"author,omitempty" json:"author,omitempty"`
+	Templates *CommitTemplateConfig `yaml:"templates,omitempty" json:"templates,omitempty"`
 }
 
 // validate the CommitConfig is correct.
@@ -202,13 +352,14 @@ func (c *CommitConfig) validate() error {
 // CommitTemplateConfig defines the templates for commit messages for different
 // operations.
 type CommitTemplateConfig struct {
-	Update string `yaml:"update,omitempty"`
-	Extras string `yaml:"extras,omitempty"`
+	Update string `yaml:"update,omitempty" json:"update,omitempty"`
+	Extras string `yaml:"extras,omitempty" json:"extras,omitempty"`
 }
 
 // validate the CommitTemplateConfig is correct.
 func (c *CommitTemplateConfig) validate() error {
-	// TODO (etd): could check if templates are valid upfront
+	// Templates are checked for the Config as a whole (see Config.Templates)
+	// so errors can be reported with their location.
 	return nil
 }
 
@@ -216,9 +367,16 @@ func (c *CommitTemplateConfig) validate() error {
 // section. These options provide definitions for how chart-releaser should
 // operate when a new release of the application is cut.
 type ReleaseConfig struct {
-	Matches  []string `yaml:"matches,omitempty"`
-	Ignores  []string `yaml:"ignores,omitempty"`
-	Strategy string   `yaml:"strategy,omitempty"`
+	Matches        []string             `yaml:"matches,omitempty" json:"matches,omitempty"`
+	Ignores        []string             `yaml:"ignores,omitempty" json:"ignores,omitempty"`
+	Strategy       string               `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+	TagPattern     string               `yaml:"tag_pattern,omitempty" json:"tag_pattern,omitempty"`
+	TagNormalize   bool                 `yaml:"tag_normalize,omitempty" json:"tag_normalize,omitempty"`
+	SourceRepo     string               `yaml:"source_repo,omitempty" json:"source_repo,omitempty"`
+	VersionSource  *VersionSourceConfig `yaml:"version_source,omitempty" json:"version_source,omitempty"`
+	PreviousSource string               `yaml:"previous_source,omitempty" json:"previous_source,omitempty"`
+	Changelog      *ChangelogConfig     `yaml:"changelog,omitempty" json:"changelog,omitempty"`
+	ReleaseNotes   bool                 `yaml:"release_notes,omitempty" json:"release_notes,omitempty"`
 }
 
 // validate the ReleaseConfig is correct.
@@ -233,6 +391,87 @@ func (c *ReleaseConfig) validate() error {
 		}
 	}
 
+	// The tag pattern is used to extract the application version from the
+	// tag, so it must compile and define a named "version" capture group.
+	if c.TagPattern != "" {
+		re, err := regexp.Compile(c.TagPattern)
+		if err != nil {
+			collector.Add(fmt.Errorf("invalid release tag_pattern '%v': %v", c.TagPattern, err))
+		} else if re.SubexpIndex(TagPatternGroup) == -1 {
+			collector.Add(fmt.Errorf("invalid release tag_pattern '%v': no named capture group '%s'", c.TagPattern, TagPatternGroup))
+		}
+	}
+
+	if c.PreviousSource != "" {
+		if _, err := strategies.PreviousSourceFromString(c.PreviousSource); err != nil {
+			collector.Add(fmt.Errorf("invalid release previous_source '%v', should be one of: %v", c.PreviousSource, strategies.ListPreviousSources()))
+		}
+	}
+
+	if c.VersionSource == nil {
+		c.VersionSource = &VersionSourceConfig{}
+	}
+	if err := c.VersionSource.validate(); err != nil {
+		collector.Add(err)
+	}
+
+	if c.Changelog == nil {
+		c.Changelog = &ChangelogConfig{}
+	}
+	if err := c.Changelog.validate(); err != nil {
+		collector.Add(err)
+	}
+
+	if collector.HasErrors() {
+		return collector
+	}
+	return nil
+}
+
+// VersionSourceConfig defines where chart-releaser gets the version of the
+// application being released. If no type is set, the version is taken from
+// the latest git tag.
+type VersionSourceConfig struct {
+	Type    string   `yaml:"type,omitempty" json:"type,omitempty"`
+	Env     []string `yaml:"env,omitempty" json:"env,omitempty"`
+	File    string   `yaml:"file,omitempty" json:"file,omitempty"`
+	Command string   `yaml:"command,omitempty" json:"command,omitempty"`
+}
+
+// validate the VersionSourceConfig is correct.
+func (c *VersionSourceConfig) validate() error {
+	collector := errs.NewCollector()
+
+	if c.Type != "" {
+		source, err := strategies.VersionSourceFromString(c.Type)
+		if err != nil {
+			collector.Add(fmt.Errorf("invalid release version_source type '%v', should be one of: %v", c.Type, strategies.ListVersionSources()))
+		} else if source == strategies.SourceCommand && c.Command == "" {
+			collector.Add(fmt.Errorf("release version_source type 'command' requires 'command' to be set"))
+		}
+	}
+
+	if collector.HasErrors() {
+		return collector
+	}
+	return nil
+}
+
+// ChangelogConfig defines how chart-releaser collects the application commits
+// made between the previous release and the new release.
+type ChangelogConfig struct {
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	Limit    int  `yaml:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// validate the ChangelogConfig is correct.
+func (c *ChangelogConfig) validate() error {
+	collector := errs.NewCollector()
+
+	if c.Limit < 0 {
+		collector.Add(fmt.Errorf("release changelog must have a non-negative limit"))
+	}
+
 	if collector.HasErrors() {
 		return collector
 	}
@@ -242,8 +481,8 @@ func (c *ReleaseConfig) validate() error {
 // PublishCommitConfig contains additional options for how chart-releaser
 // should behave when using the "commit" update strategy.
 type PublishCommitConfig struct {
-	Branch string `yaml:"branch,omitempty"`
-	Base   string `yaml:"base,omitempty"`
+	Branch string `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Base   string `yaml:"base,omitempty" json:"base,omitempty"`
 }
 
 // validate the UpdateCommitConfig is correct.
@@ -254,23 +493,24 @@ func (c *PublishCommitConfig) validate() error {
 // PublishPRConfig contains additional options for how chart-releaser should
 // behave when using the "pull request" update strategy.
 type PublishPRConfig struct {
-	BranchTemplate string `yaml:"branch_template,omitempty"`
-	Base           string `yaml:"base,omitempty"`
-	TitleTemplate  string `yaml:"title_template,omitempty"`
-	BodyTemplate   string `yaml:"body_template,omitempty"`
+	BranchTemplate string `yaml:"branch_template,omitempty" json:"branch_template,omitempty"`
+	Base           string `yaml:"base,omitempty" json:"base,omitempty"`
+	TitleTemplate  string `yaml:"title_template,omitempty" json:"title_template,omitempty"`
+	BodyTemplate   string `yaml:"body_template,omitempty" json:"body_template,omitempty"`
 }
 
 // validate the PublishPRConfig is correct.
 func (c *PublishPRConfig) validate() error {
-	// TODO (etd): could check if templates are valid upfront
+	// Templates are checked for the Config as a whole (see Config.Templates)
+	// so errors can be reported with their location.
 	return nil
 }
 
 // CommitAuthorConfig provides the commit metadata for who the author of
 // the commits made by chart-releaser will be.
 type CommitAuthorConfig struct {
-	Name  string `yaml:"name,omitempty"`
-	Email string `yaml:"email,omitempty"`
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Email string `yaml:"email,omitempty" json:"email,omitempty"`
 }
 
 // validate the CommitAuthorConfig is correct.
@@ -291,11 +531,132 @@ func (c *CommitAuthorConfig) validate() error {
 	return nil
 }
 
+// PipelineConfig is used to select which stages of the update pipeline run.
+// If Only is set, only the listed stages run; any stages listed in Skip do
+// not run.
+type PipelineConfig struct {
+	Skip []string `yaml:"skip,omitempty" json:"skip,omitempty"`
+	Only []string `yaml:"only,omitempty" json:"only,omitempty"`
+}
+
+// HookConfig defines shell commands run before and after a stage of the
+// update pipeline. Hooks are configured per stage, keyed by the stage name.
+type HookConfig struct {
+	Before []string `yaml:"before,omitempty" json:"before,omitempty"`
+	After  []string `yaml:"after,omitempty" json:"after,omitempty"`
+}
+
+// validate the HookConfig for the named stage is correct.
+func (c *HookConfig) validate(stage string) error {
+	if c == nil {
+		return nil
+	}
+	collector := errs.NewCollector()
+
+	for i, cmd := range c.Before {
+		if strings.TrimSpace(cmd) == "" {
+			collector.Add(fmt.Errorf("empty command at 'hooks.%s.before[%d]'", stage, i))
+		}
+	}
+	for i, cmd := range c.After {
+		if strings.TrimSpace(cmd) == "" {
+			collector.Add(fmt.Errorf("empty command at 'hooks.%s.after[%d]'", stage, i))
+		}
+	}
+
+	if collector.HasErrors() {
+		return collector
+	}
+	return nil
+}
+
+// hookStages gets the stages which hooks are configured for in sorted order,
+// so hooks are validated in a consistent order.
+func hookStages(m map[string]*HookConfig) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// The types of notifications which can be sent.
+const (
+	NotifySlack   = "slack"
+	NotifyTeams   = "teams"
+	NotifyWebhook = "webhook"
+)
+
+// The outcomes of an update which notifications can be sent on.
+const (
+	NotifyOnSuccess = "success"
+	NotifyOnFailure = "failure"
+)
+
+// NotificationTypes are all of the supported notification types.
+var NotificationTypes = []string{NotifySlack, NotifyTeams, NotifyWebhook}
+
+// NotificationDriftLevels are all of the application version drift levels
+// which notifications can be filtered on.
+var NotificationDriftLevels = []string{"major", "minor", "patch", "prerelease"}
+
+// NotificationConfig defines a notification sent when an update is published
+// or fails.
+type NotificationConfig struct {
+	Type     string            `yaml:"type,omitempty" json:"type,omitempty"`
+	URL      string            `yaml:"url,omitempty" json:"url,omitempty"`
+	Template string            `yaml:"template,omitempty" json:"template,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	On       []string          `yaml:"on,omitempty" json:"on,omitempty"`
+	Drift    []string          `yaml:"drift,omitempty" json:"drift,omitempty"`
+}
+
+// validate the NotificationConfig at the given index is correct.
+func (c *NotificationConfig) validate(idx int) error {
+	if c == nil {
+		return fmt.Errorf("empty notification config at 'notifications[%d]'", idx)
+	}
+	collector := errs.NewCollector()
+
+	if !containsString(NotificationTypes, c.Type) {
+		collector.Add(fmt.Errorf("invalid notifications[%d].type '%s', should be one of: %v", idx, c.Type, NotificationTypes))
+	}
+	if c.URL == "" {
+		collector.Add(fmt.Errorf("required option 'notifications[%d].url' missing from config", idx))
+	}
+	for _, on := range c.On {
+		if on != NotifyOnSuccess && on != NotifyOnFailure {
+			collector.Add(fmt.Errorf("invalid notifications[%d].on '%s', should be one of: [%s %s]", idx, on, NotifyOnSuccess, NotifyOnFailure))
+		}
+	}
+	for _, drift := range c.Drift {
+		if !containsString(NotificationDriftLevels, drift) {
+			collector.Add(fmt.Errorf("invalid notifications[%d].drift '%s', should be one of: %v", idx, drift, NotificationDriftLevels))
+		}
+	}
+
+	if collector.HasErrors() {
+		return collector
+	}
+	return nil
+}
+
+// containsString checks whether the string is in the list.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 // ExtrasConfig is used to specify additional files within the configured
 // repository to update via a regular expression (regex).
 type ExtrasConfig struct {
-	Path    string           `yaml:"path,omitempty"`
-	Updates []*SearchReplace `yaml:"updates,omitempty"`
+	Path    string           `yaml:"path,omitempty" json:"path,omitempty"`
+	Updates []*SearchReplace `yaml:"updates,omitempty" json:"updates,omitempty"`
 }
 
 // validate the ExtrasConfig is correct.
@@ -327,9 +688,9 @@ func (c *ExtrasConfig) validate() error {
 // SearchReplace defines a regex to search for and a value to replace the found
 // match(es) to the regex.
 type SearchReplace struct {
-	Search  string `yaml:"search,omitempty"`
-	Replace string `yaml:"replace,omitempty"`
-	Limit   int    `yaml:"limit,omitempty"`
+	Search  string `yaml:"search,omitempty" json:"search,omitempty"`
+	Replace string `yaml:"replace,omitempty" json:"replace,omitempty"`
+	Limit   int    `yaml:"limit,omitempty" json:"limit,omitempty"`
 }
 
 // validate the SearchReplace is correct.
@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/edaniszewski/chart-releaser/pkg/errs"
@@ -25,6 +27,93 @@ func TestLoadFromBytes_Error(t *testing.T) {
 	assert.EqualError(t, err, "error converting YAML to JSON: yaml: control characters are not allowed")
 }
 
+func TestLoadFromBytes_TagPattern(t *testing.T) {
+	b := []byte(`
+version: v1
+publish:
+  pr:
+    title_template: test-title
+release:
+  tag_pattern: ^api/v(?P<version>.+)$
+  tag_normalize: true
+`)
+
+	c, err := LoadFromBytes(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-title", c.Publish.PR.TitleTemplate)
+	assert.Equal(t, "^api/v(?P<version>.+)$", c.Release.TagPattern)
+	assert.True(t, c.Release.TagNormalize)
+}
+
+func TestLoadFromBytes_VersionSource(t *testing.T) {
+	b := []byte(`
+version: v1
+release:
+  source_repo: github.com/example/app
+  version_source:
+    type: file
+    file: VERSION
+`)
+
+	c, err := LoadFromBytes(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/example/app", c.Release.SourceRepo)
+	assert.Equal(t, &VersionSourceConfig{Type: "file", File: "VERSION"}, c.Release.VersionSource)
+}
+
+func TestLoadFromBytes_PreviousSource(t *testing.T) {
+	b := []byte(`
+version: v1
+release:
+  previous_source: git
+`)
+
+	c, err := LoadFromBytes(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "git", c.Release.PreviousSource)
+}
+
+func TestLoadFromBytes_Changelog(t *testing.T) {
+	b := []byte(`
+version: v1
+release:
+  changelog:
+    disabled: true
+    limit: 10
+`)
+
+	c, err := LoadFromBytes(b)
+	assert.NoError(t, err)
+	assert.Equal(t, &ChangelogConfig{Disabled: true, Limit: 10}, c.Release.Changelog)
+}
+
+func TestLoadFromBytes_ReleaseNotes(t *testing.T) {
+	b := []byte(`
+version: v1
+release:
+  release_notes: true
+`)
+
+	c, err := LoadFromBytes(b)
+	assert.NoError(t, err)
+	assert.True(t, c.Release.ReleaseNotes)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "update.txt"), []byte("update {{ .Chart.Name }}"), 0644))
+
+	cfg, err := Load([]byte("version: v1\ncommit:\n  templates:\n    update: file:update.txt\n"), dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "update {{ .Chart.Name }}", cfg.Commit.Templates.Update)
+}
+
+func TestLoad_TemplateFileError(t *testing.T) {
+	cfg, err := Load([]byte("version: v1\ncommit:\n  templates:\n    update: file:update.txt\n"), "")
+	assert.Error(t, err)
+	assert.Nil(t, cfg)
+}
+
 func TestConfig_GetVersion(t *testing.T) {
 	c := Config{
 		Version: "v1",
@@ -117,6 +206,109 @@ Errors:
 `)
 }
 
+func TestConfig_Validate_TemplateAndRegexErrors(t *testing.T) {
+	cfg := Config{
+		Version: "v1",
+		Chart: &ChartConfig{
+			Name: "test-chart",
+			Repo: "github.com/test/test-chart",
+		},
+		Publish: &PublishConfig{
+			PR: &PublishPRConfig{
+				TitleTemplate: "{{ .App.NewVersion ",
+			},
+		},
+		Commit: &CommitConfig{
+			Templates: &CommitTemplateConfig{
+				Update: "{{ if }}",
+			},
+		},
+		Release: &ReleaseConfig{
+			Matches: []string{"^v1.*$", "[a-"},
+		},
+		Extras: []*ExtrasConfig{
+			{
+				Path: "README.md",
+				Updates: []*SearchReplace{
+					{Search: "ok", Replace: "ok"},
+					{Search: "(", Replace: "{{ end }}"},
+				},
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+
+	collector, ok := err.(*errs.Collector)
+	assert.True(t, ok, "error is an instance of errs.Collector")
+	assert.Equal(t, 5, collector.Count())
+	assert.Contains(t, err.Error(), "invalid template at 'commit.templates.update'")
+	assert.Contains(t, err.Error(), "invalid template at 'publish.pr.title_template'")
+	assert.Contains(t, err.Error(), "invalid template at 'extras[0].updates[1].replace'")
+	assert.Contains(t, err.Error(), "invalid regex at 'release.matches[1]'")
+	assert.Contains(t, err.Error(), "invalid regex at 'extras[0].updates[1].search'")
+}
+
+func TestConfig_Templates(t *testing.T) {
+	cfg := Config{
+		Publish: &PublishConfig{
+			PR: &PublishPRConfig{
+				BranchTemplate: "branch",
+				BodyTemplate:   "body",
+			},
+		},
+		Commit: &CommitConfig{
+			Templates: &CommitTemplateConfig{
+				Extras: "extras",
+			},
+		},
+		Extras: []*ExtrasConfig{
+			{
+				Path: "README.md",
+				Updates: []*SearchReplace{
+					{Search: "a", Replace: "b"},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []Field{
+		{Location: "commit.templates.extras", Value: "extras"},
+		{Location: "publish.pr.branch_template", Value: "branch"},
+		{Location: "publish.pr.body_template", Value: "body"},
+		{Location: "extras[0].updates[0].replace", Value: "b"},
+	}, cfg.Templates())
+}
+
+func TestConfig_Templates_Empty(t *testing.T) {
+	cfg := Config{}
+	assert.Empty(t, cfg.Templates())
+}
+
+func TestConfig_Patterns(t *testing.T) {
+	cfg := Config{
+		Release: &ReleaseConfig{
+			Matches: []string{"m"},
+			Ignores: []string{"i"},
+		},
+		Extras: []*ExtrasConfig{
+			{
+				Path: "README.md",
+				Updates: []*SearchReplace{
+					{Search: "a", Replace: "b"},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []Field{
+		{Location: "release.matches[0]", Value: "m"},
+		{Location: "release.ignores[0]", Value: "i"},
+		{Location: "extras[0].updates[0].search", Value: "a"},
+	}, cfg.Patterns())
+}
+
 func TestChartConfig_validate(t *testing.T) {
 	cfg := ChartConfig{
 		Name: "test-chart",
@@ -263,6 +455,137 @@ Errors:
 `)
 }
 
+func TestReleaseConfig_validateTagPattern(t *testing.T) {
+	cfg := ReleaseConfig{
+		TagPattern:   `^api/(?P<version>v\d+\.\d+\.\d+)$`,
+		TagNormalize: true,
+	}
+
+	err := cfg.validate()
+	assert.NoError(t, err)
+}
+
+func TestReleaseConfig_validateTagPatternErrors(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{
+			pattern:  "*",
+			expected: "invalid release tag_pattern '*': error parsing regexp: missing argument to repetition operator: `*`",
+		},
+		{
+			pattern:  "^api/(.*)$",
+			expected: "invalid release tag_pattern '^api/(.*)$': no named capture group 'version'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			cfg := ReleaseConfig{
+				TagPattern: test.pattern,
+			}
+
+			err := cfg.validate()
+			assert.Error(t, err)
+
+			collector, ok := err.(*errs.Collector)
+			assert.True(t, ok, "error is an instance of errs.Collector")
+			assert.Equal(t, 1, collector.Count())
+			assert.EqualError(t, err, "\nErrors:\n • "+test.expected+"\n\n")
+		})
+	}
+}
+
+func TestReleaseConfig_validatePreviousSource(t *testing.T) {
+	for _, source := range []string{"", "chart", "git"} {
+		t.Run(source, func(t *testing.T) {
+			cfg := ReleaseConfig{
+				PreviousSource: source,
+			}
+
+			err := cfg.validate()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReleaseConfig_validatePreviousSourceError(t *testing.T) {
+	cfg := ReleaseConfig{
+		PreviousSource: "invalid",
+	}
+
+	err := cfg.validate()
+	assert.EqualError(t, err, "\nErrors:\n • invalid release previous_source 'invalid', should be one of: [chart git]\n\n")
+}
+
+func TestChangelogConfig_validate(t *testing.T) {
+	cfg := ChangelogConfig{
+		Limit: 10,
+	}
+
+	err := cfg.validate()
+	assert.NoError(t, err)
+}
+
+func TestChangelogConfig_validateErrors(t *testing.T) {
+	cfg := ChangelogConfig{
+		Limit: -1,
+	}
+
+	err := cfg.validate()
+	assert.EqualError(t, err, "\nErrors:\n • release changelog must have a non-negative limit\n\n")
+}
+
+func TestVersionSourceConfig_validate(t *testing.T) {
+	for _, source := range []string{"", "git", "flag", "env", "file", "github"} {
+		t.Run(source, func(t *testing.T) {
+			cfg := VersionSourceConfig{
+				Type: source,
+			}
+
+			err := cfg.validate()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVersionSourceConfig_validateCommand(t *testing.T) {
+	cfg := VersionSourceConfig{
+		Type:    "command",
+		Command: "make version",
+	}
+
+	err := cfg.validate()
+	assert.NoError(t, err)
+}
+
+func TestVersionSourceConfig_validateErrors(t *testing.T) {
+	tests := []struct {
+		cfg      VersionSourceConfig
+		expected string
+	}{
+		{
+			cfg:      VersionSourceConfig{Type: "invalid"},
+			expected: "invalid release version_source type 'invalid', should be one of: [git flag env file command github]",
+		},
+		{
+			cfg:      VersionSourceConfig{Type: "command"},
+			expected: "release version_source type 'command' requires 'command' to be set",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.cfg.Type, func(t *testing.T) {
+			err := test.cfg.validate()
+			assert.Error(t, err)
+
+			collector, ok := err.(*errs.Collector)
+			assert.True(t, ok, "error is an instance of errs.Collector")
+			assert.Equal(t, 1, collector.Count())
+			assert.EqualError(t, err, "\nErrors:\n • "+test.expected+"\n\n")
+		})
+	}
+}
+
 func TestPublishCommitConfig_validate(t *testing.T) {
 	cfg := PublishCommitConfig{}
 
@@ -416,3 +739,72 @@ Errors:
 
 `)
 }
+
+func TestHookConfig_validate(t *testing.T) {
+	cfg := HookConfig{
+		Before: []string{"helm-docs"},
+		After:  []string{"./notify.sh"},
+	}
+
+	err := cfg.validate("publish")
+	assert.NoError(t, err)
+}
+
+func TestHookConfig_validateNil(t *testing.T) {
+	var cfg *HookConfig
+
+	err := cfg.validate("publish")
+	assert.NoError(t, err)
+}
+
+func TestHookConfig_validateErrors(t *testing.T) {
+	cfg := HookConfig{
+		Before: []string{"helm-docs", ""},
+		After:  []string{"  "},
+	}
+
+	err := cfg.validate("publish")
+	assert.EqualError(t, err, `
+Errors:
+ • empty command at 'hooks.publish.before[1]'
+ • empty command at 'hooks.publish.after[0]'
+
+`)
+}
+
+func TestNotificationConfig_validate(t *testing.T) {
+	cfg := NotificationConfig{
+		Type:  NotifySlack,
+		URL:   `{{ env "SLACK_WEBHOOK_URL" }}`,
+		On:    []string{NotifyOnSuccess},
+		Drift: []string{"major", "minor"},
+	}
+
+	err := cfg.validate(0)
+	assert.NoError(t, err)
+}
+
+func TestNotificationConfig_validateNil(t *testing.T) {
+	var cfg *NotificationConfig
+
+	err := cfg.validate(2)
+	assert.EqualError(t, err, "empty notification config at 'notifications[2]'")
+}
+
+func TestNotificationConfig_validateErrors(t *testing.T) {
+	cfg := NotificationConfig{
+		Type:  "email",
+		On:    []string{"always"},
+		Drift: []string{"minor", "huge"},
+	}
+
+	err := cfg.validate(1)
+	assert.EqualError(t, err, `
+Errors:
+ • invalid notifications[1].type 'email', should be one of: [slack teams webhook]
+ • required option 'notifications[1].url' missing from config
+ • invalid notifications[1].on 'always', should be one of: [success failure]
+ • invalid notifications[1].drift 'huge', should be one of: [major minor patch prerelease]
+
+`)
+}
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/edaniszewski/chart-releaser/pkg/errs"
+)
+
+// TemplateFilePrefix is the prefix for template values which reference a file
+// containing the template, e.g. "file:templates/pr-body.md".
+const TemplateFilePrefix = "file:"
+
+// Errors for loading template files.
+var (
+	ErrTemplateNoDir      = errors.New("config was not loaded from a file, so template files can not be used")
+	ErrTemplateAbsPath    = errors.New("template file paths must be relative to the config directory")
+	ErrTemplateOutsideDir = errors.New("template file path is outside of the config directory")
+)
+
+// LoadTemplateFiles loads the contents of the template files referenced by the
+// Config. Paths are resolved relative to the given directory, which should be the
+// directory containing the config file, and may not point outside of it.
+//
+// Template fields with a "file:" reference are replaced with the contents of the
+// file. Those files, along with the files listed in "includes", are kept with the
+// Config so that named templates they define ({{ define "name" }}) can be used by
+// any template in the Config.
+func (c *Config) LoadTemplateFiles(dir string) error {
+	collector := errs.NewCollector()
+	c.templateFiles = nil
+
+	for _, ref := range c.templateRefs() {
+		if !strings.HasPrefix(*ref.value, TemplateFilePrefix) {
+			continue
+		}
+		path := strings.TrimSpace(strings.TrimPrefix(*ref.value, TemplateFilePrefix))
+		contents, err := c.loadTemplateFile(dir, path)
+		if err != nil {
+			collector.Add(fmt.Errorf("unable to load template file for '%s': %v", ref.location, err))
+			continue
+		}
+		*ref.value = contents
+	}
+
+	for i, path := range c.Includes {
+		if _, err := c.loadTemplateFile(dir, path); err != nil {
+			collector.Add(fmt.Errorf("unable to load template file for 'includes[%d]': %v", i, err))
+		}
+	}
+
+	if collector.HasErrors() {
+		return collector
+	}
+	return nil
+}
+
+// loadTemplateFile reads a template file and adds it to the Config's template
+// files, returning its contents.
+func (c *Config) loadTemplateFile(dir, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("no file path specified")
+	}
+	if dir == "" {
+		return "", ErrTemplateNoDir
+	}
+	if filepath.IsAbs(path) {
+		return "", ErrTemplateAbsPath
+	}
+	path = filepath.Join(dir, path)
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrTemplateOutsideDir
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	c.templateFiles = append(c.templateFiles, Field{Location: path, Value: string(contents)})
+	return string(contents), nil
+}
+
+// NewTemplate creates a new template with the given name which has the named
+// templates defined in the Config's template files available to it. It is safe
+// to call on a nil Config.
+func (c *Config) NewTemplate(name string) (*template.Template, error) {
+	t := c.newTemplate(name)
+	if c == nil {
+		return t, nil
+	}
+
+	for _, f := range c.templateFiles {
+		if _, err := t.New(f.Location).Parse(f.Value); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
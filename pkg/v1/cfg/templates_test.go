@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, dir, name, contents string) {
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+}
+
+func TestConfig_LoadTemplateFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "body.md", `{{ define "footer" }}-- {{ .Name }}{{ end }}Body for {{ .Name }}
+{{ template "footer" . }}`)
+	writeFile(t, dir, "partials.tmpl", `{{ define "title" }}Release {{ .Name }}{{ end }}`)
+
+	cfg := Config{
+		Version: "v1",
+		Chart: &ChartConfig{
+			Name: "test-chart",
+			Repo: "github.com/test/test-chart",
+		},
+		Publish: &PublishConfig{
+			PR: &PublishPRConfig{
+				TitleTemplate: `{{ template "title" . }}`,
+				BodyTemplate:  "file: body.md",
+			},
+		},
+		Includes: []string{"partials.tmpl"},
+	}
+
+	err := cfg.LoadTemplateFiles(dir)
+	assert.NoError(t, err)
+	assert.Contains(t, cfg.Publish.PR.BodyTemplate, "Body for {{ .Name }}")
+	assert.NoError(t, cfg.Validate())
+
+	data := map[string]string{"Name": "test"}
+	for _, test := range []struct {
+		tmpl     string
+		expected string
+	}{
+		{tmpl: cfg.Publish.PR.TitleTemplate, expected: "Release test"},
+		{tmpl: cfg.Publish.PR.BodyTemplate, expected: "Body for test\n-- test"},
+	} {
+		tmpl, err := cfg.NewTemplate("test")
+		assert.NoError(t, err)
+		tmpl, err = tmpl.Parse(test.tmpl)
+		assert.NoError(t, err)
+
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Execute(&buf, data))
+		assert.Equal(t, test.expected, buf.String())
+	}
+}
+
+func TestConfig_LoadTemplateFiles_NoFiles(t *testing.T) {
+	cfg := Config{
+		Commit: &CommitConfig{
+			Templates: &CommitTemplateConfig{
+				Update: "update {{ .Chart.Name }}",
+			},
+		},
+	}
+
+	err := cfg.LoadTemplateFiles(t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, "update {{ .Chart.Name }}", cfg.Commit.Templates.Update)
+	assert.Empty(t, cfg.templateFiles)
+}
+
+func TestConfig_LoadTemplateFiles_Error(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg := Config{
+		Commit: &CommitConfig{
+			Templates: &CommitTemplateConfig{
+				Update: "file:missing.txt",
+				Extras: "file:",
+			},
+		},
+		Includes: []string{"missing.tmpl"},
+	}
+
+	err := cfg.LoadTemplateFiles(dir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to load template file for 'commit.templates.update': open "+filepath.Join(dir, "missing.txt"))
+	assert.Contains(t, err.Error(), "unable to load template file for 'commit.templates.extras': no file path specified")
+	assert.Contains(t, err.Error(), "unable to load template file for 'includes[0]': open "+filepath.Join(dir, "missing.tmpl"))
+}
+
+func TestConfig_LoadTemplateFiles_OutsideDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "config")
+	assert.NoError(t, os.Mkdir(dir, 0755))
+	writeFile(t, root, "secret.txt", "secret")
+
+	cfg := Config{
+		Commit: &CommitConfig{
+			Templates: &CommitTemplateConfig{
+				Update: "file:../secret.txt",
+				Extras: "file:" + filepath.Join(root, "secret.txt"),
+			},
+		},
+		Includes: []string{"nested/../../secret.txt"},
+	}
+
+	err := cfg.LoadTemplateFiles(dir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to load template file for 'commit.templates.update': "+ErrTemplateOutsideDir.Error())
+	assert.Contains(t, err.Error(), "unable to load template file for 'commit.templates.extras': "+ErrTemplateAbsPath.Error())
+	assert.Contains(t, err.Error(), "unable to load template file for 'includes[0]': "+ErrTemplateOutsideDir.Error())
+	assert.Empty(t, cfg.templateFiles)
+}
+
+func TestConfig_LoadTemplateFiles_NoDir(t *testing.T) {
+	cfg := Config{
+		Commit: &CommitConfig{
+			Templates: &CommitTemplateConfig{
+				Update: "file:update.txt",
+			},
+		},
+	}
+
+	err := cfg.LoadTemplateFiles("")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to load template file for 'commit.templates.update': "+ErrTemplateNoDir.Error())
+}
+
+func TestConfig_Validate_IncludeParseError(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "partials.tmpl", `{{ define "title" }}{{ .Name }`)
+
+	cfg := Config{
+		Version: "v1",
+		Chart: &ChartConfig{
+			Name: "test-chart",
+			Repo: "github.com/test/test-chart",
+		},
+		Includes: []string{"partials.tmpl"},
+	}
+
+	assert.NoError(t, cfg.LoadTemplateFiles(dir))
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid template in includes: template: "+filepath.Join(dir, "partials.tmpl"))
+}
+
+func TestConfig_NewTemplate_Nil(t *testing.T) {
+	var cfg *Config
+
+	tmpl, err := cfg.NewTemplate("test")
+	assert.NoError(t, err)
+	assert.Equal(t, "test", tmpl.Name())
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/edaniszewski/chart-releaser/pkg/errs"
+	"github.com/edaniszewski/chart-releaser/pkg/templates"
+)
+
+// Features of a Config which run commands or read from the host running
+// chart-releaser. A Config which is not trusted may only use the features it
+// is allowed.
+const (
+	FeatureHooks = "hooks"
+	FeatureFiles = "files"
+	FeatureEnv   = "env"
+)
+
+// Features holds all of the features which may be allowed for a Config.
+var Features = []string{FeatureHooks, FeatureFiles, FeatureEnv}
+
+// LoadUntrusted attempts to load raw bytes into a Config struct for a config
+// from a source which is not trusted, e.g. one fetched from a repository. The
+// features the Config uses are checked against the allowed features before
+// any template files are loaded, and unless FeatureEnv is allowed, the Config
+// is restricted (see Restrict).
+func LoadUntrusted(b []byte, dir string, allow []string) (*Config, error) {
+	c, err := LoadFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.CheckFeatures(allow); err != nil {
+		return nil, err
+	}
+	if !containsString(allow, FeatureEnv) {
+		c.Restrict()
+	}
+	if err := c.LoadTemplateFiles(dir); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CheckFeatures checks that the Config only uses the allowed features. Hooks
+// require FeatureHooks, and template file references and includes require
+// FeatureFiles. Environment variables are not checked, since templates read
+// them at runtime; a restricted Config can not read them instead.
+func (c *Config) CheckFeatures(allow []string) error {
+	collector := errs.NewCollector()
+
+	if len(c.Hooks) != 0 && !containsString(allow, FeatureHooks) {
+		collector.Add(fmt.Errorf("hooks are not allowed for this config"))
+	}
+	if !containsString(allow, FeatureFiles) {
+		for _, ref := range c.templateRefs() {
+			if strings.HasPrefix(*ref.value, TemplateFilePrefix) {
+				collector.Add(fmt.Errorf("template file for '%s' is not allowed for this config", ref.location))
+			}
+		}
+		if len(c.Includes) != 0 {
+			collector.Add(fmt.Errorf("includes are not allowed for this config"))
+		}
+	}
+
+	if collector.HasErrors() {
+		return collector
+	}
+	return nil
+}
+
+// Restrict the Config, since it comes from a source which is not trusted.
+// Templates created for a restricted Config can not read environment
+// variables.
+func (c *Config) Restrict() {
+	c.restricted = true
+}
+
+// newTemplate creates a new template with the functions available to the
+// templates of the Config.
+func (c *Config) newTemplate(name string) *template.Template {
+	if c != nil && c.restricted {
+		return templates.NewRestricted(name)
+	}
+	return templates.New(name)
+}
@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_CheckFeatures(t *testing.T) {
+	cfg := Config{
+		Publish: &PublishConfig{
+			PR: &PublishPRConfig{
+				TitleTemplate: "Release {{ .Chart.Name }}",
+				BodyTemplate:  "file:body.md",
+			},
+		},
+		Includes: []string{"partials.tmpl"},
+		Hooks: map[string]*HookConfig{
+			"publish": {Before: []string{"true"}},
+		},
+	}
+
+	err := cfg.CheckFeatures(nil)
+	assert.EqualError(t, err, `
+Errors:
+ • hooks are not allowed for this config
+ • template file for 'publish.pr.body_template' is not allowed for this config
+ • includes are not allowed for this config
+
+`)
+
+	err = cfg.CheckFeatures([]string{FeatureFiles})
+	assert.EqualError(t, err, "\nErrors:\n • hooks are not allowed for this config\n\n")
+
+	assert.NoError(t, cfg.CheckFeatures([]string{FeatureHooks, FeatureFiles}))
+	assert.NoError(t, (&Config{}).CheckFeatures(nil))
+}
+
+func TestLoadUntrusted(t *testing.T) {
+	assert.NoError(t, os.Setenv("CHART_RELEASER_TEST_ENV", "test-value"))
+	defer os.Unsetenv("CHART_RELEASER_TEST_ENV")
+
+	data := []byte(`version: v1
+commit:
+  templates:
+    update: '{{ env "CHART_RELEASER_TEST_ENV" }}'
+`)
+
+	cfg, err := LoadUntrusted(data, "", nil)
+	assert.NoError(t, err)
+	err = cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid template at 'commit.templates.update': template: commit.templates.update:1: function "env" not defined`)
+
+	cfg, err = LoadUntrusted(data, "", []string{FeatureEnv})
+	assert.NoError(t, err)
+	tmpl, err := cfg.NewTemplate("test")
+	assert.NoError(t, err)
+	tmpl, err = tmpl.Parse(cfg.Commit.Templates.Update)
+	assert.NoError(t, err)
+	buf := bytes.Buffer{}
+	assert.NoError(t, tmpl.Execute(&buf, nil))
+	assert.Equal(t, "test-value", buf.String())
+}
+
+func TestLoadUntrusted_NotAllowed(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "update.txt", "update")
+
+	cfg, err := LoadUntrusted([]byte("version: v1\ncommit:\n  templates:\n    update: file:update.txt\n"), dir, nil)
+	assert.EqualError(t, err, "\nErrors:\n • template file for 'commit.templates.update' is not allowed for this config\n\n")
+	assert.Nil(t, cfg)
+
+	cfg, err = LoadUntrusted([]byte("version: v1\ncommit:\n  templates:\n    update: file:update.txt\n"), dir, []string{FeatureFiles})
+	assert.NoError(t, err)
+	assert.Equal(t, "update", cfg.Commit.Templates.Update)
+}
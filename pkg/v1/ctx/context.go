@@ -12,6 +12,7 @@ import (
 
 	"github.com/apex/log"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/edaniszewski/chart-releaser/pkg/ci"
 	"github.com/edaniszewski/chart-releaser/pkg/client"
 	"github.com/edaniszewski/chart-releaser/pkg/errs"
 	version "github.com/edaniszewski/chart-releaser/pkg/semver"
@@ -50,6 +51,21 @@ func RepoTypeFromString(s string) (RepoType, error) {
 type App struct {
 	NewVersion      version.Semver
 	PreviousVersion version.Semver
+
+	// PreviousTag is the highest tag in the git history with a version lower
+	// than the new version, if one could be found.
+	PreviousTag string
+
+	// ChartAppVersion is the appVersion defined in the Chart prior to update.
+	// This may differ from the PreviousVersion if the previous version is
+	// determined from the git history.
+	ChartAppVersion version.Semver
+
+	// Information from the published release of the application in its
+	// source repository, if release notes lookup is enabled.
+	ReleaseNotes string
+	ReleaseURL   string
+	ReleaseDate  time.Time
 }
 
 // Author information for the committer.
@@ -73,6 +89,12 @@ type File struct {
 	Path             string
 	PreviousContents []byte
 	NewContents      []byte
+
+	// OriginalContents holds the contents of the file exactly as read from the
+	// repository, if they differ from PreviousContents (e.g. because the file
+	// was normalized when loaded). Since NewContents replace the whole file,
+	// diffs against the original contents are what can be applied to the repo.
+	OriginalContents []byte
 }
 
 // HasChanges determines whether the previous contents of the file differ from the
@@ -81,11 +103,39 @@ func (f *File) HasChanges() bool {
 	return !bytes.Equal(f.PreviousContents, f.NewContents)
 }
 
+// DiffBase gets the contents of the file which the new contents should be
+// diffed against to get the changes made to the file in the repository.
+func (f *File) DiffBase() []byte {
+	if f.OriginalContents != nil {
+		return f.OriginalContents
+	}
+	return f.PreviousContents
+}
+
 // Git information used for publishing chart updates.
 type Git struct {
-	Tag  string
-	Ref  string
-	Base string
+	Tag       string
+	TagSource string
+	Ref       string
+	Base      string
+
+	// Commits made to the application between the previous release tag
+	// and the new release, most recent first. The number of commits is
+	// capped; OmittedCommits holds the number of commits left out.
+	Commits        []Commit
+	OmittedCommits int
+}
+
+// Commit holds information about a commit made to the application.
+type Commit struct {
+	SHA      string
+	ShortSHA string
+	Author   string
+	Subject  string
+
+	// PR is the number of the pull request the commit was merged from,
+	// if it could be determined from the commit subject.
+	PR int
 }
 
 // Repository metadata.
@@ -103,6 +153,107 @@ type Release struct {
 	ExtrasCommitMsg string
 	Matches         []*regexp.Regexp
 	Ignores         []*regexp.Regexp
+	TagPattern      *regexp.Regexp
+	TagNormalize    bool
+	VersionSource   strategies.VersionSource
+	PreviousSource  strategies.PreviousSource
+}
+
+// Publish holds the results of publishing the chart update.
+type Publish struct {
+	// Commits made to the chart repository, in the order they were made.
+	Commits []PublishedCommit
+
+	// PullRequest opened for the update, if using the pull request strategy.
+	PullRequest *client.PullRequest
+
+	// SkipReason describes why the update was not published, e.g. if the
+	// release tag does not meet the configured release constraints.
+	SkipReason string
+
+	// Journal records each change made to the chart repository while
+	// publishing, in the order they were made, so that they can be rolled
+	// back if publishing fails.
+	Journal []Mutation
+}
+
+// The kinds of change made to the chart repository while publishing.
+const (
+	MutationCreateRef         = "create-ref"
+	MutationUpdateFile        = "update-file"
+	MutationCreatePullRequest = "create-pull-request"
+)
+
+// Mutation records a change made to the chart repository while publishing.
+type Mutation struct {
+	Kind string
+
+	// Ref is the branch the change was made to.
+	Ref string
+
+	// Path and SHA are the file updated and the commit made for an
+	// update-file change.
+	Path string
+	SHA  string
+
+	// PullRequest is the pull request opened by a create-pull-request change.
+	PullRequest *client.PullRequest
+
+	// RolledBack is set if the change was rolled back. Otherwise,
+	// RollbackError describes why it was not, if a rollback was attempted.
+	RolledBack    bool
+	RollbackError string
+}
+
+// String describes the change.
+func (m Mutation) String() string {
+	switch m.Kind {
+	case MutationCreateRef:
+		return fmt.Sprintf("created branch %s", m.Ref)
+	case MutationUpdateFile:
+		return fmt.Sprintf("updated %s on branch %s (commit %s)", m.Path, m.Ref, m.SHA)
+	case MutationCreatePullRequest:
+		if m.PullRequest == nil {
+			return fmt.Sprintf("opened pull request from branch %s", m.Ref)
+		}
+		return fmt.Sprintf("opened pull request #%d (%s)", m.PullRequest.Number, m.PullRequest.URL)
+	default:
+		return m.Kind
+	}
+}
+
+// PublishedCommit holds information about a commit made to the chart repository.
+type PublishedCommit struct {
+	Path    string
+	SHA     string
+	Message string
+}
+
+// Result holds the outcome of an update. It is set when notifications are
+// sent, so notification templates can describe the outcome.
+type Result struct {
+	Success bool
+	Error   string
+
+	// Drift is the level of drift between the previous and new application
+	// versions, e.g. "minor".
+	Drift string
+}
+
+// Actions holds information about the GitHub Actions workflow run, when
+// running in GitHub Actions.
+type Actions struct {
+	Enabled bool
+
+	// EventName is the name of the event which triggered the workflow.
+	EventName string
+}
+
+// StageRun records the run of a stage in the update pipeline.
+type StageRun struct {
+	Name     string
+	Duration time.Duration
+	Err      error
 }
 
 // Context holds information that is used by chart-releaser throughout
@@ -112,8 +263,10 @@ type Context struct {
 	context.Context
 	Config *v1.Config
 	Out    io.Writer
+	In     io.Reader
 
 	Token           string
+	APIURL          string
 	PublishStrategy strategies.PublishStrategy
 	UpdateStrategy  strategies.UpdateStrategy
 
@@ -125,6 +278,20 @@ type Context struct {
 	Git        Git
 	Repository Repository
 	Release    Release
+	Publish    Publish
+	Result     Result
+	Actions    Actions
+
+	// CI describes the CI job chart-releaser is running in, if any.
+	CI ci.Environment
+
+	// Stages holds the stages of the update pipeline which have been run, in
+	// the order they were run.
+	Stages []StageRun
+
+	// SourceRepository is the repository for the application being released.
+	// It may differ from the Repository holding the Helm Chart.
+	SourceRepository Repository
 
 	// CurrentFile holds a reference to a extras file that is currently being
 	// worked on when publishing changes. This allows template rendering to
@@ -132,53 +299,167 @@ type Context struct {
 	CurrentFile File
 
 	AllowDirty bool
+	AppVersion string
 	DryRun     bool
 	ShowDiff   bool
 
+	// NoColor disables colors in the output written to Out, e.g. so that
+	// it is not mixed with structured logs.
+	NoColor bool
+
+	// NoRollback disables rolling back the changes made to the chart
+	// repository if publishing fails.
+	NoRollback bool
+
+	// DryRunHooks runs hooks in dry-run mode. Hooks may have side effects,
+	// so they are skipped in dry-run mode otherwise.
+	DryRunHooks bool
+
+	// NoGitHistory is set if there is no local checkout of the source
+	// repository to read the release history from, e.g. when updating from a
+	// webhook. The previous release tag and its commits are then not loaded.
+	NoGitHistory bool
+
+	// Retry configures how failed requests to the repository are retried.
+	Retry client.RetryOptions
+
+	// DiffFormat is the format in which the diff stage shows changes.
+	DiffFormat string
+	// DiffOutput is the path of a file the diff stage writes changes to, in
+	// addition to showing them if ShowDiff is set.
+	DiffOutput string
+	// DiffContext is the number of unchanged lines shown around each change.
+	DiffContext int
+
+	// Confirm requires the changes to be confirmed before they are published.
+	// The confirmation is read from In when Interactive; otherwise, changes are
+	// only published if AssumeYes is set.
+	Confirm     bool
+	AssumeYes   bool
+	Interactive bool
+
+	// LocalChartRepo is the path to a local checkout of the chart repository.
+	// If set, chart files are read from the checkout rather than the remote
+	// repository, so no token or network access is needed.
+	LocalChartRepo string
+
+	// logger is the logger for the stage of the update pipeline being run.
+	logger log.Interface
+
 	errors errs.Collector
 }
 
+// Log gets the logger for the stage of the update pipeline being run. While a
+// stage runs, the logger holds the fields identifying the stage, repository,
+// ref and path, so every line logged by the stage can be attributed to it.
+// Otherwise, the package-level logger is used.
+func (ctx *Context) Log() log.Interface {
+	if ctx.logger == nil {
+		return log.Log
+	}
+	return ctx.logger
+}
+
+// SetLog sets the logger used by Log. If nil, the package-level logger is
+// used.
+func (ctx *Context) SetLog(logger log.Interface) {
+	ctx.logger = logger
+}
+
 // Dump the Context to console.
 //nolint:gosimple
 func (ctx *Context) Dump() {
 	if ctx.Out == nil {
-		log.Error("unable to dump context: context output writer is nil")
+		ctx.Log().Error("unable to dump context: context output writer is nil")
 		return
 	}
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("\n=== Context ==="))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("\n=== Context ==="))
 	_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("AllowDirty:\t\t%v", ctx.AllowDirty))
 	_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("DryRun:\t\t\t%v", ctx.DryRun))
 	_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("ShowDiff:\t\t%v", ctx.ShowDiff))
+	_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("DiffFormat:\t\t%s", ctx.DiffFormat))
 	_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("PublishStrategy:\t%s", ctx.PublishStrategy))
 	_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("UpdateStrategy:\t\t%s", ctx.UpdateStrategy))
-	_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("Token:\t\t\t%s****", ctx.Token[0:4]))
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("Config"))
+	if len(ctx.Token) >= 4 {
+		_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("Token:\t\t\t%s****", ctx.Token[0:4]))
+	} else {
+		_, _ = fmt.Fprintln(ctx.Out, "Token:\t\t\t<not set>")
+	}
+	if ctx.LocalChartRepo != "" {
+		_, _ = fmt.Fprintln(ctx.Out, fmt.Sprintf("LocalChartRepo:\t\t%s", ctx.LocalChartRepo))
+	}
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("Config"))
 	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.Config))
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("App"))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("App"))
 	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.App))
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("Author"))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("Author"))
 	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.Author))
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("Chart"))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("Chart"))
 	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.Chart))
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("Files"))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("Files"))
 	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.Files))
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("Git"))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("Git"))
 	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.Git))
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("Repository"))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("Repository"))
 	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.Repository))
-	_, _ = fmt.Fprintln(ctx.Out, color.New(color.Bold).Sprint("Release"))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("Release"))
 	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.Release))
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("CI"))
+	_, _ = fmt.Fprintln(ctx.Out, spew.Sdump(ctx.CI))
 }
 
 // PrintErrors prints any errors encountered and collected by the Context's error
 // collector.
 func (ctx *Context) PrintErrors() {
 	if ctx.errors.HasErrors() {
-		_, _ = fmt.Fprint(ctx.Out, ansi.Color("\ndry-run completed with errors", "red"))
+		_, _ = fmt.Fprint(ctx.Out, ctx.color("\ndry-run completed with errors", "red"))
 		_, _ = fmt.Fprint(ctx.Out, ctx.errors.Error())
 	} else {
-		_, _ = fmt.Fprint(ctx.Out, ansi.Color("dry-run completed without errors\n", "green"))
+		_, _ = fmt.Fprint(ctx.Out, ctx.color("dry-run completed without errors\n", "green"))
+	}
+}
+
+// PrintJournal prints the changes made to the chart repository while
+// publishing, and whether each was rolled back, so that any changes left
+// behind by a failed update are known.
+func (ctx *Context) PrintJournal() {
+	if ctx.Out == nil || len(ctx.Publish.Journal) == 0 {
+		return
 	}
+	_, _ = fmt.Fprintln(ctx.Out, ctx.bold("\n=== Publish Journal ==="))
+	for _, m := range ctx.Publish.Journal {
+		var status string
+		switch {
+		case m.RolledBack:
+			status = ctx.color("rolled back", "green")
+		case m.RollbackError != "":
+			status = ctx.color("left behind: "+m.RollbackError, "red")
+		default:
+			status = ctx.color("left behind", "yellow")
+		}
+		_, _ = fmt.Fprintf(ctx.Out, " • %s [%s]\n", m, status)
+	}
+}
+
+// bold formats the text as bold, unless colors are disabled.
+func (ctx *Context) bold(s string) string {
+	if ctx.NoColor {
+		return s
+	}
+	return color.New(color.Bold).Sprint(s)
+}
+
+// color formats the text with the ansi style, unless colors are disabled.
+func (ctx *Context) color(s, style string) string {
+	if ctx.NoColor {
+		return s
+	}
+	return ansi.Color(s, style)
+}
+
+// ErrorList returns the individual errors collected by the Context.
+func (ctx *Context) ErrorList() []error {
+	return ctx.errors.Errors()
 }
 
 // Errors returns the errors collected by the Context.
@@ -194,7 +475,7 @@ func (ctx *Context) Errors() error {
 // the same error it was given for the caller to propagate appropriately.
 func (ctx *Context) CheckDryRun(err error) error {
 	if ctx.DryRun {
-		log.WithError(err).Warn("dry-run: ignoring error")
+		ctx.Log().WithError(err).Warn("dry-run: ignoring error")
 		ctx.errors.Add(err)
 		return nil
 	}
@@ -219,6 +500,7 @@ func Wrap(ctx context.Context, config *v1.Config) *Context {
 		Context: ctx,
 		Config:  config,
 		Out:     os.Stdout,
+		In:      os.Stdin,
 		errors:  *errs.NewCollector(),
 	}
 }
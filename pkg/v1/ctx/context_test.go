@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/discard"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
 	"github.com/edaniszewski/chart-releaser/pkg/errs"
 	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
 	"github.com/pkg/errors"
@@ -56,6 +59,16 @@ func TestFile_HasChanges(t *testing.T) {
 	assert.True(t, file.HasChanges())
 }
 
+func TestFile_DiffBase(t *testing.T) {
+	file := File{
+		PreviousContents: []byte{0x01, 0x02, 0x03},
+	}
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, file.DiffBase())
+
+	file.OriginalContents = []byte{0x03, 0x02, 0x01}
+	assert.Equal(t, []byte{0x03, 0x02, 0x01}, file.DiffBase())
+}
+
 func TestNew(t *testing.T) {
 	c := v1.Config{}
 
@@ -104,6 +117,48 @@ func TestContext_PrintErrors_HasErrors(t *testing.T) {
 	assert.Equal(t, "\x1b[0;31m\ndry-run completed with errors\x1b[0m\nErrors:\n • test error\n\n", buf.String())
 }
 
+func TestContext_PrintErrors_NoColor(t *testing.T) {
+	buf := bytes.Buffer{}
+	ctx := Context{
+		Out:     &buf,
+		NoColor: true,
+		errors:  errs.Collector{},
+	}
+	ctx.errors.Add(errors.New("test error"))
+
+	ctx.PrintErrors()
+	assert.Equal(t, "\ndry-run completed with errors\nErrors:\n • test error\n\n", buf.String())
+}
+
+func TestContext_PrintJournal(t *testing.T) {
+	buf := bytes.Buffer{}
+	ctx := Context{
+		Out: &buf,
+		Publish: Publish{
+			Journal: []Mutation{
+				{Kind: MutationCreateRef, Ref: "testbranch", RolledBack: true},
+				{Kind: MutationUpdateFile, Ref: "testbranch", Path: "Chart.yaml", SHA: "abc123", RollbackError: "test error"},
+				{Kind: MutationCreatePullRequest, Ref: "testbranch", PullRequest: &client.PullRequest{Number: 7, URL: "https://github.com/org/repo/pull/7"}},
+			},
+		},
+	}
+
+	ctx.PrintJournal()
+	assert.Equal(t, "\n=== Publish Journal ===\n"+
+		" • created branch testbranch [\x1b[0;32mrolled back\x1b[0m]\n"+
+		" • updated Chart.yaml on branch testbranch (commit abc123) [\x1b[0;31mleft behind: test error\x1b[0m]\n"+
+		" • opened pull request #7 (https://github.com/org/repo/pull/7) [\x1b[0;33mleft behind\x1b[0m]\n",
+		buf.String())
+}
+
+func TestContext_PrintJournal_Empty(t *testing.T) {
+	buf := bytes.Buffer{}
+	ctx := Context{Out: &buf}
+
+	ctx.PrintJournal()
+	assert.Empty(t, buf.String())
+}
+
 func TestContext_CheckDryRun(t *testing.T) {
 	ctx := Context{
 		DryRun: true,
@@ -142,3 +197,15 @@ func TestContext_NoErrors(t *testing.T) {
 
 	assert.Nil(t, ctx.Errors())
 }
+
+func TestContext_Log(t *testing.T) {
+	ctx := Context{}
+	assert.Equal(t, log.Log, ctx.Log())
+
+	logger := &log.Logger{Handler: discard.New()}
+	ctx.SetLog(logger)
+	assert.Equal(t, logger, ctx.Log())
+
+	ctx.SetLog(nil)
+	assert.Equal(t, log.Log, ctx.Log())
+}
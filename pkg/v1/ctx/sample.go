@@ -0,0 +1,110 @@
+package ctx
+
+import (
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/edaniszewski/chart-releaser/pkg/ci"
+	version "github.com/edaniszewski/chart-releaser/pkg/semver"
+	"github.com/edaniszewski/chart-releaser/pkg/strategies"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+)
+
+// NewSample creates a new v1 Context for the given v1 Config with all fields
+// populated with sample values. It does not reflect any real release; it is
+// used to check that templates execute without needing to run the update
+// pipeline.
+func NewSample(config *v1.Config) *Context {
+	ctx := Wrap(context.Background(), config)
+	ctx.Out = ioutil.Discard
+
+	ctx.PublishStrategy = strategies.PublishPullRequest
+	ctx.UpdateStrategy = strategies.UpdateDefault
+
+	ctx.App = App{
+		NewVersion:      mustVersion("v1.3.0"),
+		PreviousVersion: mustVersion("v1.2.0"),
+		PreviousTag:     "v1.2.0",
+		ChartAppVersion: mustVersion("v1.2.0"),
+		ReleaseNotes:    "* sample release notes",
+		ReleaseURL:      "https://github.com/example/app/releases/tag/v1.3.0",
+		ReleaseDate:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	ctx.Author = Author{
+		Name:  "sample",
+		Email: "sample@example.com",
+	}
+	ctx.Chart = Chart{
+		Name:    "sample",
+		SubPath: "Chart.yaml",
+		File: File{
+			Path:             "Chart.yaml",
+			PreviousContents: []byte("version: 0.1.0\nappVersion: v1.2.0\n"),
+			NewContents:      []byte("version: 0.2.0\nappVersion: v1.3.0\n"),
+		},
+		NewVersion:      mustVersion("0.2.0"),
+		PreviousVersion: mustVersion("0.1.0"),
+	}
+	ctx.CurrentFile = File{
+		Path:             "README.md",
+		PreviousContents: []byte("sample v1.2.0"),
+		NewContents:      []byte("sample v1.3.0"),
+	}
+	ctx.Files = []File{ctx.CurrentFile}
+	ctx.Git = Git{
+		Tag:       "v1.3.0",
+		TagSource: "sample",
+		Ref:       "chartreleaser/sample/0.2.0",
+		Base:      "master",
+		Commits: []Commit{
+			{
+				SHA:      "0123456789abcdef0123456789abcdef01234567",
+				ShortSHA: "0123456",
+				Author:   "sample",
+				Subject:  "Sample change (#1)",
+				PR:       1,
+			},
+		},
+		OmittedCommits: 1,
+	}
+	ctx.Repository = Repository{
+		Type:  RepoGithub,
+		Owner: "example",
+		Name:  "charts",
+	}
+	ctx.CI = ci.Environment{
+		Provider: ci.GitLab,
+		Tag:      "v1.3.0",
+		SHA:      "0123456789abcdef0123456789abcdef01234567",
+		Branch:   "master",
+		JobID:    "1",
+		JobURL:   "https://gitlab.example.com/example/app/-/jobs/1",
+	}
+	ctx.SourceRepository = Repository{
+		Type:  RepoGithub,
+		Owner: "example",
+		Name:  "app",
+	}
+	ctx.Release = Release{
+		PRTitle:         "Sample pull request",
+		PRBody:          "Sample pull request body",
+		ChartCommitMsg:  "Sample chart commit",
+		ExtrasCommitMsg: "Sample extras commit",
+	}
+	ctx.Result = Result{
+		Success: true,
+		Drift:   "minor",
+	}
+	return ctx
+}
+
+// mustVersion loads a semantic version, panicking if it fails. It should only be
+// used with static versions known to be valid.
+func mustVersion(v string) version.Semver {
+	s, err := version.Load(v)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
@@ -0,0 +1,314 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+)
+
+// The phases in which hooks run, relative to the stage they are configured for.
+const (
+	Before = "before"
+	After  = "after"
+)
+
+// Commands gets the hook commands configured for the phase of the named stage.
+func Commands(ctx *context.Context, stage, phase string) []string {
+	if ctx.Config == nil {
+		return nil
+	}
+	hook := ctx.Config.Hooks[stage]
+	if hook == nil {
+		return nil
+	}
+
+	switch phase {
+	case Before:
+		return hook.Before
+	case After:
+		return hook.After
+	default:
+		return nil
+	}
+}
+
+// Run the hooks configured for the phase of the named stage.
+//
+// The chart file and any extra files are written to a temporary directory,
+// which hooks are run from. Any changes hooks make to the files in the
+// directory are folded back into the Context so they are published. Changes
+// made by hooks which run once the changes have been published are dropped
+// with a warning, and changes made once the diff has been shown are rejected,
+// since they would be published without being reviewed.
+//
+// Hooks may have side effects, so they are not run in dry-run mode unless
+// the Context enables DryRunHooks.
+func Run(ctx *context.Context, stage, phase string) error {
+	commands := Commands(ctx, stage, phase)
+	if len(commands) == 0 {
+		return nil
+	}
+	if ctx.DryRun && !ctx.DryRunHooks {
+		ctx.Log().WithFields(log.Fields{
+			"stage": stage,
+			"phase": phase,
+		}).Info("dry-run: skipping hooks")
+		return nil
+	}
+
+	dir, err := ioutil.TempDir("", "chart-releaser-hooks-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			ctx.Log().WithError(err).WithField("dir", dir).Warn("failed to remove hook directory")
+		}
+	}()
+
+	files, err := materialize(ctx, dir)
+	if err != nil {
+		return err
+	}
+	env := append(os.Environ(), Env(ctx, stage, phase, dir)...)
+
+	for _, command := range commands {
+		ctx.Log().WithFields(log.Fields{
+			"stage":   stage,
+			"phase":   phase,
+			"command": command,
+		}).Info("running hook")
+
+		if err := run(ctx, dir, env, command); err != nil {
+			err = fmt.Errorf("%s hook for stage '%s' failed (%s): %v", phase, stage, command, err)
+			if err := ctx.CheckDryRun(err); err != nil {
+				return err
+			}
+		}
+	}
+	return collect(ctx, dir, files, stage, phase)
+}
+
+// published checks whether the changes have already been published when the
+// hooks for the phase of the named stage run.
+func published(stage, phase string) bool {
+	return (stage == "publish" && phase == After) || stage == "notify"
+}
+
+// reviewed checks whether the changes have already been shown by the diff
+// stage when the hooks for the phase of the named stage run, e.g. to be
+// confirmed before they are published.
+func reviewed(ctx *context.Context, stage, phase string) bool {
+	if !ctx.ShowDiff && ctx.DiffOutput == "" {
+		return false
+	}
+	if stage == "diff" && phase == After {
+		return true
+	}
+	for _, s := range ctx.Stages {
+		if s.Name == "diff" {
+			return true
+		}
+	}
+	return false
+}
+
+// run a hook command from the given directory.
+func run(ctx *context.Context, dir string, env []string, command string) error {
+	cmd := exec.CommandContext(ctx.Context, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = ctx.Out
+	if cmd.Stdout == nil {
+		cmd.Stdout = ioutil.Discard
+	}
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Env gets the environment variables describing the Context which are set for
+// hooks. Values which are not yet known at the time a hook runs are empty.
+func Env(ctx *context.Context, stage, phase, dir string) []string {
+	vars := map[string]string{
+		"CR_STAGE":      stage,
+		"CR_HOOK_PHASE": phase,
+		"CR_DRY_RUN":    strconv.FormatBool(ctx.DryRun),
+		"CR_WORKDIR":    dir,
+		"CR_CHART_NAME": ctx.Chart.Name,
+		"CR_CHART_PATH": ctx.Chart.File.Path,
+		"CR_TAG":        ctx.Git.Tag,
+		"CR_BRANCH":     ctx.Git.Ref,
+		"CR_BASE":       ctx.Git.Base,
+	}
+	if ctx.Repository.Owner != "" {
+		vars["CR_REPO"] = ctx.Repository.Owner + "/" + ctx.Repository.Name
+	}
+
+	// Versions are only known once the stages which load them have run, so
+	// they are left empty rather than set to 0.0.0.
+	if ctx.Git.Tag != "" {
+		vars["CR_APP_VERSION"] = ctx.App.NewVersion.String()
+	}
+	if ctx.Chart.File.PreviousContents != nil {
+		vars["CR_APP_PREVIOUS_VERSION"] = ctx.App.PreviousVersion.String()
+		vars["CR_CHART_PREVIOUS_VERSION"] = ctx.Chart.PreviousVersion.String()
+		vars["CR_CHART_VERSION"] = ctx.Chart.NewVersion.String()
+	}
+	if pr := ctx.Publish.PullRequest; pr != nil {
+		vars["CR_PR_NUMBER"] = strconv.Itoa(pr.Number)
+		vars["CR_PR_URL"] = pr.URL
+	}
+
+	var changed []string
+	for _, f := range files(ctx) {
+		if f.HasChanges() {
+			changed = append(changed, f.Path)
+		}
+	}
+	vars["CR_CHANGED_FILES"] = strings.Join(changed, " ")
+
+	var env []string
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// files gets the chart file and extra files of the Context.
+func files(ctx *context.Context) []context.File {
+	var f []context.File
+	if ctx.Chart.File.Path != "" {
+		f = append(f, ctx.Chart.File)
+	}
+	return append(f, ctx.Files...)
+}
+
+// contents gets the current contents of a file: its new contents if it has
+// been updated, otherwise its previous contents.
+func contents(f context.File) []byte {
+	if f.NewContents != nil {
+		return f.NewContents
+	}
+	return f.PreviousContents
+}
+
+// localPath gets the path for a repository file within the directory, making
+// sure that it does not escape the directory.
+func localPath(dir, path string) (string, error) {
+	p := filepath.Join(dir, filepath.FromSlash(path))
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file path '%s' is outside of the repository", path)
+	}
+	return p, nil
+}
+
+// materialize writes the files of the Context to the directory, at their
+// paths within the repository. The contents written for each path are returned.
+func materialize(ctx *context.Context, dir string) (map[string][]byte, error) {
+	written := map[string][]byte{}
+	for _, f := range files(ctx) {
+		p, err := localPath(dir, f.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return nil, err
+		}
+		data := contents(f)
+		if err := ioutil.WriteFile(p, data, 0644); err != nil {
+			return nil, err
+		}
+		written[f.Path] = data
+	}
+	return written, nil
+}
+
+// collect files from the directory which were modified or created by hooks
+// and fold them back into the Context. If the changes have already been
+// published, the files are not collected. If they have already been shown by
+// the diff stage, modifying them is an error.
+func collect(ctx *context.Context, dir string, written map[string][]byte, stage, phase string) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		path := filepath.ToSlash(rel)
+
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if prev, ok := written[path]; ok && bytes.Equal(prev, data) {
+			return nil
+		}
+		if published(stage, phase) {
+			ctx.Log().WithField("path", path).Warn("file modified by hook after publishing - changes will not be published")
+			return nil
+		}
+		if reviewed(ctx, stage, phase) {
+			err := fmt.Errorf("file '%s' modified by %s hook for stage '%s' after the diff was shown; hooks which modify files must run before the 'diff' stage", path, phase, stage)
+			if err := ctx.CheckDryRun(err); err != nil {
+				return err
+			}
+			return nil
+		}
+		ctx.Log().WithField("path", path).Info("file modified by hook")
+
+		if path == ctx.Chart.File.Path {
+			ctx.Chart.File.NewContents = data
+			return nil
+		}
+		for i := range ctx.Files {
+			if ctx.Files[i].Path == path {
+				ctx.Files[i].NewContents = data
+				return nil
+			}
+		}
+		return addFile(ctx, path, data)
+	})
+}
+
+// addFile adds a file created by a hook to the Context. Only files which
+// already exist in the repository can be updated, so the previous contents
+// are fetched from the repository.
+func addFile(ctx *context.Context, path string, data []byte) error {
+	if ctx.Client == nil {
+		ctx.Log().WithField("path", path).Warn("repository client not set - ignoring file created by hook")
+		return nil
+	}
+
+	prev, err := ctx.Client.GetFile(ctx.Context, &client.Options{
+		RepoName:  ctx.Repository.Name,
+		RepoOwner: ctx.Repository.Owner,
+	}, path)
+	if err != nil {
+		ctx.Log().WithError(err).WithField("path", path).Warn("file created by hook not found in repository - ignoring")
+		return nil
+	}
+
+	ctx.Files = append(ctx.Files, context.File{
+		Path:             path,
+		PreviousContents: []byte(prev),
+		NewContents:      data,
+	})
+	return nil
+}
@@ -0,0 +1,495 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommands(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"publish": {
+					Before: []string{"echo before"},
+					After:  []string{"echo after"},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"echo before"}, Commands(context, "publish", Before))
+	assert.Equal(t, []string{"echo after"}, Commands(context, "publish", After))
+	assert.Nil(t, Commands(context, "publish", "during"))
+	assert.Nil(t, Commands(context, "diff", Before))
+	assert.Nil(t, Commands(&ctx.Context{}, "publish", Before))
+}
+
+func TestEnv(t *testing.T) {
+	context := &ctx.Context{
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v1.1.0"),
+			PreviousVersion: testutils.NewSemver(t, "v1.0.0"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.1.1\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.1.1"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.1.0",
+			Ref: "chartreleaser/foo/0.1.1",
+		},
+		Repository: ctx.Repository{
+			Owner: "edaniszewski",
+			Name:  "charts",
+		},
+		Publish: ctx.Publish{
+			PullRequest: &client.PullRequest{
+				Number: 3,
+				URL:    "https://github.com/edaniszewski/charts/pull/3",
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"CR_APP_PREVIOUS_VERSION=v1.0.0",
+		"CR_APP_VERSION=v1.1.0",
+		"CR_BASE=",
+		"CR_BRANCH=chartreleaser/foo/0.1.1",
+		"CR_CHANGED_FILES=charts/foo/Chart.yaml",
+		"CR_CHART_NAME=foo",
+		"CR_CHART_PATH=charts/foo/Chart.yaml",
+		"CR_CHART_PREVIOUS_VERSION=0.1.0",
+		"CR_CHART_VERSION=0.1.1",
+		"CR_DRY_RUN=false",
+		"CR_HOOK_PHASE=after",
+		"CR_PR_NUMBER=3",
+		"CR_PR_URL=https://github.com/edaniszewski/charts/pull/3",
+		"CR_REPO=edaniszewski/charts",
+		"CR_STAGE=publish",
+		"CR_TAG=v1.1.0",
+		"CR_WORKDIR=/tmp/hooks",
+	}, Env(context, "publish", After, "/tmp/hooks"))
+}
+
+func TestEnv_Empty(t *testing.T) {
+	assert.Equal(t, []string{
+		"CR_BASE=",
+		"CR_BRANCH=",
+		"CR_CHANGED_FILES=",
+		"CR_CHART_NAME=",
+		"CR_CHART_PATH=",
+		"CR_DRY_RUN=true",
+		"CR_HOOK_PHASE=before",
+		"CR_STAGE=setup",
+		"CR_TAG=",
+		"CR_WORKDIR=/tmp/hooks",
+	}, Env(&ctx.Context{DryRun: true}, "setup", Before, "/tmp/hooks"))
+}
+
+func TestRun_NoHooks(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config:  &v1.Config{},
+	}
+
+	err := Run(context, "publish", Before)
+	assert.NoError(t, err)
+}
+
+func TestRun(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"publish": {
+					Before: []string{
+						`echo "$CR_STAGE $CR_HOOK_PHASE $CR_CHART_VERSION"`,
+						`cat charts/foo/Chart.yaml`,
+					},
+				},
+			},
+		},
+		Out: &bytes.Buffer{},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.1.1\n"),
+			},
+			NewVersion: testutils.NewSemver(t, "0.1.1"),
+		},
+		Files: []ctx.File{
+			{
+				Path:             "charts/foo/README.md",
+				PreviousContents: []byte("# foo 0.1.0\n"),
+				NewContents:      []byte("# foo 0.1.0\n"),
+			},
+		},
+	}
+
+	err := Run(context, "publish", Before)
+	assert.NoError(t, err)
+	assert.Equal(t, "publish before 0.1.1\nversion: 0.1.1\n", context.Out.(*bytes.Buffer).String())
+
+	// No files were modified.
+	assert.Equal(t, "version: 0.1.1\n", string(context.Chart.File.NewContents))
+	assert.Len(t, context.Files, 1)
+	assert.False(t, context.Files[0].HasChanges())
+}
+
+func TestRun_ModifiedFiles(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"publish": {
+					Before: []string{
+						`echo "# foo $CR_CHART_VERSION" > charts/foo/README.md`,
+						`echo "name: foo" >> charts/foo/Chart.yaml`,
+					},
+				},
+			},
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.1.1\n"),
+			},
+			NewVersion: testutils.NewSemver(t, "0.1.1"),
+		},
+		Files: []ctx.File{
+			{
+				Path:             "charts/foo/README.md",
+				PreviousContents: []byte("# foo 0.1.0\n"),
+				NewContents:      []byte("# foo 0.1.0\n"),
+			},
+		},
+	}
+
+	err := Run(context, "publish", Before)
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 0.1.1\nname: foo\n", string(context.Chart.File.NewContents))
+	assert.Len(t, context.Files, 1)
+	assert.Equal(t, "# foo 0.1.1\n", string(context.Files[0].NewContents))
+	assert.True(t, context.Files[0].HasChanges())
+}
+
+func TestRun_ModifiedFilesAfterPublish(t *testing.T) {
+	for _, test := range []struct {
+		stage string
+		phase string
+	}{
+		{stage: "publish", phase: After},
+		{stage: "notify", phase: Before},
+		{stage: "notify", phase: After},
+	} {
+		t.Run(test.stage+"-"+test.phase, func(t *testing.T) {
+			context := &ctx.Context{
+				Context: context.Background(),
+				Config: &v1.Config{
+					Hooks: map[string]*v1.HookConfig{
+						test.stage: {
+							Before: []string{`echo "name: foo" >> charts/foo/Chart.yaml`},
+							After:  []string{`echo "name: foo" >> charts/foo/Chart.yaml`},
+						},
+					},
+				},
+				Chart: ctx.Chart{
+					File: ctx.File{
+						Path:             "charts/foo/Chart.yaml",
+						PreviousContents: []byte("version: 0.1.0\n"),
+						NewContents:      []byte("version: 0.1.1\n"),
+					},
+				},
+			}
+
+			err := Run(context, test.stage, test.phase)
+			assert.NoError(t, err)
+			assert.Equal(t, "version: 0.1.1\n", string(context.Chart.File.NewContents))
+		})
+	}
+}
+
+func TestRun_ModifiedFilesAfterDiff(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		stage      string
+		phase      string
+		showDiff   bool
+		diffOutput string
+		stages     []ctx.StageRun
+		err        string
+	}{
+		{
+			name:     "diff after",
+			stage:    "diff",
+			phase:    After,
+			showDiff: true,
+			err:      "file 'charts/foo/Chart.yaml' modified by after hook for stage 'diff' after the diff was shown; hooks which modify files must run before the 'diff' stage",
+		},
+		{
+			name:     "publish before",
+			stage:    "publish",
+			phase:    Before,
+			showDiff: true,
+			stages:   []ctx.StageRun{{Name: "render"}, {Name: "diff"}, {Name: "confirm"}},
+			err:      "file 'charts/foo/Chart.yaml' modified by before hook for stage 'publish' after the diff was shown; hooks which modify files must run before the 'diff' stage",
+		},
+		{
+			name:       "diff output",
+			stage:      "confirm",
+			phase:      Before,
+			diffOutput: "changes.patch",
+			stages:     []ctx.StageRun{{Name: "diff"}},
+			err:        "file 'charts/foo/Chart.yaml' modified by before hook for stage 'confirm' after the diff was shown; hooks which modify files must run before the 'diff' stage",
+		},
+		{
+			name:     "diff not run",
+			stage:    "publish",
+			phase:    Before,
+			showDiff: true,
+			stages:   []ctx.StageRun{{Name: "render"}},
+		},
+		{
+			name:   "diff not shown",
+			stage:  "publish",
+			phase:  Before,
+			stages: []ctx.StageRun{{Name: "diff"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			context := &ctx.Context{
+				Context: context.Background(),
+				Config: &v1.Config{
+					Hooks: map[string]*v1.HookConfig{
+						test.stage: {
+							Before: []string{`echo "name: foo" >> charts/foo/Chart.yaml`},
+							After:  []string{`echo "name: foo" >> charts/foo/Chart.yaml`},
+						},
+					},
+				},
+				Chart: ctx.Chart{
+					File: ctx.File{
+						Path:             "charts/foo/Chart.yaml",
+						PreviousContents: []byte("version: 0.1.0\n"),
+						NewContents:      []byte("version: 0.1.1\n"),
+					},
+				},
+				ShowDiff:   test.showDiff,
+				DiffOutput: test.diffOutput,
+				Stages:     test.stages,
+			}
+
+			err := Run(context, test.stage, test.phase)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				assert.Equal(t, "version: 0.1.1\n", string(context.Chart.File.NewContents))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "version: 0.1.1\nname: foo\n", string(context.Chart.File.NewContents))
+			}
+		})
+	}
+}
+
+func TestRun_ModifiedFilesAfterDiffDryRun(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"publish": {
+					Before: []string{`echo "name: foo" >> charts/foo/Chart.yaml`},
+				},
+			},
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.1.1\n"),
+			},
+		},
+		ShowDiff:    true,
+		Stages:      []ctx.StageRun{{Name: "diff"}},
+		DryRun:      true,
+		DryRunHooks: true,
+	}
+
+	err := Run(context, "publish", Before)
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 0.1.1\n", string(context.Chart.File.NewContents))
+	assert.Len(t, context.ErrorList(), 1)
+}
+
+func TestRun_CreatedFiles(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"diff": {
+					Before: []string{`echo "new" > charts/foo/NOTES.txt`},
+				},
+			},
+		},
+		Client: &testutils.FakeClient{
+			FileData: "old\n",
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.1.1\n"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "charts/foo/README.md",
+				PreviousContents: []byte("# foo 0.1.0\n"),
+				NewContents:      []byte("# foo 0.1.0\n"),
+			},
+		},
+		Repository: ctx.Repository{
+			Owner: "edaniszewski",
+			Name:  "charts",
+		},
+	}
+
+	err := Run(context, "diff", Before)
+	assert.NoError(t, err)
+	assert.Len(t, context.Files, 2)
+	assert.Equal(t, "charts/foo/NOTES.txt", context.Files[1].Path)
+	assert.Equal(t, "old\n", string(context.Files[1].PreviousContents))
+	assert.Equal(t, "new\n", string(context.Files[1].NewContents))
+}
+
+func TestRun_CreatedFilesNotInRepo(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"diff": {
+					Before: []string{`echo "new" > charts/foo/NOTES.txt`},
+				},
+			},
+		},
+		Client: &testutils.FakeClient{
+			GetFileError: []error{client.ErrFileNotFound},
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.1.1\n"),
+			},
+		},
+		Repository: ctx.Repository{
+			Owner: "edaniszewski",
+			Name:  "charts",
+		},
+	}
+
+	err := Run(context, "diff", Before)
+	assert.NoError(t, err)
+	assert.Empty(t, context.Files)
+}
+
+func TestRun_Error(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"publish": {
+					After: []string{"exit 3", "echo not run"},
+				},
+			},
+		},
+		Out: &bytes.Buffer{},
+	}
+
+	err := Run(context, "publish", After)
+	assert.EqualError(t, err, "after hook for stage 'publish' failed (exit 3): exit status 3")
+	assert.Empty(t, context.Out.(*bytes.Buffer).String())
+}
+
+func TestRun_ErrorDryRun(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"publish": {
+					After: []string{"exit 3", "echo still run"},
+				},
+			},
+		},
+		Out:         &bytes.Buffer{},
+		DryRun:      true,
+		DryRunHooks: true,
+	}
+
+	err := Run(context, "publish", After)
+	assert.NoError(t, err)
+	assert.Equal(t, "still run\n", context.Out.(*bytes.Buffer).String())
+	assert.Len(t, context.ErrorList(), 1)
+}
+
+func TestRun_DryRun(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"publish": {
+					Before: []string{"echo run"},
+				},
+			},
+		},
+		Out:    &bytes.Buffer{},
+		DryRun: true,
+	}
+
+	// Hooks may have side effects, so they are only run in dry-run mode if
+	// enabled.
+	err := Run(context, "publish", Before)
+	assert.NoError(t, err)
+	assert.Empty(t, context.Out.(*bytes.Buffer).String())
+
+	context.DryRunHooks = true
+	err = Run(context, "publish", Before)
+	assert.NoError(t, err)
+	assert.Equal(t, "run\n", context.Out.(*bytes.Buffer).String())
+}
+
+func TestRun_PathOutsideRepo(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Hooks: map[string]*v1.HookConfig{
+				"publish": {
+					Before: []string{"true"},
+				},
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "../outside.txt",
+				PreviousContents: []byte("outside\n"),
+			},
+		},
+	}
+
+	err := Run(context, "publish", Before)
+	assert.EqualError(t, err, "file path '../outside.txt' is outside of the repository")
+}
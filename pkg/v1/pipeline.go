@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/edaniszewski/chart-releaser/pkg/errs"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages"
+)
+
+// PipelineBuilder composes a Pipeline from a set of stages. Stages may be
+// inserted relative to existing stages, and the stages which are run may be
+// limited by name. The composed Pipeline is checked when it is built, so any
+// stage whose required stages are not run before it results in an error.
+type PipelineBuilder struct {
+	stages   Pipeline
+	skip     []string
+	only     []string
+	provided []string
+	cfg      *v1.Config
+	errors   errs.Collector
+}
+
+// NewPipelineBuilder creates a new PipelineBuilder, starting from the given
+// stages. To customize the default update pipeline, use UpdatePipeline.
+func NewPipelineBuilder(stages ...stages.V1Stage) *PipelineBuilder {
+	return &PipelineBuilder{
+		stages: append(Pipeline{}, stages...),
+	}
+}
+
+// Names gets the names of all stages in the builder, in order.
+func (b *PipelineBuilder) Names() []string {
+	var names []string
+	for _, s := range b.stages {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// Append stages to the end of the pipeline.
+func (b *PipelineBuilder) Append(stages ...stages.V1Stage) *PipelineBuilder {
+	b.stages = append(b.stages, stages...)
+	return b
+}
+
+// InsertBefore inserts a stage directly before the named stage.
+func (b *PipelineBuilder) InsertBefore(name string, stage stages.V1Stage) *PipelineBuilder {
+	return b.insert(name, 0, stage)
+}
+
+// InsertAfter inserts a stage directly after the named stage.
+func (b *PipelineBuilder) InsertAfter(name string, stage stages.V1Stage) *PipelineBuilder {
+	return b.insert(name, 1, stage)
+}
+
+func (b *PipelineBuilder) insert(name string, offset int, stage stages.V1Stage) *PipelineBuilder {
+	idx := b.index(name)
+	if idx == -1 {
+		b.errors.Add(fmt.Errorf("unable to insert stage '%s': unknown stage '%s'", stage.Name(), name))
+		return b
+	}
+	idx += offset
+
+	b.stages = append(b.stages, nil)
+	copy(b.stages[idx+1:], b.stages[idx:])
+	b.stages[idx] = stage
+	return b
+}
+
+// Skip the named stages, so they are not included in the built pipeline.
+func (b *PipelineBuilder) Skip(names ...string) *PipelineBuilder {
+	b.skip = append(b.skip, names...)
+	return b
+}
+
+// Only includes the named stages in the built pipeline. Any stages which
+// are also skipped are not included.
+func (b *PipelineBuilder) Only(names ...string) *PipelineBuilder {
+	b.only = append(b.only, names...)
+	return b
+}
+
+// Provided marks the named stages as provided outside of the pipeline, e.g.
+// because their values are set on the Context directly, so stages which
+// require them may be run without them.
+func (b *PipelineBuilder) Provided(names ...string) *PipelineBuilder {
+	b.provided = append(b.provided, names...)
+	return b
+}
+
+// Config sets the configuration the pipeline is run with, so the stages
+// required by a stage may depend on it.
+func (b *PipelineBuilder) Config(cfg *v1.Config) *PipelineBuilder {
+	b.cfg = cfg
+	return b
+}
+
+// Build the Pipeline.
+func (b *PipelineBuilder) Build() (Pipeline, error) {
+	collector := errs.NewCollector()
+	collector.Add(&b.errors)
+
+	seen := map[string]bool{}
+	for _, s := range b.stages {
+		if seen[s.Name()] {
+			collector.Add(fmt.Errorf("duplicate stage '%s' in pipeline", s.Name()))
+		}
+		seen[s.Name()] = true
+	}
+	for _, name := range append(append([]string{}, b.skip...), b.only...) {
+		if !seen[name] {
+			collector.Add(fmt.Errorf("unknown stage '%s', should be one of: %v", name, b.Names()))
+		}
+	}
+	if collector.HasErrors() {
+		return nil, collector
+	}
+
+	var pipeline Pipeline
+	enabled := map[string]bool{}
+	for _, name := range b.provided {
+		enabled[name] = true
+	}
+	for _, s := range b.stages {
+		if contains(b.skip, s.Name()) || (len(b.only) != 0 && !contains(b.only, s.Name())) {
+			continue
+		}
+
+		// Stages are run in order, so any stage which is required must already
+		// be in the pipeline.
+		var requires []string
+		if d, ok := s.(stages.Dependent); ok {
+			requires = append(requires, d.Requires()...)
+		}
+		if d, ok := s.(stages.ConfigDependent); ok && b.cfg != nil {
+			requires = append(requires, d.RequiresFor(b.cfg)...)
+		}
+		for _, req := range requires {
+			if enabled[req] {
+				continue
+			}
+			if seen[req] && b.index(req) < b.index(s.Name()) {
+				collector.Add(fmt.Errorf("stage '%s' requires stage '%s', which is not enabled", s.Name(), req))
+			} else {
+				collector.Add(fmt.Errorf("stage '%s' requires stage '%s' to run before it", s.Name(), req))
+			}
+		}
+		enabled[s.Name()] = true
+		pipeline = append(pipeline, s)
+	}
+	if collector.HasErrors() {
+		return nil, collector
+	}
+	return pipeline, nil
+}
+
+// index gets the index of the named stage, or -1 if there is no such stage.
+func (b *PipelineBuilder) index(name string) int {
+	for i, s := range b.stages {
+		if s.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// contains checks whether the string is in the list.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
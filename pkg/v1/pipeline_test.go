@@ -0,0 +1,206 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/edaniszewski/chart-releaser/pkg/logging"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/git"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStage is a stage used for testing pipeline composition.
+type testStage struct {
+	name     string
+	requires []string
+}
+
+func (s testStage) Name() string             { return s.name }
+func (s testStage) String() string           { return "test stage " + s.name }
+func (s testStage) Requires() []string       { return s.requires }
+func (s testStage) Run(_ *ctx.Context) error { return nil }
+
+// logStage is a stage which logs a message when run.
+type logStage struct {
+	testStage
+}
+
+func (s logStage) Run(ctx *ctx.Context) error {
+	ctx.Log().WithField("path", "charts/foo/README.md").Info("running " + s.name)
+	return nil
+}
+
+func names(p Pipeline) []string {
+	var n []string
+	for _, s := range p {
+		n = append(n, s.Name())
+	}
+	return n
+}
+
+func TestPipelineBuilder_Default(t *testing.T) {
+	p, err := NewPipelineBuilder(UpdatePipeline...).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, names(UpdatePipeline), names(p))
+}
+
+func TestPipelineBuilder_Skip(t *testing.T) {
+	p, err := NewPipelineBuilder(UpdatePipeline...).Skip("setup", "extras", "diff", "confirm").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config", "env", "client", "git", "notes", "chart", "render", "publish", "notify"}, names(p))
+}
+
+func TestPipelineBuilder_Only(t *testing.T) {
+	p, err := NewPipelineBuilder(UpdatePipeline...).Only("config", "env", "client", "git", "chart", "diff").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config", "env", "client", "git", "chart", "diff"}, names(p))
+}
+
+func TestPipelineBuilder_SkipRequired(t *testing.T) {
+	_, err := NewPipelineBuilder(UpdatePipeline...).Skip("config").Build()
+	assert.EqualError(t, err, "\nErrors:\n"+
+		" • stage 'env' requires stage 'config', which is not enabled\n"+
+		" • stage 'client' requires stage 'config', which is not enabled\n"+
+		" • stage 'git' requires stage 'config', which is not enabled\n"+
+		" • stage 'render' requires stage 'config', which is not enabled\n\n",
+	)
+}
+
+func TestPipelineBuilder_SkipDiff(t *testing.T) {
+	// Changes are only confirmed once they have been shown.
+	_, err := NewPipelineBuilder(UpdatePipeline...).Skip("diff").Build()
+	assert.EqualError(t, err, "\nErrors:\n • stage 'confirm' requires stage 'diff', which is not enabled\n\n")
+}
+
+func TestPipelineBuilder_OnlyMissingRequired(t *testing.T) {
+	_, err := NewPipelineBuilder(UpdatePipeline...).Only("diff").Build()
+	assert.EqualError(t, err, "\nErrors:\n • stage 'diff' requires stage 'chart', which is not enabled\n\n")
+}
+
+func TestPipelineBuilder_UnknownStage(t *testing.T) {
+	_, err := NewPipelineBuilder(testStage{name: "a"}, testStage{name: "b"}).Skip("c").Only("d").Build()
+	assert.EqualError(t, err, "\nErrors:\n"+
+		" • unknown stage 'c', should be one of: [a b]\n"+
+		" • unknown stage 'd', should be one of: [a b]\n\n",
+	)
+}
+
+func TestPipelineBuilder_Insert(t *testing.T) {
+	p, err := NewPipelineBuilder(testStage{name: "a"}, testStage{name: "b"}).
+		InsertBefore("a", testStage{name: "first"}).
+		InsertAfter("a", testStage{name: "second", requires: []string{"a"}}).
+		InsertAfter("b", testStage{name: "last", requires: []string{"second"}}).
+		Append(testStage{name: "appended"}).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "a", "second", "b", "last", "appended"}, names(p))
+}
+
+func TestPipelineBuilder_InsertUnknown(t *testing.T) {
+	_, err := NewPipelineBuilder(testStage{name: "a"}).
+		InsertBefore("b", testStage{name: "c"}).
+		Build()
+	assert.EqualError(t, err, "\nErrors:\n • unable to insert stage 'c': unknown stage 'b'\n\n")
+}
+
+func TestPipelineBuilder_RequiresLaterStage(t *testing.T) {
+	_, err := NewPipelineBuilder(testStage{name: "a", requires: []string{"b"}}, testStage{name: "b"}).Build()
+	assert.EqualError(t, err, "\nErrors:\n • stage 'a' requires stage 'b' to run before it\n\n")
+}
+
+func TestPipelineBuilder_Provided(t *testing.T) {
+	p, err := NewPipelineBuilder(testStage{name: "b", requires: []string{"a"}}).Provided("a").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, names(p))
+}
+
+func TestPipelineBuilder_Duplicate(t *testing.T) {
+	_, err := NewPipelineBuilder(testStage{name: "a"}, testStage{name: "a"}).Build()
+	assert.EqualError(t, err, "\nErrors:\n • duplicate stage 'a' in pipeline\n\n")
+}
+
+func TestBuildPipeline_Config(t *testing.T) {
+	cfg := &v1.Config{
+		Pipeline: &v1.PipelineConfig{
+			Skip: []string{"b"},
+			Only: []string{"a", "b", "c"},
+		},
+	}
+	base := Pipeline{testStage{name: "a"}, testStage{name: "b"}, testStage{name: "c"}, testStage{name: "d"}}
+
+	p, err := buildPipeline(base, cfg, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, names(p))
+
+	// Skipped stages are added to those configured, and only stages replace
+	// those configured.
+	p, err = buildPipeline(base, cfg, []string{"a"}, []string{"a", "d"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, names(p))
+}
+
+func TestBuildPipeline_Default(t *testing.T) {
+	p, err := buildPipeline(nil, &v1.Config{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, names(UpdatePipeline), names(p))
+}
+
+func TestBuildPipeline_ConfigRequires(t *testing.T) {
+	cfg := &v1.Config{
+		Release: &v1.ReleaseConfig{
+			VersionSource: &v1.VersionSourceConfig{Type: "github"},
+		},
+	}
+
+	base := Pipeline{testStage{name: "config"}, testStage{name: "client"}, git.Stage{}}
+
+	_, err := buildPipeline(base, cfg, []string{"client"}, nil)
+	assert.EqualError(t, err, "\nErrors:\n • stage 'git' requires stage 'client', which is not enabled\n\n")
+
+	// Other version sources do not use the client.
+	cfg.Release.VersionSource.Type = "git"
+	p, err := buildPipeline(base, cfg, []string{"client"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config", "git"}, names(p))
+}
+
+func TestBuildPipeline_UnknownHookStage(t *testing.T) {
+	cfg := &v1.Config{
+		Hooks: map[string]*v1.HookConfig{
+			"a":      {Before: []string{"true"}},
+			"foo":    {Before: []string{"true"}},
+			"bar":    {After: []string{"true"}},
+			"second": {After: []string{"true"}},
+		},
+	}
+	base := Pipeline{testStage{name: "a"}, testStage{name: "second"}}
+
+	_, err := buildPipeline(base, cfg, nil, []string{"a"})
+	assert.EqualError(t, err, "hooks configured for unknown stages [bar foo], should be one of: [a second]")
+}
+
+func TestPipeline_RunStageFields(t *testing.T) {
+	h := memory.New()
+	logger := &log.Logger{Handler: h, Level: log.InfoLevel}
+	context := ctx.New(&v1.Config{})
+	context.NoColor = true
+	context.SetLog(logger)
+	context.Repository = ctx.Repository{Owner: "example", Name: "charts"}
+	context.Chart.SubPath = "charts/foo"
+	context.Git.Ref = "chartreleaser/{{ .Chart.Name }}"
+
+	p := Pipeline{logStage{testStage{name: "first"}}, logStage{testStage{name: "second"}}}
+	assert.NoError(t, p.Run(context))
+	assert.Equal(t, logger, context.Log())
+
+	assert.Len(t, h.Entries, 4)
+	assert.Equal(t, "FIRST - test stage first", h.Entries[0].Message)
+	// The ref is left out until it is rendered.
+	assert.Equal(t, logging.StageFields("first", "example/charts", "", "charts/foo"), h.Entries[0].Fields)
+	assert.Equal(t, "running second", h.Entries[3].Message)
+	assert.Equal(t, "charts/foo/README.md", h.Entries[3].Fields.Get("path"))
+	assert.Equal(t, logging.StageFields("second", "", "", "").Get("stage"), h.Entries[3].Fields.Get("stage"))
+}
@@ -0,0 +1,173 @@
+package v1
+
+import (
+	gocontext "context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/strategies"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/chart"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/config"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/extras"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/notes"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/notify"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/publish"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/render"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
+)
+
+// Errors for running an update from a Go program.
+var (
+	ErrReleaseNoConfig     = errors.New("no config provided for release")
+	ErrReleaseNoClient     = errors.New("no client provided for release")
+	ErrReleaseNoAppVersion = errors.New("no app version provided for release")
+)
+
+// ReleasePipeline defines the stages run by Release, in order. The stages which
+// read the local git repository and environment are left out, since their
+// values are provided by the ReleaseOptions instead.
+var ReleasePipeline Pipeline = []stages.V1Stage{
+	notes.Stage{},
+	chart.Stage{},
+	extras.Stage{},
+	render.Stage{},
+	publish.Stage{},
+	notify.Stage{},
+}
+
+// releaseProvided holds the names of the stages which are required by the
+// ReleasePipeline, but whose values are set by Release instead.
+var releaseProvided = []string{"config", "client", "git"}
+
+// ReleaseOptions provide the inputs for a Release.
+type ReleaseOptions struct {
+	// Config is the parsed v1 configuration, e.g. from cfg.Load, with any
+	// template files loaded.
+	Config *v1.Config
+
+	// Client is used to read from and publish to the chart repository, e.g.
+	// a GitHub client created with client.NewGitHubClient, or an in-memory
+	// client from the client/memory package.
+	Client client.Client
+
+	// AppVersion is the tag of the new application release. It is parsed
+	// into a version using the tag pattern of the configuration.
+	AppVersion string
+
+	// PreviousAppVersion is the tag of the previous application release. If
+	// not set, the previous version is taken from the appVersion of the
+	// Chart, regardless of the configured previous version source.
+	PreviousAppVersion string
+
+	DryRun      bool
+	DryRunHooks bool
+	NoColor     bool
+	NoRollback  bool
+
+	// Out receives the output which is written to the console by the update
+	// command, such as the publish journal. If nil, output is discarded.
+	Out io.Writer
+
+	// Logger receives the log messages of the stages of the release. If nil,
+	// the package-level apex/log logger is used. See Release for the messages
+	// which do not go to Logger.
+	Logger log.Interface
+}
+
+// Release runs an update for a new application release, without reading
+// the local git repository, environment variables, or flags.
+//
+// A report of the release is returned even if the release fails, once the
+// options have been validated, so any changes made to the chart repository
+// can be inspected.
+//
+// Only the stages log to opts.Logger. The Client is created by the caller,
+// so Release can not set its logger: the GitHub client logs its requests,
+// retries and created pull requests to the package-level apex/log logger,
+// even if opts.Logger is set.
+func Release(goctx gocontext.Context, opts ReleaseOptions) (*report.Report, error) {
+	switch {
+	case opts.Config == nil:
+		return nil, ErrReleaseNoConfig
+	case opts.Client == nil:
+		return nil, ErrReleaseNoClient
+	case opts.AppVersion == "":
+		return nil, ErrReleaseNoAppVersion
+	}
+	if err := opts.Config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateTemplates(opts.Config); err != nil {
+		return nil, err
+	}
+
+	context := ctx.Wrap(goctx, opts.Config)
+	context.SetLog(opts.Logger)
+	context.Out = opts.Out
+	if context.Out == nil {
+		context.Out = ioutil.Discard
+	}
+	context.In = strings.NewReader("")
+	context.Client = opts.Client
+	context.AppVersion = opts.AppVersion
+	context.DryRun = opts.DryRun
+	context.DryRunHooks = opts.DryRunHooks
+	context.NoColor = opts.NoColor
+	context.NoRollback = opts.NoRollback
+
+	err := release(context, opts)
+	return report.New(context, err), err
+}
+
+// release populates the Context from the options and runs the ReleasePipeline.
+func release(context *ctx.Context, opts ReleaseOptions) error {
+	if err := (config.Stage{}).Run(context); err != nil {
+		return err
+	}
+
+	var err error
+	context.Git.Tag = opts.AppVersion
+	context.Git.TagSource = "release options"
+	context.App.NewVersion, err = utils.VersionFromTag(opts.AppVersion, context.Release.TagPattern, context.Release.TagNormalize)
+	if err != nil {
+		return err
+	}
+	if opts.PreviousAppVersion != "" {
+		context.App.PreviousTag = opts.PreviousAppVersion
+		if context.Release.PreviousSource == strategies.PreviousGit {
+			context.App.PreviousVersion, err = utils.VersionFromTag(opts.PreviousAppVersion, context.Release.TagPattern, context.Release.TagNormalize)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	pipeline, err := releasePipeline(context.Config)
+	if err != nil {
+		return err
+	}
+	err = pipeline.Run(context)
+	if err != nil && pipeline.Contains(notify.Stage{}.Name()) {
+		notify.Send(context, err)
+	}
+	return err
+}
+
+// releasePipeline gets the stages of the ReleasePipeline selected by the
+// configuration. Stages which are configured but not part of the
+// ReleasePipeline are rejected, as they are for the UpdatePipeline.
+func releasePipeline(cfg *v1.Config) (Pipeline, error) {
+	b := NewPipelineBuilder(ReleasePipeline...).Provided(releaseProvided...)
+	if cfg.Pipeline != nil {
+		b.Skip(cfg.Pipeline.Skip...).Only(cfg.Pipeline.Only...)
+	}
+	return b.Build()
+}
@@ -0,0 +1,237 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	memclient "github.com/edaniszewski/chart-releaser/pkg/client/memory"
+	"github.com/edaniszewski/chart-releaser/pkg/logging"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
+	"github.com/stretchr/testify/assert"
+)
+
+const releaseConfig = `version: v1
+chart:
+  name: foo
+  repo: github.com/example/charts
+  path: charts/foo
+commit:
+  author:
+    name: releaser
+    email: releaser@example.com
+`
+
+func TestRelease(t *testing.T) {
+	cfg, err := v1.LoadFromBytes([]byte(releaseConfig))
+	assert.NoError(t, err)
+	c := memclient.New(map[string]string{
+		"charts/foo/Chart.yaml": "apiVersion: v2\nappVersion: v0.1.0\nname: foo\nversion: 0.1.0\n",
+	})
+	opts := ReleaseOptions{
+		Config:     cfg,
+		Client:     c,
+		AppVersion: "v0.2.0",
+	}
+
+	r, err := Release(context.Background(), opts)
+	assert.NoError(t, err)
+
+	log := c.Log("chartreleaser/foo/0.1.1")
+	assert.Len(t, log, 2)
+	chart, _ := c.File("chartreleaser/foo/0.1.1", "charts/foo/Chart.yaml")
+	assert.Equal(t, "apiVersion: v2\nappVersion: v0.2.0\nname: foo\nversion: 0.1.1\n", chart)
+
+	assert.True(t, r.Success)
+	assert.Equal(t, report.Versions{Previous: "v0.1.0", New: "v0.2.0"}, r.App)
+	assert.Equal(t, report.Versions{Previous: "0.1.0", New: "0.1.1"}, r.Chart)
+	assert.Equal(t, "chartreleaser/foo/0.1.1", r.Branch)
+	assert.Equal(t, "master", r.Base)
+	assert.Len(t, r.Files, 1)
+	assert.True(t, r.Files[0].Changed)
+	assert.Equal(t, []report.Commit{
+		{Path: "charts/foo/Chart.yaml", SHA: log[0].SHA, Message: log[0].Message},
+	}, r.Commits)
+	assert.Equal(t, &report.PullRequest{Number: 1, URL: "memory://example/charts/pull/1"}, r.PullRequest)
+	assert.Equal(t, []string{"notes", "chart", "extras", "render", "publish", "notify"}, stageNames(r))
+}
+
+func TestRelease_DryRun(t *testing.T) {
+	cfg, err := v1.LoadFromBytes([]byte(releaseConfig))
+	assert.NoError(t, err)
+	c := memclient.New(map[string]string{
+		"charts/foo/Chart.yaml": "apiVersion: v2\nappVersion: v0.1.0\nname: foo\nversion: 0.1.0\n",
+	})
+	opts := ReleaseOptions{
+		Config:     cfg,
+		Client:     c,
+		AppVersion: "v0.2.0",
+		DryRun:     true,
+	}
+	out := bytes.Buffer{}
+	opts.Out = &out
+
+	r, err := Release(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.True(t, r.DryRun)
+	assert.Equal(t, report.Versions{Previous: "0.1.0", New: "0.1.1"}, r.Chart)
+
+	assert.Equal(t, []string{"master"}, c.Branches())
+	assert.Empty(t, r.Commits)
+	assert.Contains(t, out.String(), "=== Context ===")
+}
+
+func TestRelease_PreviousAppVersion(t *testing.T) {
+	cfg, err := v1.LoadFromBytes([]byte(releaseConfig + `release:
+  previous_source: git
+`))
+	assert.NoError(t, err)
+	opts := ReleaseOptions{
+		Config: cfg,
+		Client: memclient.New(map[string]string{
+			"charts/foo/Chart.yaml": "apiVersion: v2\nappVersion: v0.1.0\nname: foo\nversion: 0.1.0\n",
+		}),
+		AppVersion:         "v0.2.0",
+		DryRun:             true,
+		PreviousAppVersion: "v0.1.5",
+	}
+
+	r, err := Release(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, report.Versions{Previous: "v0.1.5", New: "v0.2.0"}, r.App)
+}
+
+func TestRelease_SkipStages(t *testing.T) {
+	cfg, err := v1.LoadFromBytes([]byte(releaseConfig + `pipeline:
+  skip: [extras, notify]
+`))
+	assert.NoError(t, err)
+	opts := ReleaseOptions{
+		Config: cfg,
+		Client: memclient.New(map[string]string{
+			"charts/foo/Chart.yaml": "apiVersion: v2\nappVersion: v0.1.0\nname: foo\nversion: 0.1.0\n",
+		}),
+		AppVersion: "v0.2.0",
+		DryRun:     true,
+	}
+
+	r, err := Release(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"notes", "chart", "render", "publish"}, stageNames(r))
+}
+
+func TestRelease_SkipUnknownStage(t *testing.T) {
+	// Stages of the update pipeline which are not run by Release are unknown.
+	cfg, err := v1.LoadFromBytes([]byte(releaseConfig + `pipeline:
+  skip: [setup]
+`))
+	assert.NoError(t, err)
+	c := memclient.New(map[string]string{
+		"charts/foo/Chart.yaml": "apiVersion: v2\nappVersion: v0.1.0\nname: foo\nversion: 0.1.0\n",
+	})
+	opts := ReleaseOptions{
+		Config:     cfg,
+		Client:     c,
+		AppVersion: "v0.2.0",
+	}
+
+	r, err := Release(context.Background(), opts)
+	assert.EqualError(t, err, "\nErrors:\n • unknown stage 'setup', should be one of: [notes chart extras render publish notify]\n\n")
+	assert.False(t, r.Success)
+	assert.Empty(t, r.Stages)
+	assert.Equal(t, []string{"master"}, c.Branches())
+}
+
+func TestRelease_Logger(t *testing.T) {
+	cfg, err := v1.LoadFromBytes([]byte(releaseConfig))
+	assert.NoError(t, err)
+	opts := ReleaseOptions{
+		Config: cfg,
+		Client: memclient.New(map[string]string{
+			"charts/foo/Chart.yaml": "apiVersion: v2\nappVersion: v0.1.0\nname: foo\nversion: 0.1.0\n",
+		}),
+		AppVersion: "v0.2.0",
+		DryRun:     true,
+		NoColor:    true,
+	}
+	h := memory.New()
+	opts.Logger = &log.Logger{Handler: h, Level: log.InfoLevel}
+	orig := log.Log
+
+	_, err = Release(context.Background(), opts)
+	assert.NoError(t, err)
+	var notes *log.Entry
+	for _, e := range h.Entries {
+		if e.Message == "NOTES - fetching application release notes" {
+			notes = e
+		}
+	}
+	if assert.NotNil(t, notes) {
+		assert.Equal(t, logging.StageFields("notes", "", "", "").Get("stage"), notes.Fields.Get("stage"))
+	}
+	assert.Equal(t, orig, log.Log)
+}
+
+func TestRelease_Error(t *testing.T) {
+	cfg, err := v1.LoadFromBytes([]byte(releaseConfig))
+	assert.NoError(t, err)
+	c := memclient.New(map[string]string{
+		"charts/foo/Chart.yaml": "apiVersion: v2\nappVersion: v0.1.0\nname: foo\nversion: 0.1.0\n",
+	})
+	opts := ReleaseOptions{
+		Config:     cfg,
+		Client:     c,
+		AppVersion: "v0.2.0",
+	}
+
+	// A branch left behind by an earlier update blocks the next one.
+	assert.NoError(t, c.CreateRef(context.Background(), &client.Options{Ref: "chartreleaser/foo/0.1.1", Base: "master"}))
+
+	r, err := Release(context.Background(), opts)
+	assert.True(t, errors.Is(err, memclient.ErrBranchExists))
+	assert.False(t, r.Success)
+	assert.Equal(t, report.Versions{Previous: "0.1.0", New: "0.1.1"}, r.Chart)
+	assert.Empty(t, r.Commits)
+	assert.Equal(t, "publish", r.Stages[len(r.Stages)-1].Name)
+	assert.NotEmpty(t, r.Stages[len(r.Stages)-1].Error)
+}
+
+func TestRelease_OptionsError(t *testing.T) {
+	cfg, err := v1.LoadFromBytes([]byte(releaseConfig))
+	assert.NoError(t, err)
+	opts := ReleaseOptions{
+		Config: cfg,
+		Client: memclient.New(map[string]string{
+			"charts/foo/Chart.yaml": "apiVersion: v2\nappVersion: v0.1.0\nname: foo\nversion: 0.1.0\n",
+		}),
+		AppVersion: "v0.2.0",
+	}
+
+	noConfig := opts
+	noConfig.Config = nil
+	_, err = Release(context.Background(), noConfig)
+	assert.Equal(t, ErrReleaseNoConfig, err)
+
+	noClient := opts
+	noClient.Client = nil
+	_, err = Release(context.Background(), noClient)
+	assert.Equal(t, ErrReleaseNoClient, err)
+
+	noVersion := opts
+	noVersion.AppVersion = ""
+	_, err = Release(context.Background(), noVersion)
+	assert.Equal(t, ErrReleaseNoAppVersion, err)
+}
+
+func stageNames(r *report.Report) []string {
+	var names []string
+	for _, s := range r.Stages {
+		names = append(names, s.Name)
+	}
+	return names
+}
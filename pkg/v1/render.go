@@ -0,0 +1,238 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apex/log"
+	version "github.com/edaniszewski/chart-releaser/pkg/semver"
+	"github.com/edaniszewski/chart-releaser/pkg/strategies"
+	"github.com/edaniszewski/chart-releaser/pkg/templates"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/chart"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/config"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/git"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/publish"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/render"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
+)
+
+// Errors for rendering templates for a hypothetical release.
+var (
+	ErrRenderVersionSource  = errors.New("the 'github' version source requires network access, set the app version with --app-version")
+	ErrNoPreviousAppVersion = errors.New("unable to determine the previous app version from git tags, set it with --previous-app-version")
+)
+
+// Output formats supported by the Renderer.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// DefaultPreviousChartVersion is the previous chart version used for rendering
+// when none is provided.
+const DefaultPreviousChartVersion = "0.1.0"
+
+// Renderer renders the templated outputs of an update for a hypothetical release,
+// without making any changes.
+type Renderer struct {
+	data []byte
+}
+
+// NewRenderer creates a new Renderer.
+func NewRenderer(b []byte) *Renderer {
+	return &Renderer{
+		data: b,
+	}
+}
+
+// RenderOptions provide command line options to the Renderer.
+type RenderOptions struct {
+	AppVersion           string
+	ChartVersion         string
+	ConfigPath           string
+	PreviousAppVersion   string
+	PreviousChartVersion string
+	Output               string
+	Out                  io.Writer
+}
+
+// RenderResult holds the rendered outputs for a release.
+type RenderResult struct {
+	AppVersion           string                  `json:"app_version"`
+	PreviousAppVersion   string                  `json:"previous_app_version"`
+	ChartVersion         string                  `json:"chart_version"`
+	PreviousChartVersion string                  `json:"previous_chart_version"`
+	PublishStrategy      string                  `json:"publish_strategy"`
+	Branch               string                  `json:"branch"`
+	Base                 string                  `json:"base"`
+	ChartCommitMessage   string                  `json:"chart_commit_message"`
+	ExtrasCommitMessages []RenderedCommitMessage `json:"extras_commit_messages,omitempty"`
+	PRTitle              string                  `json:"pr_title,omitempty"`
+	PRBody               string                  `json:"pr_body,omitempty"`
+}
+
+// RenderedCommitMessage is the rendered commit message for an extras file.
+type RenderedCommitMessage struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Run the v1 template renderer.
+//
+// The Context is built from the configuration and the versions provided in the
+// options. Any versions which are not provided are discovered locally, so this
+// does not require network access or a token.
+func (r *Renderer) Run(opts RenderOptions) error {
+	if opts.Output == "" {
+		opts.Output = OutputText
+	}
+	if opts.Output != OutputText && opts.Output != OutputJSON {
+		return fmt.Errorf("unsupported output format '%s', should be one of: [%s %s]", opts.Output, OutputText, OutputJSON)
+	}
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+
+	cfg, err := v1.Load(r.data, configDir(opts.ConfigPath))
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	context := ctx.New(cfg)
+	context.Out = opts.Out
+	context.AppVersion = opts.AppVersion
+	detectCI(context)
+
+	result, err := renderRelease(context, opts)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Output {
+	case OutputJSON:
+		enc := json.NewEncoder(opts.Out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	default:
+		t, err := templates.New("render").Parse(templates.RenderOutputTemplate)
+		if err != nil {
+			return err
+		}
+		return t.Execute(opts.Out, result)
+	}
+}
+
+// renderRelease populates the Context for the release and renders its templates.
+func renderRelease(context *ctx.Context, opts RenderOptions) (*RenderResult, error) {
+	if err := (config.Stage{}).Run(context); err != nil {
+		return nil, err
+	}
+
+	// The app version is resolved by the git stage, which only needs local git
+	// information unless the version comes from a GitHub release.
+	if opts.AppVersion == "" && context.Release.VersionSource == strategies.SourceGitHub {
+		return nil, ErrRenderVersionSource
+	}
+	if opts.PreviousAppVersion != "" {
+		// The previous version is provided, so git tags are only used to
+		// collect the commits for the release.
+		context.Release.PreviousSource = strategies.PreviousChart
+	}
+	if err := (git.Stage{}).Run(context); err != nil {
+		return nil, err
+	}
+
+	if err := loadRenderVersions(context, opts); err != nil {
+		return nil, err
+	}
+	context.Chart.File = ctx.File{
+		Path: chart.FilePath(context.Chart.SubPath),
+	}
+
+	if err := (render.Stage{}).Run(context); err != nil {
+		return nil, err
+	}
+
+	result := &RenderResult{
+		AppVersion:           context.App.NewVersion.String(),
+		PreviousAppVersion:   context.App.PreviousVersion.String(),
+		ChartVersion:         context.Chart.NewVersion.String(),
+		PreviousChartVersion: context.Chart.PreviousVersion.String(),
+		PublishStrategy:      string(context.PublishStrategy),
+		Branch:               context.Git.Ref,
+		Base:                 context.Git.Base,
+		ChartCommitMessage:   context.Release.ChartCommitMsg,
+		PRTitle:              context.Release.PRTitle,
+		PRBody:               context.Release.PRBody,
+	}
+	for _, extra := range context.Config.Extras {
+		msg, err := publish.ExtrasCommitMessage(context, ctx.File{Path: extra.Path})
+		if err != nil {
+			return nil, err
+		}
+		result.ExtrasCommitMessages = append(result.ExtrasCommitMessages, RenderedCommitMessage{
+			Path:    extra.Path,
+			Message: msg,
+		})
+	}
+	return result, nil
+}
+
+// loadRenderVersions sets the previous app version and the chart versions on the
+// Context. These are usually read from the Chart in the remote repository, so
+// they are taken from the options or stand-in values instead.
+func loadRenderVersions(context *ctx.Context, opts RenderOptions) error {
+	var err error
+
+	switch {
+	case opts.PreviousAppVersion != "":
+		context.App.PreviousVersion, err = version.Load(opts.PreviousAppVersion)
+		if err != nil {
+			return err
+		}
+	case context.App.PreviousTag != "":
+		if context.Release.PreviousSource != strategies.PreviousGit {
+			context.App.PreviousVersion, err = utils.VersionFromTag(context.App.PreviousTag, context.Release.TagPattern, context.Release.TagNormalize)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrNoPreviousAppVersion
+	}
+	context.App.ChartAppVersion = context.App.PreviousVersion
+
+	previousChartVersion := opts.PreviousChartVersion
+	if previousChartVersion == "" {
+		previousChartVersion = DefaultPreviousChartVersion
+		log.WithField("version", previousChartVersion).Info("no previous chart version provided, using default")
+	}
+	context.Chart.PreviousVersion, err = version.Load(previousChartVersion)
+	if err != nil {
+		return err
+	}
+
+	if opts.ChartVersion != "" {
+		context.Chart.NewVersion, err = version.Load(opts.ChartVersion)
+		return err
+	}
+	context.Chart.NewVersion, err = strategies.UpdateRelease(&strategies.UpdateCtx{
+		OldAppVersion:   &context.App.PreviousVersion,
+		NewAppVersion:   &context.App.NewVersion,
+		OldChartVersion: &context.Chart.PreviousVersion,
+		Strategy:        context.UpdateStrategy,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to determine the new chart version (%v), set it with --chart-version", err)
+	}
+	return nil
+}
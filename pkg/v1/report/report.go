@@ -0,0 +1,196 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"github.com/edaniszewski/chart-releaser/pkg/utils"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+)
+
+// SchemaVersion is the version of the report schema. It is incremented whenever
+// a backwards incompatible change is made to the report.
+const SchemaVersion = 1
+
+// Report is a machine-readable summary of a run of the update pipeline.
+type Report struct {
+	SchemaVersion int          `json:"schema_version"`
+	Success       bool         `json:"success"`
+	DryRun        bool         `json:"dry_run"`
+	Stages        []Stage      `json:"stages"`
+	App           Versions     `json:"app"`
+	Chart         Versions     `json:"chart"`
+	Files         []File       `json:"files"`
+	Branch        string       `json:"branch,omitempty"`
+	Base          string       `json:"base,omitempty"`
+	Commits       []Commit     `json:"commits"`
+	PullRequest   *PullRequest `json:"pull_request,omitempty"`
+	Skipped       bool         `json:"skipped"`
+	SkipReason    string       `json:"skip_reason,omitempty"`
+	Errors        []string     `json:"errors"`
+	Journal       []Mutation   `json:"journal,omitempty"`
+}
+
+// Stage is a stage of the update pipeline which was run.
+type Stage struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration_seconds"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Versions holds the previous and new versions of the app or chart.
+type Versions struct {
+	Previous string `json:"previous,omitempty"`
+	New      string `json:"new,omitempty"`
+}
+
+// File is a file in the chart repository considered for update.
+type File struct {
+	Path    string `json:"path"`
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+// Commit is a commit made to the chart repository.
+type Commit struct {
+	Path    string `json:"path"`
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+}
+
+// PullRequest is the pull request opened for the update.
+type PullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+// Mutation is a change made to the chart repository while publishing, and
+// whether it was rolled back after publishing failed.
+type Mutation struct {
+	Kind          string `json:"kind"`
+	Ref           string `json:"ref"`
+	Path          string `json:"path,omitempty"`
+	SHA           string `json:"sha,omitempty"`
+	PullRequest   int    `json:"pull_request,omitempty"`
+	RolledBack    bool   `json:"rolled_back"`
+	RollbackError string `json:"rollback_error,omitempty"`
+}
+
+// New creates a Report from the Context of an update pipeline run. The error
+// returned by the pipeline, if any, is included in the report.
+func New(ctx *context.Context, err error) *Report {
+	r := &Report{
+		SchemaVersion: SchemaVersion,
+		Success:       err == nil,
+		DryRun:        ctx.DryRun,
+		Stages:        []Stage{},
+		Files:         []File{},
+		Branch:        ctx.Git.Ref,
+		Base:          ctx.Git.Base,
+		Commits:       []Commit{},
+		SkipReason:    ctx.Publish.SkipReason,
+		Skipped:       ctx.Publish.SkipReason != "",
+		Errors:        []string{},
+	}
+
+	for _, s := range ctx.Stages {
+		stage := Stage{
+			Name:     s.Name,
+			Duration: s.Duration.Seconds(),
+		}
+		if s.Err != nil {
+			stage.Error = s.Err.Error()
+		}
+		r.Stages = append(r.Stages, stage)
+	}
+
+	// Versions are only known once the stages which load them have run, so
+	// unset versions are left out rather than reported as 0.0.0.
+	if ctx.Git.Tag != "" {
+		r.App.New = ctx.App.NewVersion.String()
+	}
+	if ctx.Chart.File.PreviousContents != nil {
+		r.App.Previous = ctx.App.PreviousVersion.String()
+		r.Chart.Previous = ctx.Chart.PreviousVersion.String()
+		r.Chart.New = ctx.Chart.NewVersion.String()
+	}
+
+	r.Files = append(r.Files, Files(ctx, utils.DefaultDiffContext)...)
+
+	for _, c := range ctx.Publish.Commits {
+		r.Commits = append(r.Commits, Commit{
+			Path:    c.Path,
+			SHA:     c.SHA,
+			Message: c.Message,
+		})
+	}
+	if pr := ctx.Publish.PullRequest; pr != nil {
+		r.PullRequest = &PullRequest{
+			Number: pr.Number,
+			URL:    pr.URL,
+		}
+	}
+
+	for _, m := range ctx.Publish.Journal {
+		mutation := Mutation{
+			Kind:          m.Kind,
+			Ref:           m.Ref,
+			Path:          m.Path,
+			SHA:           m.SHA,
+			RolledBack:    m.RolledBack,
+			RollbackError: m.RollbackError,
+		}
+		if m.PullRequest != nil {
+			mutation.PullRequest = m.PullRequest.Number
+		}
+		r.Journal = append(r.Journal, mutation)
+	}
+
+	for _, e := range ctx.ErrorList() {
+		r.Errors = append(r.Errors, e.Error())
+	}
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+	return r
+}
+
+// Files gets the chart file and any extra files of the Context which were
+// considered for update, with a unified diff of their changes using the given
+// number of context lines.
+func Files(ctx *context.Context, diffContext int) []File {
+	var files []File
+	for _, f := range append([]context.File{ctx.Chart.File}, ctx.Files...) {
+		if f.Path == "" {
+			continue
+		}
+		file := File{
+			Path:    f.Path,
+			Changed: f.HasChanges(),
+		}
+		if file.Changed {
+			file.Diff = utils.UnifiedDiff(f.Path, string(f.DiffBase()), string(f.NewContents), diffContext)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// Write the Report as JSON to the given writer.
+func (r *Report) Write(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteFile writes the Report as JSON to the file at the given path.
+func (r *Report) WriteFile(path string) error {
+	buf := bytes.Buffer{}
+	if err := r.Write(&buf); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
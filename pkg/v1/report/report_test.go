@@ -0,0 +1,168 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	context := ctx.Context{
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v1.1.0"),
+			PreviousVersion: testutils.NewSemver(t, "v1.0.0"),
+		},
+		Chart: ctx.Chart{
+			NewVersion:      testutils.NewSemver(t, "0.3.1"),
+			PreviousVersion: testutils.NewSemver(t, "0.3.0"),
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.3.0\n"),
+				NewContents:      []byte("version: 0.3.1\n"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "README.md",
+				PreviousContents: []byte("foo\n"),
+				NewContents:      []byte("foo\n"),
+			},
+		},
+		Git: ctx.Git{
+			Tag:  "v1.1.0",
+			Ref:  "chartreleaser/foo/0.3.1",
+			Base: "master",
+		},
+		Publish: ctx.Publish{
+			Commits: []ctx.PublishedCommit{
+				{Path: "charts/foo/Chart.yaml", SHA: "abc123", Message: "bump chart"},
+			},
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/test/charts/pull/7",
+			},
+		},
+		Stages: []ctx.StageRun{
+			{Name: "config", Duration: 1500 * time.Millisecond},
+			{Name: "publish", Duration: 2 * time.Second},
+		},
+	}
+
+	r := New(&context, nil)
+	assert.Equal(t, &Report{
+		SchemaVersion: SchemaVersion,
+		Success:       true,
+		Stages: []Stage{
+			{Name: "config", Duration: 1.5},
+			{Name: "publish", Duration: 2},
+		},
+		App:   Versions{Previous: "v1.0.0", New: "v1.1.0"},
+		Chart: Versions{Previous: "0.3.0", New: "0.3.1"},
+		Files: []File{
+			{
+				Path:    "charts/foo/Chart.yaml",
+				Changed: true,
+				Diff:    "--- a/charts/foo/Chart.yaml\n+++ b/charts/foo/Chart.yaml\n@@ -1 +1 @@\n-version: 0.3.0\n+version: 0.3.1\n",
+			},
+			{
+				Path: "README.md",
+			},
+		},
+		Branch: "chartreleaser/foo/0.3.1",
+		Base:   "master",
+		Commits: []Commit{
+			{Path: "charts/foo/Chart.yaml", SHA: "abc123", Message: "bump chart"},
+		},
+		PullRequest: &PullRequest{
+			Number: 7,
+			URL:    "https://github.com/test/charts/pull/7",
+		},
+		Errors: []string{},
+	}, r)
+}
+
+func TestNew_Failed(t *testing.T) {
+	context := ctx.New(nil)
+	context.DryRun = true
+	assert.NoError(t, context.CheckDryRun(errors.New("dry-run error")))
+	context.Stages = []ctx.StageRun{
+		{Name: "git", Duration: time.Second, Err: errors.New("stage error")},
+	}
+
+	r := New(context, errors.New("stage error"))
+	assert.False(t, r.Success)
+	assert.True(t, r.DryRun)
+	assert.Equal(t, []Stage{{Name: "git", Duration: 1, Error: "stage error"}}, r.Stages)
+	assert.Equal(t, Versions{}, r.App)
+	assert.Equal(t, Versions{}, r.Chart)
+	assert.Empty(t, r.Files)
+	assert.Equal(t, []string{"dry-run error", "stage error"}, r.Errors)
+}
+
+func TestNew_Skipped(t *testing.T) {
+	context := ctx.Context{
+		Publish: ctx.Publish{
+			SkipReason: "tag dev does not match release constraint 'v.*'",
+		},
+	}
+
+	r := New(&context, nil)
+	assert.True(t, r.Success)
+	assert.True(t, r.Skipped)
+	assert.Equal(t, "tag dev does not match release constraint 'v.*'", r.SkipReason)
+}
+
+func TestNew_Journal(t *testing.T) {
+	context := ctx.Context{
+		Publish: ctx.Publish{
+			Journal: []ctx.Mutation{
+				{Kind: ctx.MutationCreateRef, Ref: "chartreleaser/foo/0.2.0", RolledBack: true},
+				{Kind: ctx.MutationUpdateFile, Ref: "chartreleaser/foo/0.2.0", Path: "Chart.yaml", SHA: "abc123", RolledBack: true},
+				{Kind: ctx.MutationCreatePullRequest, Ref: "chartreleaser/foo/0.2.0", PullRequest: &client.PullRequest{Number: 7}, RollbackError: "test error"},
+			},
+		},
+	}
+
+	r := New(&context, errors.New("publish error"))
+	assert.Equal(t, []Mutation{
+		{Kind: "create-ref", Ref: "chartreleaser/foo/0.2.0", RolledBack: true},
+		{Kind: "update-file", Ref: "chartreleaser/foo/0.2.0", Path: "Chart.yaml", SHA: "abc123", RolledBack: true},
+		{Kind: "create-pull-request", Ref: "chartreleaser/foo/0.2.0", PullRequest: 7, RollbackError: "test error"},
+	}, r.Journal)
+}
+
+func TestReport_Write(t *testing.T) {
+	r := New(&ctx.Context{}, nil)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, r.Write(&buf))
+
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, float64(SchemaVersion), decoded["schema_version"])
+	assert.Equal(t, true, decoded["success"])
+	assert.Equal(t, []interface{}{}, decoded["errors"])
+}
+
+func TestReport_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	r := New(&ctx.Context{}, nil)
+
+	assert.NoError(t, r.WriteFile(path))
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, r.Write(&buf))
+	assert.Equal(t, buf.Bytes(), data)
+}
@@ -1,6 +1,7 @@
 package chart
 
 import (
+	"bytes"
 	"encoding/json"
 	"path/filepath"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	version "github.com/edaniszewski/chart-releaser/pkg/semver"
 	"github.com/edaniszewski/chart-releaser/pkg/strategies"
 	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
 	"github.com/pkg/errors"
 	"helm.sh/helm/v3/pkg/chart"
 	"sigs.k8s.io/yaml"
@@ -34,14 +36,14 @@ func (Stage) String() string {
 	return "updating helm chart"
 }
 
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"client", "git"}
+}
+
 // Run the operations defined for the stage.
 func (Stage) Run(ctx *context.Context) error {
-	// Check that the given path is for a Chart.yaml file. If not, consider it
-	// a directory and append "Chart.yaml" to the path.
-	path := ctx.Chart.SubPath
-	if !strings.HasSuffix(path, "Chart.yaml") && !strings.HasSuffix(path, "Chart.yml") {
-		path = filepath.Join(path, "Chart.yaml")
-	}
+	path := FilePath(ctx.Chart.SubPath)
 
 	ctx.Chart.File = context.File{
 		Path: path,
@@ -68,6 +70,9 @@ func (Stage) Run(ctx *context.Context) error {
 	if err != nil {
 		return err
 	}
+	if !bytes.Equal(ctx.Chart.File.PreviousContents, []byte(raw)) {
+		ctx.Chart.File.OriginalContents = []byte(raw)
+	}
 
 	// Get the chart version and the app version defined in the Chart
 	if chartMeta.Version == "" {
@@ -75,14 +80,14 @@ func (Stage) Run(ctx *context.Context) error {
 			return err
 		}
 		chartMeta.Version = "0.0.0"
-		log.WithField("version", chartMeta.Version).Warn("dry-run: using placeholder for chart version")
+		ctx.Log().WithField("version", chartMeta.Version).Warn("dry-run: using placeholder for chart version")
 	}
 	if chartMeta.AppVersion == "" {
 		if err := ctx.CheckDryRun(ErrNoAppVersion); err != nil {
 			return err
 		}
 		chartMeta.AppVersion = "0.0.0"
-		log.WithField("appVersion", chartMeta.AppVersion).Warn("dry-run: using placeholder for appVersion")
+		ctx.Log().WithField("appVersion", chartMeta.AppVersion).Warn("dry-run: using placeholder for appVersion")
 	}
 
 	ctx.Chart.PreviousVersion, err = version.Load(chartMeta.Version)
@@ -90,11 +95,30 @@ func (Stage) Run(ctx *context.Context) error {
 		return err
 	}
 
-	ctx.App.PreviousVersion, err = version.Load(chartMeta.AppVersion)
+	ctx.App.ChartAppVersion, err = version.Load(chartMeta.AppVersion)
 	if err != nil {
 		return err
 	}
 
+	// The appVersion in the Chart may lag behind or have been edited by hand,
+	// so check it against the previous release tag found in the git history.
+	if ctx.App.PreviousTag != "" {
+		tagVersion, err := utils.VersionFromTag(ctx.App.PreviousTag, ctx.Release.TagPattern, ctx.Release.TagNormalize)
+		if err == nil && tagVersion.Compare(&ctx.App.ChartAppVersion) != 0 {
+			ctx.Log().WithFields(log.Fields{
+				"appVersion":  ctx.App.ChartAppVersion.String(),
+				"previousTag": ctx.App.PreviousTag,
+				"source":      ctx.Release.PreviousSource,
+			}).Warn("chart appVersion does not match the previous release tag in git history")
+		}
+	}
+
+	// Unless the previous app version was already determined from the git
+	// history, it is the appVersion currently defined in the Chart.
+	if ctx.Release.PreviousSource != strategies.PreviousGit || ctx.App.PreviousTag == "" {
+		ctx.App.PreviousVersion = ctx.App.ChartAppVersion
+	}
+
 	// Determine the new version of the chart.
 	ctx.Chart.NewVersion, err = strategies.UpdateRelease(&strategies.UpdateCtx{
 		OldAppVersion:   &ctx.App.PreviousVersion,
@@ -108,7 +132,7 @@ func (Stage) Run(ctx *context.Context) error {
 		}
 		v, _ := version.Load("0.1.0")
 		ctx.Chart.NewVersion = v
-		log.WithField("version", "0.1.0").Warn("dry-run: using placeholder for new chart version")
+		ctx.Log().WithField("version", "0.1.0").Warn("dry-run: using placeholder for new chart version")
 	}
 
 	// Update the chart metadata struct with the new values.
@@ -123,6 +147,16 @@ func (Stage) Run(ctx *context.Context) error {
 	return nil
 }
 
+// FilePath gets the path to the Chart.yaml file for the configured chart path.
+// If the given path is not for a Chart.yaml file, it is considered a directory
+// and "Chart.yaml" is appended to the path.
+func FilePath(path string) string {
+	if !strings.HasSuffix(path, "Chart.yaml") && !strings.HasSuffix(path, "Chart.yml") {
+		path = filepath.Join(path, "Chart.yaml")
+	}
+	return path
+}
+
 // marshalContents marshals the Chart metadata first to JSON then to YAML. This is a
 // bit of a hack because the struct does not include annotations for YAML, do direct
 // marshalling to YAML will result in undesired fields, and inconsistent field names.
@@ -50,6 +50,7 @@ appVersion: 0.2.3
 	assert.Equal(t, "charts/Chart.yaml", context.Chart.File.Path)
 	assert.Equal(t, "apiVersion: v1\nappVersion: 0.3.0\nname: test-chart\nversion: 0.1.3\n", string(context.Chart.File.NewContents))
 	assert.Equal(t, "apiVersion: v1\nappVersion: 0.2.3\nname: test-chart\nversion: 0.1.2\n", string(context.Chart.File.PreviousContents))
+	assert.Equal(t, "\napiVersion: v1\nname: test-chart\nversion: 0.1.2\nappVersion: 0.2.3\n", string(context.Chart.File.OriginalContents))
 
 	assert.Equal(t, "0.3.0", context.App.NewVersion.String())
 	assert.Equal(t, "0.2.3", context.App.PreviousVersion.String())
@@ -69,6 +70,70 @@ appVersion: 0.2.3
 	assert.Len(t, context.Files, 0)
 }
 
+func TestStage_RunPreviousFromGit(t *testing.T) {
+	context := ctx.Context{
+		Chart: ctx.Chart{
+			Name: "test-chart",
+		},
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v0.3.0"),
+			PreviousVersion: testutils.NewSemver(t, "v0.2.9"),
+			PreviousTag:     "v0.2.9",
+		},
+		Release: ctx.Release{
+			PreviousSource: strategies.PreviousGit,
+		},
+		UpdateStrategy: strategies.UpdateDefault,
+		Client: &testutils.FakeClient{
+			FileData: `
+apiVersion: v1
+name: test-chart
+version: 0.1.2
+appVersion: 0.2.3
+`,
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "0.1.3", context.Chart.NewVersion.String())
+	assert.Equal(t, "v0.3.0", context.App.NewVersion.String())
+	assert.Equal(t, "v0.2.9", context.App.PreviousVersion.String())
+	assert.Equal(t, "0.2.3", context.App.ChartAppVersion.String())
+	assert.Equal(t, "v0.2.9", context.App.PreviousTag)
+}
+
+func TestStage_RunPreviousFromGitNoTag(t *testing.T) {
+	context := ctx.Context{
+		Chart: ctx.Chart{
+			Name: "test-chart",
+		},
+		App: ctx.App{
+			NewVersion: testutils.NewSemver(t, "0.3.0"),
+		},
+		Release: ctx.Release{
+			PreviousSource: strategies.PreviousGit,
+		},
+		UpdateStrategy: strategies.UpdateDefault,
+		Client: &testutils.FakeClient{
+			FileData: `
+apiVersion: v1
+name: test-chart
+version: 0.1.2
+appVersion: 0.2.3
+`,
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "0.2.3", context.App.PreviousVersion.String())
+	assert.Equal(t, "0.2.3", context.App.ChartAppVersion.String())
+	assert.Equal(t, "", context.App.PreviousTag)
+}
+
 func TestStage_RunChartGetError(t *testing.T) {
 	context := ctx.Context{
 		Client: &testutils.FakeClient{
@@ -27,22 +27,37 @@ func (Stage) String() string {
 	return "creating repository client"
 }
 
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"config", "env"}
+}
+
 // Run the operations defined for the stage.
 func (Stage) Run(ctx *context.Context) error {
 	if ctx.Repository.Type == "" {
 		return ErrRepoTypeNotSet
 	}
 
+	if ctx.LocalChartRepo != "" {
+		ctx.Log().WithField("path", ctx.LocalChartRepo).Info("using local chart repo client")
+		c, err := client.NewLocalClient(ctx.LocalChartRepo)
+		if err != nil {
+			return err
+		}
+		ctx.Client = c
+		return nil
+	}
+
 	switch ctx.Repository.Type {
 	case context.RepoGithub:
-		c, err := client.NewGitHubClient(ctx.Context, ctx.Token)
+		c, err := client.NewGitHubClientWithURL(ctx.Context, ctx.APIURL, ctx.Token, ctx.Retry)
 		if err != nil {
 			return err
 		}
 		ctx.Client = c
 
 	default:
-		log.WithFields(log.Fields{
+		ctx.Log().WithFields(log.Fields{
 			"type": ctx.Repository.Type,
 		}).Error("unsupported repository type specified")
 		return ErrUnsupportedRepoType
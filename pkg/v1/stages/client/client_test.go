@@ -65,3 +65,30 @@ func TestStage_Run_ErrorUnsupportedRepoType(t *testing.T) {
 	assert.Equal(t, ErrUnsupportedRepoType, err)
 	assert.Nil(t, context.Client)
 }
+
+func TestStage_Run_LocalChartRepo(t *testing.T) {
+	context := ctx.Context{
+		Repository: ctx.Repository{
+			Type: ctx.RepoGithub,
+		},
+		LocalChartRepo: t.TempDir(),
+	}
+	assert.Nil(t, context.Client)
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.NotNil(t, context.Client)
+}
+
+func TestStage_Run_LocalChartRepoError(t *testing.T) {
+	context := ctx.Context{
+		Repository: ctx.Repository{
+			Type: ctx.RepoGithub,
+		},
+		LocalChartRepo: "/does/not/exist",
+	}
+
+	err := Stage{}.Run(&context)
+	assert.Error(t, err)
+	assert.Nil(t, context.Client)
+}
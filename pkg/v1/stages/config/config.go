@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"regexp"
 
-	"github.com/apex/log"
 	"github.com/edaniszewski/chart-releaser/pkg/strategies"
 	"github.com/edaniszewski/chart-releaser/pkg/templates"
 	u "github.com/edaniszewski/chart-releaser/pkg/utils"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
 	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
 )
@@ -43,62 +43,73 @@ func (Stage) Run(ctx *context.Context) error {
 	}
 
 	// Author
-	log.Debug("loading author context")
+	ctx.Log().Debug("loading author context")
 	ctx.Author.Name = ctx.Config.Commit.Author.Name
 	if ctx.Author.Name == "" {
-		log.Debug("no commit author set, discovering from git config")
+		ctx.Log().Debug("no commit author set, discovering from git config")
 		name, err := u.GetGitUserName()
 		if err != nil {
 			if err := ctx.CheckDryRun(err); err != nil {
-				log.WithError(err).Error("unable to determine committer name - must be set explicitly")
+				ctx.Log().WithError(err).Error("unable to determine committer name - must be set explicitly")
 				return err
 			}
-			log.Info("dry-run: using stand-in for committer name")
+			ctx.Log().Info("dry-run: using stand-in for committer name")
 			name = "dry-run-user"
 		}
 		ctx.Author.Name = name
 	}
 	ctx.Author.Email = ctx.Config.Commit.Author.Email
 	if ctx.Author.Email == "" {
-		log.Debug("no commit email set, discovering from git config")
+		ctx.Log().Debug("no commit email set, discovering from git config")
 		email, err := u.GetGitUserEmail()
 		if err != nil {
 			if err := ctx.CheckDryRun(err); err != nil {
-				log.WithError(err).Error("unable to determine committer email - must be set explicitly")
+				ctx.Log().WithError(err).Error("unable to determine committer email - must be set explicitly")
 				return err
 			}
-			log.Info("dry-run: using stand-in for committer email")
+			ctx.Log().Info("dry-run: using stand-in for committer email")
 			email = "dry-run@commiter.dev"
 		}
 		ctx.Author.Email = email
 	}
 
 	// Chart
-	log.Debug("loading chart context")
+	ctx.Log().Debug("loading chart context")
 	ctx.Chart.Name = ctx.Config.Chart.Name
 	ctx.Chart.SubPath = ctx.Config.Chart.Path
 
 	// Repository
-	log.Debug("loading repository context")
+	ctx.Log().Debug("loading repository context")
 	repo, err := utils.ParseRepository(ctx.Config.Chart.Repo)
 	if err != nil {
 		return err
 	}
 	ctx.Repository = repo
 
+	// Version Source
+	ctx.Log().Debug("loading version source context")
+	if err := loadVersionSource(ctx); err != nil {
+		return err
+	}
+
 	// Release
-	log.Debug("loading release constraints")
+	ctx.Log().Debug("loading release constraints")
 	if err := loadReleaseConstraints(ctx); err != nil {
 		return err
 	}
+
+	ctx.Log().Debug("loading release tag pattern")
+	if err := loadTagPattern(ctx); err != nil {
+		return err
+	}
 	return nil
 }
 
 func loadUpdateStrategy(ctx *context.Context) error {
-	log.Debug("loading upgrade strategy context")
+	ctx.Log().Debug("loading upgrade strategy context")
 	s := ctx.Config.Release.Strategy
 	if s == "" {
-		log.WithField("strategy", "default").Info("no release strategy configured, using default")
+		ctx.Log().WithField("strategy", "default").Info("no release strategy configured, using default")
 		s = "default"
 	}
 	releaseStrat, err := strategies.UpdateStrategyFromString(s)
@@ -110,9 +121,9 @@ func loadUpdateStrategy(ctx *context.Context) error {
 }
 
 func loadPublishStrategy(ctx *context.Context) error {
-	log.Debug("loading publish strategy context")
+	ctx.Log().Debug("loading publish strategy context")
 	if ctx.Config.Publish.Commit == nil && ctx.Config.Publish.PR == nil {
-		log.WithField("default", strategies.PublishPullRequest).Debug("no publish config defined, using default publish strategy")
+		ctx.Log().WithField("default", strategies.PublishPullRequest).Debug("no publish config defined, using default publish strategy")
 		ctx.PublishStrategy = strategies.PublishPullRequest
 	} else if ctx.Config.Publish.Commit != nil {
 		ctx.PublishStrategy = strategies.PublishCommit
@@ -125,21 +136,21 @@ func loadPublishStrategy(ctx *context.Context) error {
 func loadTemplateStrings(ctx *context.Context) error {
 	if ctx.Config.Commit.Templates == nil {
 		ctx.Release.ChartCommitMsg = templates.DefaultUpdateCommitMessage
-		log.WithField("default", ctx.Release.ChartCommitMsg).Debug("using default commit message for updating Chart")
+		ctx.Log().WithField("default", ctx.Release.ChartCommitMsg).Debug("using default commit message for updating Chart")
 
 		ctx.Release.ExtrasCommitMsg = templates.DefaultExtrasCommitMessage
-		log.WithField("default", ctx.Release.ExtrasCommitMsg).Debug("using default commit message for updating extra files")
+		ctx.Log().WithField("default", ctx.Release.ExtrasCommitMsg).Debug("using default commit message for updating extra files")
 	} else {
 		ctx.Release.ChartCommitMsg = ctx.Config.Commit.Templates.Update
 		if ctx.Release.ChartCommitMsg == "" {
 			ctx.Release.ChartCommitMsg = templates.DefaultUpdateCommitMessage
-			log.WithField("default", ctx.Release.ChartCommitMsg).Debug("using default commit message for updating Chart")
+			ctx.Log().WithField("default", ctx.Release.ChartCommitMsg).Debug("using default commit message for updating Chart")
 		}
 
 		ctx.Release.ExtrasCommitMsg = ctx.Config.Commit.Templates.Extras
 		if ctx.Release.ExtrasCommitMsg == "" {
 			ctx.Release.ExtrasCommitMsg = templates.DefaultExtrasCommitMessage
-			log.WithField("default", ctx.Release.ExtrasCommitMsg).Debug("using default commit message for updating extra files")
+			ctx.Log().WithField("default", ctx.Release.ExtrasCommitMsg).Debug("using default commit message for updating extra files")
 		}
 	}
 
@@ -148,38 +159,38 @@ func loadTemplateStrings(ctx *context.Context) error {
 		ctx.Git.Ref = ctx.Config.Publish.Commit.Branch
 		if ctx.Git.Ref == "" {
 			ctx.Git.Ref = "master"
-			log.WithField("default", ctx.Git.Ref).Debug("no publish commit branch defined, using default")
+			ctx.Log().WithField("default", ctx.Git.Ref).Debug("no publish commit branch defined, using default")
 		}
 
 		ctx.Git.Base = ctx.Config.Publish.Commit.Base
 		if ctx.Git.Base == "" {
 			ctx.Git.Base = "master"
-			log.WithField("default", ctx.Git.Base).Debug("no publish commit base branch defined, using default")
+			ctx.Log().WithField("default", ctx.Git.Base).Debug("no publish commit base branch defined, using default")
 		}
 
 	case strategies.PublishPullRequest:
 		ctx.Git.Ref = ctx.Config.Publish.PR.BranchTemplate
 		if ctx.Git.Ref == "" {
 			ctx.Git.Ref = templates.DefaultBranchName
-			log.WithField("default", ctx.Git.Ref).Debug("no PR branch template defined, using default")
+			ctx.Log().WithField("default", ctx.Git.Ref).Debug("no PR branch template defined, using default")
 		}
 
 		ctx.Git.Base = ctx.Config.Publish.PR.Base
 		if ctx.Git.Base == "" {
 			ctx.Git.Base = "master"
-			log.WithField("default", ctx.Git.Base).Debug("no publish PR base branch defined, using default")
+			ctx.Log().WithField("default", ctx.Git.Base).Debug("no publish PR base branch defined, using default")
 		}
 
 		ctx.Release.PRTitle = ctx.Config.Publish.PR.TitleTemplate
 		if ctx.Release.PRTitle == "" {
 			ctx.Release.PRTitle = templates.DefaultPullRequestTitle
-			log.WithField("default", ctx.Release.PRTitle).Debug("using default pull request title")
+			ctx.Log().WithField("default", ctx.Release.PRTitle).Debug("using default pull request title")
 		}
 
 		ctx.Release.PRBody = ctx.Config.Publish.PR.BodyTemplate
 		if ctx.Release.PRBody == "" {
 			ctx.Release.PRBody = templates.DefaultPullRequestBody
-			log.WithField("default", ctx.Release.PRBody).Debug("using default pull request body")
+			ctx.Log().WithField("default", ctx.Release.PRBody).Debug("using default pull request body")
 		}
 
 	default:
@@ -195,7 +206,7 @@ func loadReleaseConstraints(ctx *context.Context) error {
 			if err := ctx.CheckDryRun(err); err != nil {
 				return err
 			}
-			log.Warnf("dry-run: failed to compile release match regex '%s', using stand-in", match)
+			ctx.Log().Warnf("dry-run: failed to compile release match regex '%s', using stand-in", match)
 			r = regexp.MustCompile("dry-run")
 		}
 		ctx.Release.Matches = append(ctx.Release.Matches, r)
@@ -207,10 +218,68 @@ func loadReleaseConstraints(ctx *context.Context) error {
 			if err := ctx.CheckDryRun(err); err != nil {
 				return err
 			}
-			log.Warnf("dry-run: failed to compile release ignore regex '%s', using stand-in", ignore)
+			ctx.Log().Warnf("dry-run: failed to compile release ignore regex '%s', using stand-in", ignore)
 			r = regexp.MustCompile("dry-run")
 		}
 		ctx.Release.Ignores = append(ctx.Release.Ignores, r)
 	}
 	return nil
 }
+
+func loadTagPattern(ctx *context.Context) error {
+	ctx.Release.TagNormalize = ctx.Config.Release.TagNormalize
+
+	pattern := ctx.Config.Release.TagPattern
+	if pattern == "" {
+		return nil
+	}
+
+	r, err := regexp.Compile(pattern)
+	if err == nil && r.SubexpIndex(v1.TagPatternGroup) == -1 {
+		err = fmt.Errorf("release tag pattern '%s' has no named capture group '%s'", pattern, v1.TagPatternGroup)
+	}
+	if err != nil {
+		if err := ctx.CheckDryRun(err); err != nil {
+			return err
+		}
+		ctx.Log().Warnf("dry-run: invalid release tag pattern '%s', using stand-in", pattern)
+		r = regexp.MustCompile("(?P<version>.*)")
+	}
+	ctx.Release.TagPattern = r
+	return nil
+}
+
+func loadVersionSource(ctx *context.Context) error {
+	ctx.Release.VersionSource = strategies.SourceGit
+	if vs := ctx.Config.Release.VersionSource; vs != nil && vs.Type != "" {
+		source, err := strategies.VersionSourceFromString(vs.Type)
+		if err != nil {
+			return err
+		}
+		ctx.Release.VersionSource = source
+	}
+
+	ctx.Release.PreviousSource = strategies.PreviousChart
+	if ps := ctx.Config.Release.PreviousSource; ps != "" {
+		source, err := strategies.PreviousSourceFromString(ps)
+		if err != nil {
+			return err
+		}
+		ctx.Release.PreviousSource = source
+	}
+
+	// The source repository is only needed for operations against the remote
+	// application repo. If it is not configured, assume the application lives
+	// alongside its chart.
+	repo := ctx.Config.Release.SourceRepo
+	if repo == "" {
+		repo = ctx.Config.Chart.Repo
+		ctx.Log().WithField("default", repo).Debug("no release source repo defined, using chart repo")
+	}
+	sourceRepo, err := utils.ParseRepository(repo)
+	if err != nil {
+		return err
+	}
+	ctx.SourceRepository = sourceRepo
+	return nil
+}
@@ -64,6 +64,7 @@ func TestStage_Run(t *testing.T) {
 	assert.Equal(t, "charts-test", context.Repository.Name)
 	assert.Equal(t, "edaniszewski", context.Repository.Owner)
 	assert.Equal(t, ctx.RepoGithub, context.Repository.Type)
+	assert.Equal(t, context.Repository, context.SourceRepository)
 
 	// App
 	assert.True(t, context.App.PreviousVersion.Equals(testutils.NewSemverP(t, "0.0.0")))
@@ -79,13 +80,14 @@ func TestStage_Run(t *testing.T) {
 
 	// Release
 	assert.Equal(t, "Bump {{ .Chart.Name }} Chart from {{ .Chart.PreviousVersion }} to {{ .Chart.NewVersion }}", context.Release.PRTitle)
-	assert.Equal(t, "Bumps the {{ .Chart.Name }} Helm Chart from {{ .Chart.PreviousVersion }} to {{ .Chart.NewVersion }}.\n\n{{ if .Files }}The following files have also been updated:\n{{ range .Files }}- {{ .Path }}\n{{ end }}{{ end }}\n---\n*This PR was generated with [chart-releaser](https://github.com/edaniszewski/chart-releaser)*\n", context.Release.PRBody)
+	assert.Equal(t, "Bumps the {{ .Chart.Name }} Helm Chart from {{ .Chart.PreviousVersion }} to {{ .Chart.NewVersion }}.\n\n{{ if .Files }}The following files have also been updated:\n{{ range .Files }}- {{ .Path }}\n{{ end }}{{ end }}{{ if .Git.Commits }}\n<details>\n<summary>Application changes since {{ .App.PreviousTag }}</summary>\n\n{{ range .Git.Commits }}- {{ .ShortSHA }} {{ .Subject }} ({{ .Author }})\n{{ end }}{{ if .Git.OmittedCommits }}- *...and {{ .Git.OmittedCommits }} more*\n{{ end }}\n</details>\n{{ end }}\n---\n*This PR was generated with [chart-releaser](https://github.com/edaniszewski/chart-releaser)*\n", context.Release.PRBody)
 	assert.Equal(t, "[{{ .Chart.Name }}] bump chart to {{ .Chart.NewVersion }} for new application release ({{ .App.NewVersion }})", context.Release.ChartCommitMsg)
 
 	// Other
 	assert.Equal(t, "", context.Token)
 	assert.Equal(t, strategies.PublishPullRequest, context.PublishStrategy)
 	assert.Equal(t, strategies.UpdateMinor, context.UpdateStrategy)
+	assert.Equal(t, strategies.SourceGit, context.Release.VersionSource)
 	assert.Equal(t, false, context.AllowDirty)
 	assert.Equal(t, false, context.DryRun)
 	assert.Equal(t, false, context.ShowDiff)
@@ -444,3 +446,156 @@ func TestLoadReleaseConstraintsIgnoreErrorDryRun(t *testing.T) {
 	assert.Equal(t, "dry-run", context.Release.Ignores[0].String())
 	assert.EqualError(t, context.Errors(), "\nErrors:\n • error parsing regexp: missing argument to repetition operator: `*`\n\n")
 }
+
+func TestLoadTagPattern(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				TagPattern:   `^api/(?P<version>.+)$`,
+				TagNormalize: true,
+			},
+		},
+	}
+
+	err := loadTagPattern(context)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `^api/(?P<version>.+)$`, context.Release.TagPattern.String())
+	assert.True(t, context.Release.TagNormalize)
+}
+
+func TestLoadTagPatternNotSet(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{},
+		},
+	}
+
+	err := loadTagPattern(context)
+	assert.NoError(t, err)
+
+	assert.Nil(t, context.Release.TagPattern)
+	assert.False(t, context.Release.TagNormalize)
+}
+
+func TestLoadTagPatternErrorNoGroup(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				TagPattern: `^api/(.+)$`,
+			},
+		},
+	}
+
+	err := loadTagPattern(context)
+	assert.EqualError(t, err, "release tag pattern '^api/(.+)$' has no named capture group 'version'")
+	assert.Nil(t, context.Release.TagPattern)
+}
+
+func TestLoadTagPatternErrorDryRun(t *testing.T) {
+	context := &ctx.Context{
+		DryRun: true,
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				TagPattern: "*",
+			},
+		},
+	}
+
+	err := loadTagPattern(context)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "(?P<version>.*)", context.Release.TagPattern.String())
+	assert.EqualError(t, context.Errors(), "\nErrors:\n • error parsing regexp: missing argument to repetition operator: `*`\n\n")
+}
+
+func TestLoadVersionSource(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Chart: &v1.ChartConfig{
+				Repo: "github.com/edaniszewski/charts-test",
+			},
+			Release: &v1.ReleaseConfig{
+				SourceRepo: "github.com/edaniszewski/app",
+				VersionSource: &v1.VersionSourceConfig{
+					Type: "env",
+				},
+				PreviousSource: "git",
+			},
+		},
+	}
+
+	err := loadVersionSource(context)
+	assert.NoError(t, err)
+
+	assert.Equal(t, strategies.SourceEnv, context.Release.VersionSource)
+	assert.Equal(t, strategies.PreviousGit, context.Release.PreviousSource)
+	assert.Equal(t, ctx.Repository{
+		Type:  ctx.RepoGithub,
+		Owner: "edaniszewski",
+		Name:  "app",
+	}, context.SourceRepository)
+}
+
+func TestLoadVersionSourceDefaults(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Chart: &v1.ChartConfig{
+				Repo: "github.com/edaniszewski/charts-test",
+			},
+			Release: &v1.ReleaseConfig{},
+		},
+	}
+
+	err := loadVersionSource(context)
+	assert.NoError(t, err)
+
+	assert.Equal(t, strategies.SourceGit, context.Release.VersionSource)
+	assert.Equal(t, strategies.PreviousChart, context.Release.PreviousSource)
+	assert.Equal(t, ctx.Repository{
+		Type:  ctx.RepoGithub,
+		Owner: "edaniszewski",
+		Name:  "charts-test",
+	}, context.SourceRepository)
+}
+
+func TestLoadVersionSourceErrorType(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				VersionSource: &v1.VersionSourceConfig{
+					Type: "invalid",
+				},
+			},
+		},
+	}
+
+	err := loadVersionSource(context)
+	assert.EqualError(t, err, "unsupported version source: invalid")
+}
+
+func TestLoadVersionSourceErrorPreviousSource(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				PreviousSource: "invalid",
+			},
+		},
+	}
+
+	err := loadVersionSource(context)
+	assert.EqualError(t, err, "unsupported previous version source: invalid")
+}
+
+func TestLoadVersionSourceErrorRepo(t *testing.T) {
+	context := &ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				SourceRepo: "a/b/c/d",
+			},
+		},
+	}
+
+	err := loadVersionSource(context)
+	assert.EqualError(t, err, "unexpected repository string format - should be in the form of REPO/OWNER/NAME")
+}
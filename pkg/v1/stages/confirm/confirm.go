@@ -0,0 +1,80 @@
+package confirm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+)
+
+// Errors for the confirm stage.
+var (
+	ErrNotInteractive = errors.New("confirmation required but not running interactively; use --yes to publish without confirmation")
+	ErrNotConfirmed   = errors.New("publishing changes was not confirmed")
+	ErrInputNotSet    = errors.New("input reader not set prior to running 'confirm' stage")
+)
+
+// Stage for the "confirm" step of the update pipeline.
+type Stage struct{}
+
+// Name of the stage.
+func (Stage) Name() string {
+	return "confirm"
+}
+
+// String describes what the stage does.
+func (Stage) String() string {
+	return "confirming changes before publishing"
+}
+
+// Requires gets the stages which must run before the stage. The changes are
+// shown by the diff stage, so they can be reviewed before being confirmed.
+func (Stage) Requires() []string {
+	return []string{"chart", "diff"}
+}
+
+// Run the operations defined for the stage.
+func (Stage) Run(ctx *context.Context) error {
+	if !ctx.Confirm {
+		ctx.Log().Info("confirmation not enabled - skipping")
+		return nil
+	}
+	if ctx.DryRun {
+		ctx.Log().Info("dry-run: no changes will be published - skipping confirmation")
+		return nil
+	}
+	if ctx.Publish.SkipReason != "" {
+		ctx.Log().Infof("%s: no changes will be published - skipping confirmation", ctx.Publish.SkipReason)
+		return nil
+	}
+	if ctx.AssumeYes {
+		ctx.Log().Info("--yes provided - publishing without confirmation")
+		return nil
+	}
+	if !ctx.Interactive {
+		return ErrNotInteractive
+	}
+	if ctx.In == nil {
+		return ErrInputNotSet
+	}
+
+	_, _ = fmt.Fprintf(
+		ctx.Out,
+		"Publish %s chart %s to %s/%s? [y/N]: ",
+		ctx.Chart.Name, ctx.Chart.NewVersion.String(), ctx.Repository.Owner, ctx.Repository.Name,
+	)
+
+	answer, err := bufio.NewReader(ctx.In).ReadString('\n')
+	if err != nil && answer == "" {
+		return ErrNotConfirmed
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		ctx.Log().Info("publishing changes confirmed")
+		return nil
+	default:
+		return ErrNotConfirmed
+	}
+}
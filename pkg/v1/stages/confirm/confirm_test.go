@@ -0,0 +1,161 @@
+package confirm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStage_Name(t *testing.T) {
+	assert.Equal(t, "confirm", Stage{}.Name())
+}
+
+func TestStage_String(t *testing.T) {
+	assert.Equal(t, "confirming changes before publishing", Stage{}.String())
+}
+
+func TestStage_Requires(t *testing.T) {
+	assert.Equal(t, []string{"chart", "diff"}, Stage{}.Requires())
+}
+
+func TestStage_RunNotEnabled(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		Out:         &buf,
+		In:          strings.NewReader(""),
+		Confirm:     false,
+		Interactive: true,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestStage_RunDryRun(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		Out:         &buf,
+		In:          strings.NewReader(""),
+		Confirm:     true,
+		Interactive: true,
+		DryRun:      true,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestStage_RunSkipped(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		Out:     &buf,
+		In:      strings.NewReader(""),
+		Confirm: true,
+		Publish: ctx.Publish{
+			SkipReason: "tag dev does not match release constraint '(\\.[0-9])+'",
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestStage_RunConfirmed(t *testing.T) {
+	for _, answer := range []string{"y\n", "Y\n", "yes\n", " YES \n", "y"} {
+		t.Run(answer, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			context := ctx.Context{
+				Out:         &buf,
+				In:          strings.NewReader(answer),
+				Confirm:     true,
+				Interactive: true,
+				Chart: ctx.Chart{
+					Name:       "test-chart",
+					NewVersion: testutils.NewSemver(t, "0.1.3"),
+				},
+				Repository: ctx.Repository{
+					Owner: "edaniszewski",
+					Name:  "charts",
+				},
+			}
+
+			err := Stage{}.Run(&context)
+			assert.NoError(t, err)
+			assert.Equal(t, "Publish test-chart chart 0.1.3 to edaniszewski/charts? [y/N]: ", buf.String())
+		})
+	}
+}
+
+func TestStage_RunNotConfirmed(t *testing.T) {
+	for _, answer := range []string{"n\n", "\n", "no\n", "yep\n", ""} {
+		t.Run(answer, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			context := ctx.Context{
+				Out:         &buf,
+				In:          strings.NewReader(answer),
+				Confirm:     true,
+				Interactive: true,
+				Chart: ctx.Chart{
+					Name:       "test-chart",
+					NewVersion: testutils.NewSemver(t, "0.1.3"),
+				},
+				Repository: ctx.Repository{
+					Owner: "edaniszewski",
+					Name:  "charts",
+				},
+			}
+
+			err := Stage{}.Run(&context)
+			assert.Equal(t, ErrNotConfirmed, err)
+		})
+	}
+}
+
+func TestStage_RunNotInteractive(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		Out:         &buf,
+		In:          strings.NewReader("y\n"),
+		Confirm:     true,
+		Interactive: false,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.Equal(t, ErrNotInteractive, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestStage_RunAssumeYes(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		Out:         &buf,
+		In:          strings.NewReader(""),
+		Confirm:     true,
+		Interactive: false,
+		AssumeYes:   true,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestStage_RunNoInput(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		Out:         &buf,
+		In:          nil,
+		Confirm:     true,
+		Interactive: true,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.Equal(t, ErrInputNotSet, err)
+}
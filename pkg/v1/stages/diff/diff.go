@@ -1,14 +1,53 @@
 package diff
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 
-	"github.com/apex/log"
 	"github.com/edaniszewski/chart-releaser/pkg/utils"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
-	"github.com/mgutz/ansi"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
 )
 
+// The formats in which the diff stage can show changes.
+const (
+	// FormatUnified shows changes as a unified diff, which can be applied to
+	// the chart repository with `git apply`.
+	FormatUnified = "unified"
+
+	// FormatColor shows changes as a unified diff with terminal colors. Colors
+	// are only used when writing to a terminal.
+	FormatColor = "color"
+
+	// FormatJSON shows changes as a JSON list of files, with the unified diff
+	// of each file.
+	FormatJSON = "json"
+)
+
+// Formats are all of the supported diff formats.
+var Formats = []string{
+	FormatUnified,
+	FormatColor,
+	FormatJSON,
+}
+
+// IsValidFormat checks whether the diff format is supported. An empty format
+// is valid and uses the default color format.
+func IsValidFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
 // Stage for the "diff" step of the update pipeline.
 type Stage struct{}
 
@@ -22,31 +61,70 @@ func (Stage) String() string {
 	return "displaying changes to chart files"
 }
 
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"chart"}
+}
+
 // Run the operations defined for the stage.
 func (Stage) Run(ctx *ctx.Context) error {
-	if !ctx.ShowDiff {
-		log.Info("diff stage not enabled - skipping")
+	if !ctx.ShowDiff && ctx.DiffOutput == "" {
+		ctx.Log().Info("diff stage not enabled - skipping")
 		return nil
 	}
 
-	_, _ = fmt.Fprint(ctx.Out, ansi.Color("+----start diff\n", "white"))
-
-	utils.PrintDiff(
-		ctx.Out,
-		"Chart.yaml",
-		string(ctx.Chart.File.PreviousContents),
-		string(ctx.Chart.File.NewContents),
-	)
-
-	for _, f := range ctx.Files {
-		utils.PrintDiff(
-			ctx.Out,
-			f.Path,
-			string(f.PreviousContents),
-			string(f.NewContents),
-		)
+	if ctx.ShowDiff {
+		if err := Write(ctx.Out, ctx); err != nil {
+			return err
+		}
 	}
 
-	_, _ = fmt.Fprint(ctx.Out, ansi.Color("+----end diff\n", "white"))
+	if ctx.DiffOutput != "" {
+		buf := bytes.Buffer{}
+		if err := Write(&buf, ctx); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(ctx.DiffOutput, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		ctx.Log().WithField("path", ctx.DiffOutput).Info("wrote diff to file")
+	}
 	return nil
 }
+
+// Write the changes to the chart files of the Context to the writer, in the
+// diff format configured for the Context.
+func Write(out io.Writer, ctx *ctx.Context) error {
+	files := report.Files(ctx, ctx.DiffContext)
+
+	switch ctx.DiffFormat {
+	case FormatJSON:
+		if files == nil {
+			files = []report.File{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(files)
+
+	case FormatUnified, FormatColor, "":
+		color := ctx.DiffFormat != FormatUnified && !ctx.NoColor && utils.IsTerminal(out)
+		for _, f := range files {
+			if !f.Changed {
+				ctx.Log().WithField("path", f.Path).Debug("no changes to file")
+				continue
+			}
+			diff := f.Diff
+			if color {
+				diff = utils.ColorDiff(diff)
+			}
+			if _, err := fmt.Fprint(out, diff); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported diff format '%s', should be one of: %v", ctx.DiffFormat, Formats)
+	}
+}
@@ -2,9 +2,13 @@ package diff
 
 import (
 	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,13 +32,15 @@ func TestStage_Run_NoDiff(t *testing.T) {
 func TestStage_Run(t *testing.T) {
 	buf := bytes.Buffer{}
 	context := ctx.Context{
-		ShowDiff: true,
-		Out:      &buf,
+		ShowDiff:    true,
+		DiffFormat:  FormatColor,
+		DiffContext: 3,
+		Out:         &buf,
 		Chart: ctx.Chart{
 			File: ctx.File{
 				Path:             "foo/bar/Chart.yaml",
-				PreviousContents: []byte("version: 1"),
-				NewContents:      []byte("version: 2"),
+				PreviousContents: []byte("name: bar\nversion: 1\n"),
+				NewContents:      []byte("name: bar\nversion: 2\n"),
 			},
 		},
 		Files: []ctx.File{
@@ -43,10 +49,148 @@ func TestStage_Run(t *testing.T) {
 				PreviousContents: []byte("abc"),
 				NewContents:      []byte("123"),
 			},
+			{
+				Path:             "unchanged.txt",
+				PreviousContents: []byte("abc\n"),
+				NewContents:      []byte("abc\n"),
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	// The output is not a terminal, so no colors are used.
+	assert.Equal(t, "--- a/foo/bar/Chart.yaml\n+++ b/foo/bar/Chart.yaml\n@@ -1,2 +1,2 @@\n name: bar\n-version: 1\n+version: 2\n--- a/testfile.txt\n+++ b/testfile.txt\n@@ -1 +1 @@\n-abc\n\\ No newline at end of file\n+123\n\\ No newline at end of file\n", buf.String())
+}
+
+func TestStage_RunUnified(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		ShowDiff:    true,
+		DiffFormat:  FormatUnified,
+		DiffContext: 0,
+		Out:         &buf,
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "foo/bar/Chart.yaml",
+				PreviousContents: []byte("name: bar\nversion: 1\n"),
+				NewContents:      []byte("name: bar\nversion: 2\n"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "testfile.txt",
+				PreviousContents: []byte("abc"),
+				NewContents:      []byte("123"),
+			},
+			{
+				Path:             "unchanged.txt",
+				PreviousContents: []byte("abc\n"),
+				NewContents:      []byte("abc\n"),
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "--- a/foo/bar/Chart.yaml\n+++ b/foo/bar/Chart.yaml\n@@ -2 +2 @@\n-version: 1\n+version: 2\n--- a/testfile.txt\n+++ b/testfile.txt\n@@ -1 +1 @@\n-abc\n\\ No newline at end of file\n+123\n\\ No newline at end of file\n", buf.String())
+}
+
+func TestStage_RunJSON(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		ShowDiff:    true,
+		DiffFormat:  FormatJSON,
+		DiffContext: 3,
+		Out:         &buf,
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "foo/bar/Chart.yaml",
+				PreviousContents: []byte("name: bar\nversion: 1\n"),
+				NewContents:      []byte("name: bar\nversion: 2\n"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "testfile.txt",
+				PreviousContents: []byte("abc"),
+				NewContents:      []byte("123"),
+			},
+			{
+				Path:             "unchanged.txt",
+				PreviousContents: []byte("abc\n"),
+				NewContents:      []byte("abc\n"),
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+
+	var files []report.File
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &files))
+	assert.Len(t, files, 3)
+	assert.Equal(t, "foo/bar/Chart.yaml", files[0].Path)
+	assert.True(t, files[0].Changed)
+	assert.Equal(t, "unchanged.txt", files[2].Path)
+	assert.False(t, files[2].Changed)
+	assert.Empty(t, files[2].Diff)
+}
+
+func TestStage_RunDiffOutput(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		ShowDiff:    false,
+		DiffFormat:  FormatColor,
+		DiffContext: 3,
+		Out:         &buf,
+		DiffOutput:  filepath.Join(t.TempDir(), "chart.patch"),
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "foo/bar/Chart.yaml",
+				PreviousContents: []byte("name: bar\nversion: 1\n"),
+				NewContents:      []byte("name: bar\nversion: 2\n"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "testfile.txt",
+				PreviousContents: []byte("abc"),
+				NewContents:      []byte("123"),
+			},
+			{
+				Path:             "unchanged.txt",
+				PreviousContents: []byte("abc\n"),
+				NewContents:      []byte("abc\n"),
+			},
 		},
 	}
 
 	err := Stage{}.Run(&context)
 	assert.NoError(t, err)
-	assert.Equal(t, "\x1b[0;37m+----start diff\n\x1b[0m\x1b[0;34m===\x1b[0m\nShowing changes to \x1b[0;33mChart.yaml\x1b[0m\n\n\x1b[0;31m- version: 1\x1b[0m\n\x1b[0;32m+ version: 2\x1b[0m\n\x1b[0;34m===\x1b[0m\nShowing changes to \x1b[0;33mtestfile.txt\x1b[0m\n\n\x1b[0;31m- abc\x1b[0m\n\x1b[0;32m+ 123\x1b[0m\n\x1b[0;37m+----end diff\n\x1b[0m", buf.String())
+	assert.Empty(t, buf.String())
+
+	contents, err := ioutil.ReadFile(context.DiffOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, "--- a/foo/bar/Chart.yaml\n+++ b/foo/bar/Chart.yaml\n@@ -1,2 +1,2 @@\n name: bar\n-version: 1\n+version: 2\n--- a/testfile.txt\n+++ b/testfile.txt\n@@ -1 +1 @@\n-abc\n\\ No newline at end of file\n+123\n\\ No newline at end of file\n", string(contents))
+}
+
+func TestStage_RunUnsupportedFormat(t *testing.T) {
+	buf := bytes.Buffer{}
+	context := ctx.Context{
+		ShowDiff:   true,
+		DiffFormat: "html",
+		Out:        &buf,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.EqualError(t, err, "unsupported diff format 'html', should be one of: [unified color json]")
+}
+
+func TestIsValidFormat(t *testing.T) {
+	assert.True(t, IsValidFormat(""))
+	assert.True(t, IsValidFormat(FormatUnified))
+	assert.True(t, IsValidFormat(FormatColor))
+	assert.True(t, IsValidFormat(FormatJSON))
+	assert.False(t, IsValidFormat("html"))
 }
@@ -29,12 +29,23 @@ func (Stage) String() string {
 	return "loading environment variables"
 }
 
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"config"}
+}
+
 // Run the operations defined for the stage.
 func (Stage) Run(ctx *context.Context) error {
 	if ctx.Repository.Type == "" {
 		return ErrRepoTypeNotSet
 	}
 
+	// Files are read from the local checkout, so no token is needed.
+	if ctx.LocalChartRepo != "" {
+		ctx.Log().WithField("path", ctx.LocalChartRepo).Info("using local chart repo - skipping token lookup")
+		return nil
+	}
+
 	switch ctx.Repository.Type {
 	case context.RepoGithub:
 		val, found := os.LookupEnv(env.GithubToken)
@@ -43,12 +54,12 @@ func (Stage) Run(ctx *context.Context) error {
 				return err
 			}
 			val = ""
-			log.Warn("github token not detected - using no token for dry-run")
+			ctx.Log().Warn("github token not detected - using no token for dry-run")
 		}
 		ctx.Token = val
 
 	default:
-		log.WithFields(log.Fields{
+		ctx.Log().WithFields(log.Fields{
 			"type": ctx.Repository.Type,
 		}).Error("unsupported repository type specified")
 		return ErrUnsupportedRepoType
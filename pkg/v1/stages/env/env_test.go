@@ -39,6 +39,34 @@ func TestStage_Run_RepoGithub(t *testing.T) {
 	assert.Equal(t, "abc123", context.Token)
 }
 
+func TestStage_Run_RepoGithubIgnoresAPIURL(t *testing.T) {
+	// The token must not be sent to a host named by the environment.
+	for k, v := range map[string]string{
+		env.GithubToken:  "abc123",
+		"GITHUB_API_URL": "https://github.example.com/api/v3",
+	} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+		defer func(k string) {
+			if err := os.Unsetenv(k); err != nil {
+				t.Fatal(err)
+			}
+		}(k)
+	}
+
+	context := ctx.Context{
+		Repository: ctx.Repository{
+			Type: ctx.RepoGithub,
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", context.Token)
+	assert.Empty(t, context.APIURL)
+}
+
 func TestStage_Run_NoRepoType(t *testing.T) {
 	context := ctx.Context{
 		Repository: ctx.Repository{
@@ -95,3 +123,18 @@ func TestStage_Run_TokenNotSetDryRun(t *testing.T) {
 	assert.Equal(t, "", context.Token)
 	assert.EqualError(t, context.Errors(), "\nErrors:\n • GITHUB_TOKEN environment variable not set\n\n")
 }
+
+func TestStage_Run_LocalChartRepo(t *testing.T) {
+	context := ctx.Context{
+		Repository: ctx.Repository{
+			Type: ctx.RepoGithub,
+		},
+		DryRun:         true,
+		LocalChartRepo: "./charts",
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "", context.Token)
+	assert.NoError(t, context.Errors())
+}
@@ -4,11 +4,11 @@ import (
 	"bytes"
 	"regexp"
 	"strings"
-	"text/template"
 
 	"github.com/apex/log"
 	"github.com/edaniszewski/chart-releaser/pkg/client"
 	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
 )
 
 // Stage for the "extras" step of the update pipeline.
@@ -24,6 +24,11 @@ func (Stage) String() string {
 	return "updating additional chart files"
 }
 
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"client", "chart"}
+}
+
 // Run the operations defined for the stage.
 func (Stage) Run(ctx *context.Context) error {
 
@@ -37,7 +42,7 @@ func (Stage) Run(ctx *context.Context) error {
 			RepoName:  ctx.Repository.Name,
 			RepoOwner: ctx.Repository.Owner,
 		}
-		log.WithFields(log.Fields{
+		ctx.Log().WithFields(log.Fields{
 			"path":      extra.Path,
 			"repoName":  ctx.Repository.Name,
 			"repoOwner": ctx.Repository.Owner,
@@ -48,7 +53,7 @@ func (Stage) Run(ctx *context.Context) error {
 			if err := ctx.CheckDryRun(err); err != nil {
 				return err
 			}
-			log.WithField("path", extra.Path).Warn("failed to get contents for file -- skipping")
+			ctx.Log().WithField("path", extra.Path).Warn("failed to get contents for file -- skipping")
 			continue
 		}
 		extraFile.PreviousContents = []byte(contents)
@@ -59,7 +64,7 @@ func (Stage) Run(ctx *context.Context) error {
 				if err := ctx.CheckDryRun(err); err != nil {
 					return err
 				}
-				log.WithField("re", re).Warn("failed to compile search regex")
+				ctx.Log().WithField("re", re).Warn("failed to compile search regex")
 				continue
 			}
 
@@ -73,12 +78,12 @@ func (Stage) Run(ctx *context.Context) error {
 
 			// Parse the replace string as a template. If it is not a template, it will
 			// remain unchanged.
-			t, err := template.New("").Parse(update.Replace)
+			t, err := utils.ParseTemplate(ctx, "", update.Replace)
 			if err != nil {
 				if err := ctx.CheckDryRun(err); err != nil {
 					return err
 				}
-				log.WithField("template", update.Replace).Warn("failed to parse string as template")
+				ctx.Log().WithField("template", update.Replace).Warn("failed to parse string as template")
 				continue
 			}
 
@@ -88,7 +93,7 @@ func (Stage) Run(ctx *context.Context) error {
 				if err := ctx.CheckDryRun(err); err != nil {
 					return err
 				}
-				log.WithField("template", update.Replace).Warn("failed to execute template")
+				ctx.Log().WithField("template", update.Replace).Warn("failed to execute template")
 				continue
 			}
 			contents = strings.Join(parts, buf.String())
@@ -97,7 +102,7 @@ func (Stage) Run(ctx *context.Context) error {
 		extraFile.NewContents = []byte(contents)
 
 		if !extraFile.HasChanges() {
-			log.WithFields(log.Fields{
+			ctx.Log().WithFields(log.Fields{
 				"contents":  string(extraFile.NewContents),
 				"path":      extra.Path,
 				"repoName":  ctx.Repository.Name,
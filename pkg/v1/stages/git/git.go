@@ -1,10 +1,27 @@
 package git
 
 import (
+	"errors"
+	"regexp"
+	"strconv"
+
 	"github.com/apex/log"
-	version "github.com/edaniszewski/chart-releaser/pkg/semver"
-	"github.com/edaniszewski/chart-releaser/pkg/utils"
-	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/strategies"
+	u "github.com/edaniszewski/chart-releaser/pkg/utils"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
+)
+
+// DefaultChangelogLimit is the maximum number of commits collected for a
+// release if no limit is configured.
+const DefaultChangelogLimit = 20
+
+var prPattern = regexp.MustCompile(`(?:\(#|^Merge pull request #)(\d+)`)
+
+// Errors for the git stage.
+var (
+	ErrNoPreviousTag = errors.New("no previous release tag found in git history")
 )
 
 // Stage for the "git" step of the update pipeline.
@@ -20,30 +37,184 @@ func (Stage) String() string {
 	return "parsing git information"
 }
 
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"config"}
+}
+
+// RequiresFor gets the stages which must run before the stage with the given
+// configuration. Getting the version from a GitHub release uses the client.
+func (Stage) RequiresFor(cfg *v1.Config) []string {
+	if cfg.Release != nil && cfg.Release.VersionSource != nil {
+		if source, err := strategies.VersionSourceFromString(cfg.Release.VersionSource.Type); err == nil && source == strategies.SourceGitHub {
+			return []string{"client"}
+		}
+	}
+	return nil
+}
+
 // Run the operations defined for the stage.
-func (Stage) Run(ctx *ctx.Context) error {
-	log.Debug("looking up git tag")
-	tag, err := utils.GetTag()
+func (Stage) Run(ctx *context.Context) error {
+	ctx.Log().WithField("source", ctx.Release.VersionSource).Debug("looking up release tag")
+	tag, source, err := getTag(ctx)
+	pattern := ctx.Release.TagPattern
 	if err != nil {
+		ctx.Log().WithFields(log.Fields{
+			"error":  err,
+			"source": source,
+		}).Debug("failed to get release tag")
 		if err := ctx.CheckDryRun(err); err != nil {
 			return err
 		}
 		tag = "0.0.0"
-		log.WithField("tag", tag).Info("using fake tag for dry-run")
+		source = "dry-run placeholder"
+		// The placeholder is not a real release tag, so it is not expected
+		// to match the release tag pattern.
+		pattern = nil
+		ctx.Log().WithField("tag", tag).Info("using fake tag for dry-run")
 	}
-	log.WithField("tag", tag).Debug("got git tag")
+	ctx.Log().WithFields(log.Fields{
+		"tag":    tag,
+		"source": source,
+	}).Info("got release tag")
 	ctx.Git.Tag = tag
+	ctx.Git.TagSource = source
 
 	// Parse the tag into a Semver. If this fails, we can't continue
 	// as we'll be unable to manipulate the chart/app versions correctly.
-	v, err := version.Load(tag)
+	// The tag itself is preserved in the context so it remains available
+	// to templates, even if the version is extracted from part of it.
+	v, err := utils.VersionFromTag(tag, pattern, ctx.Release.TagNormalize)
 	if err != nil {
 		return err
 	}
+	ctx.Log().WithFields(log.Fields{
+		"tag":     tag,
+		"version": v.String(),
+	}).Debug("parsed app version from tag")
 
 	// The tag version loaded here is the new version for the application
 	// being released.
 	ctx.App.NewVersion = v
 
+	if ctx.NoGitHistory {
+		ctx.Log().Debug("no local git history - skipping previous release tag and commits")
+		if ctx.Release.PreviousSource == strategies.PreviousGit {
+			ctx.Log().Warn("previous version can not be read from git history - using the chart appVersion")
+		}
+		return nil
+	}
+
+	if err := loadPreviousTag(ctx); err != nil {
+		return err
+	}
+
+	loadCommits(ctx)
+	return nil
+}
+
+// loadPreviousTag finds the tag for the previous release of the application
+// from the git history. If the release is configured to use git as the source
+// for the previous version, this also sets the previous app version.
+func loadPreviousTag(ctx *context.Context) error {
+	fromGit := ctx.Release.PreviousSource == strategies.PreviousGit
+
+	ctx.Log().Debug("looking up previous release tag")
+	tags, err := u.GetTags()
+	if err != nil {
+		ctx.Log().WithError(err).Debug("failed to list git tags")
+		if fromGit {
+			return ctx.CheckDryRun(err)
+		}
+		return nil
+	}
+
+	tag, v, found := utils.PreviousTag(tags, ctx.App.NewVersion, ctx.Release.TagPattern, ctx.Release.TagNormalize)
+	if !found {
+		ctx.Log().WithField("version", ctx.App.NewVersion.String()).Debug("no previous release tag found in git history")
+		if fromGit {
+			return ctx.CheckDryRun(ErrNoPreviousTag)
+		}
+		return nil
+	}
+	ctx.Log().WithFields(log.Fields{
+		"tag":     tag,
+		"version": v.String(),
+	}).Debug("got previous release tag")
+
+	ctx.App.PreviousTag = tag
+	if fromGit {
+		ctx.App.PreviousVersion = v
+	}
 	return nil
 }
+
+// loadCommits collects the commits made to the application between the
+// previous release tag and the new release. Since this is only informational,
+// failure to get the commits is not considered an error.
+func loadCommits(ctx *context.Context) {
+	limit := DefaultChangelogLimit
+	if cl := ctx.Config.Release.Changelog; cl != nil {
+		if cl.Disabled {
+			ctx.Log().Debug("changelog disabled - skipping commit collection")
+			return
+		}
+		if cl.Limit != 0 {
+			limit = cl.Limit
+		}
+	}
+
+	if ctx.App.PreviousTag == "" {
+		ctx.Log().Debug("no previous release tag - skipping commit collection")
+		return
+	}
+
+	// The release tag may not exist locally, e.g. if the version was provided
+	// by a flag ahead of tagging, in which case the current HEAD is used.
+	head := ctx.Git.Tag
+	if !u.RefExists(head) {
+		ctx.Log().WithField("tag", head).Debug("release tag not found in repository, using HEAD")
+		head = "HEAD"
+	}
+
+	commits, err := u.GetCommits(ctx.App.PreviousTag, head)
+	if err != nil {
+		ctx.Log().WithError(err).Warn("failed to collect commits for the release")
+		return
+	}
+	ctx.Log().WithFields(log.Fields{
+		"from":    ctx.App.PreviousTag,
+		"to":      head,
+		"commits": len(commits),
+	}).Debug("collected commits for the release")
+
+	if len(commits) > limit {
+		ctx.Git.OmittedCommits = len(commits) - limit
+		commits = commits[:limit]
+	}
+	for _, c := range commits {
+		ctx.Git.Commits = append(ctx.Git.Commits, context.Commit{
+			SHA:      c.SHA,
+			ShortSHA: c.ShortSHA,
+			Author:   c.Author,
+			Subject:  c.Subject,
+			PR:       parsePR(c.Subject),
+		})
+	}
+}
+
+// parsePR gets the number of the pull request a commit was merged from based
+// on its subject. Both squash merges ("Add feature (#12)") and merge commits
+// ("Merge pull request #12 from ...") are supported. If no pull request number
+// is found, 0 is returned.
+func parsePR(subject string) int {
+	match := prPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return 0
+	}
+	pr, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	return pr
+}
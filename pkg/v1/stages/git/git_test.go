@@ -1,8 +1,21 @@
 package git
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/ci"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/strategies"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,6 +27,564 @@ func TestStage_String(t *testing.T) {
 	assert.Equal(t, "parsing git information", Stage{}.String())
 }
 
-func TestStage_Run(t *testing.T) {
-	// todo
+func TestStage_Requires(t *testing.T) {
+	assert.Equal(t, []string{"config"}, Stage{}.Requires())
+}
+
+func TestStage_RequiresFor(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		cfg      *v1.Config
+		expected []string
+	}{
+		{name: "no release", cfg: &v1.Config{}},
+		{name: "no version source", cfg: &v1.Config{Release: &v1.ReleaseConfig{}}},
+		{
+			name: "git",
+			cfg:  &v1.Config{Release: &v1.ReleaseConfig{VersionSource: &v1.VersionSourceConfig{Type: "git"}}},
+		},
+		{
+			name:     "github",
+			cfg:      &v1.Config{Release: &v1.ReleaseConfig{VersionSource: &v1.VersionSourceConfig{Type: "GitHub"}}},
+			expected: []string{"client"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Stage{}.RequiresFor(test.cfg))
+		})
+	}
+}
+
+func TestStage_Run_AppVersionFlag(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.AppVersion = "v1.2.3"
+	context.Release.VersionSource = strategies.SourceFile
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.3", context.Git.Tag)
+	assert.Equal(t, "--app-version flag", context.Git.TagSource)
+	assert.Equal(t, "v1.2.3", context.App.NewVersion.String())
+}
+
+func TestStage_Run_CIEnvironment(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.CI = ci.Environment{Provider: ci.GitLab, Tag: "v2.1.0"}
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2.1.0", context.Git.Tag)
+	assert.Equal(t, "gitlab CI environment", context.Git.TagSource)
+	assert.Equal(t, "v2.1.0", context.App.NewVersion.String())
+}
+
+func TestStage_Run_CIEnvironmentConfiguredSource(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.CI = ci.Environment{Provider: ci.GitHubActions, Tag: "v2.0.1"}
+	context.Release.VersionSource = strategies.SourceEnv
+	setenv(t, "GITHUB_REF", "refs/tags/v3.0.0")
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v3.0.0", context.Git.Tag)
+}
+
+func TestStage_Run_TagPattern(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.AppVersion = "release-2024.3"
+	context.Release.TagPattern = regexp.MustCompile(`^release-(?P<version>.+)$`)
+	context.Release.TagNormalize = true
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "release-2024.3", context.Git.Tag)
+	assert.Equal(t, "2024.3.0", context.App.NewVersion.String())
+}
+
+func TestStage_Run_TagPatternError(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.AppVersion = "web/v1.0.0"
+	context.Release.TagPattern = regexp.MustCompile(`^api/(?P<version>.+)$`)
+
+	err := Stage{}.Run(context)
+	assert.EqualError(t, err, "tag 'web/v1.0.0' does not match release tag pattern '^api/(?P<version>.+)$'")
+}
+
+func TestStage_Run_ErrorFlagNotSet(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.Release.VersionSource = strategies.SourceFlag
+
+	err := Stage{}.Run(context)
+	assert.Equal(t, ErrNoAppVersionFlag, err)
+}
+
+func TestStage_Run_ErrorFlagNotSetDryRun(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.DryRun = true
+	context.Release.VersionSource = strategies.SourceFlag
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.0", context.Git.Tag)
+	assert.Equal(t, "dry-run placeholder", context.Git.TagSource)
+	assert.Equal(t, "0.0.0", context.App.NewVersion.String())
+}
+
+func TestStage_Run_ErrorFlagNotSetDryRunTagPattern(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.DryRun = true
+	context.Release.VersionSource = strategies.SourceFlag
+	context.Release.TagPattern = regexp.MustCompile(`^app-(?P<version>.+)$`)
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.0", context.Git.Tag)
+	assert.Equal(t, "0.0.0", context.App.NewVersion.String())
+}
+
+func TestStage_Run_PreviousFromGit(t *testing.T) {
+	newTestRepo(t, "v1.0.0", "v1.1.0", "v1.2.0", "other")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.AppVersion = "v1.2.0"
+	context.Release.PreviousSource = strategies.PreviousGit
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.0", context.App.NewVersion.String())
+	assert.Equal(t, "v1.1.0", context.App.PreviousTag)
+	assert.Equal(t, "v1.1.0", context.App.PreviousVersion.String())
+}
+
+func TestStage_Run_PreviousFromChart(t *testing.T) {
+	newTestRepo(t, "v1.0.0", "v1.1.0")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.AppVersion = "v1.2.0"
+	context.Release.PreviousSource = strategies.PreviousChart
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.0", context.App.PreviousTag)
+	assert.Equal(t, "0.0.0", context.App.PreviousVersion.String())
+}
+
+func TestStage_Run_PreviousFromGitNotFound(t *testing.T) {
+	newTestRepo(t, "v1.2.0")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.AppVersion = "v1.2.0"
+	context.Release.PreviousSource = strategies.PreviousGit
+
+	err := Stage{}.Run(context)
+	assert.Equal(t, ErrNoPreviousTag, err)
+}
+
+func TestStage_Run_NoGitHistory(t *testing.T) {
+	newTestRepo(t, "v1.0.0", "v1.1.0")
+	commit(t, "Add a feature (#12)")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.AppVersion = "v1.2.0"
+	context.NoGitHistory = true
+	context.Release.PreviousSource = strategies.PreviousGit
+
+	// The git history of the working directory is not read, so there is
+	// no previous tag, even though one exists.
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.0", context.App.NewVersion.String())
+	assert.Equal(t, "", context.App.PreviousTag)
+	assert.Empty(t, context.Git.Commits)
+}
+
+func TestStage_Run_Commits(t *testing.T) {
+	newTestRepo(t, "v1.0.0")
+	commit(t, "Add a feature (#12)")
+	commit(t, "Merge pull request #13 from edaniszewski/fix")
+	commit(t, "Update docs")
+	git(t, "tag", "v1.1.0")
+	commit(t, "Unreleased change")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.AppVersion = "v1.1.0"
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", context.App.PreviousTag)
+	assert.Equal(t, 0, context.Git.OmittedCommits)
+	assert.Len(t, context.Git.Commits, 3)
+
+	assert.Equal(t, "Update docs", context.Git.Commits[0].Subject)
+	assert.Equal(t, "test", context.Git.Commits[0].Author)
+	assert.Equal(t, 0, context.Git.Commits[0].PR)
+	assert.Len(t, context.Git.Commits[0].SHA, 40)
+	assert.True(t, strings.HasPrefix(context.Git.Commits[0].SHA, context.Git.Commits[0].ShortSHA))
+	assert.Equal(t, "Merge pull request #13 from edaniszewski/fix", context.Git.Commits[1].Subject)
+	assert.Equal(t, 13, context.Git.Commits[1].PR)
+	assert.Equal(t, "Add a feature (#12)", context.Git.Commits[2].Subject)
+	assert.Equal(t, 12, context.Git.Commits[2].PR)
+}
+
+func TestStage_Run_CommitsLimit(t *testing.T) {
+	newTestRepo(t, "v1.0.0")
+	commit(t, "first")
+	commit(t, "second")
+	commit(t, "third")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{
+			Changelog: &v1.ChangelogConfig{
+				Limit: 2,
+			},
+		},
+	})
+	// The tag does not exist in the repo, so commits are collected up to HEAD.
+	context.AppVersion = "v1.1.0"
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, context.Git.OmittedCommits)
+	assert.Len(t, context.Git.Commits, 2)
+	assert.Equal(t, "third", context.Git.Commits[0].Subject)
+	assert.Equal(t, "second", context.Git.Commits[1].Subject)
+}
+
+func TestStage_Run_CommitsDisabled(t *testing.T) {
+	newTestRepo(t, "v1.0.0")
+	commit(t, "first")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{
+			Changelog: &v1.ChangelogConfig{
+				Disabled: true,
+			},
+		},
+	})
+	context.AppVersion = "v1.1.0"
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", context.App.PreviousTag)
+	assert.Len(t, context.Git.Commits, 0)
+}
+
+func TestParsePR(t *testing.T) {
+	tests := []struct {
+		subject  string
+		expected int
+	}{
+		{subject: "Add a feature (#12)", expected: 12},
+		{subject: "Merge pull request #345 from org/branch", expected: 345},
+		{subject: "Fix issue #7", expected: 0},
+		{subject: "Update docs", expected: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.subject, func(t *testing.T) {
+			assert.Equal(t, test.expected, parsePR(test.subject))
+		})
+	}
+}
+
+func TestGetTag_Env(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+		source   string
+	}{
+		{
+			name:     "github tag ref",
+			env:      map[string]string{"GITHUB_REF": "refs/tags/v1.0.0"},
+			expected: "v1.0.0",
+			source:   "env (GITHUB_REF)",
+		},
+		{
+			name: "github branch ref",
+			env: map[string]string{
+				"GITHUB_REF":    "refs/heads/master",
+				"CI_COMMIT_TAG": "v1.0.1",
+			},
+			expected: "v1.0.1",
+			source:   "env (CI_COMMIT_TAG)",
+		},
+		{
+			name:     "tag name",
+			env:      map[string]string{"TAG_NAME": "1.0.2"},
+			expected: "1.0.2",
+			source:   "env (TAG_NAME)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range DefaultVersionEnv {
+				setenv(t, name, "")
+			}
+			for k, v := range test.env {
+				setenv(t, k, v)
+			}
+
+			context := ctx.New(&v1.Config{
+				Release: &v1.ReleaseConfig{},
+			})
+			context.Release.VersionSource = strategies.SourceEnv
+
+			tag, source, err := getTag(context)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, tag)
+			assert.Equal(t, test.source, source)
+		})
+	}
+}
+
+func TestGetTag_EnvCustom(t *testing.T) {
+	setenv(t, "GITHUB_REF", "refs/tags/v1.0.0")
+	setenv(t, "CR_TEST_VERSION", "2.0.0")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{
+			VersionSource: &v1.VersionSourceConfig{
+				Env: []string{"CR_TEST_VERSION"},
+			},
+		},
+	})
+	context.Release.VersionSource = strategies.SourceEnv
+
+	tag, source, err := getTag(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", tag)
+	assert.Equal(t, "env (CR_TEST_VERSION)", source)
+}
+
+func TestGetTag_EnvError(t *testing.T) {
+	setenv(t, "CR_TEST_VERSION", "")
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{
+			VersionSource: &v1.VersionSourceConfig{
+				Env: []string{"CR_TEST_VERSION"},
+			},
+		},
+	})
+	context.Release.VersionSource = strategies.SourceEnv
+
+	_, _, err := getTag(context)
+	assert.Equal(t, ErrNoTagInEnv, err)
+}
+
+func TestGetTag_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chart-releaser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "VERSION")
+	if err := ioutil.WriteFile(path, []byte("1.4.2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{
+			VersionSource: &v1.VersionSourceConfig{
+				File: path,
+			},
+		},
+	})
+	context.Release.VersionSource = strategies.SourceFile
+
+	tag, source, err := getTag(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.2", tag)
+	assert.Equal(t, "file ("+path+")", source)
+}
+
+func TestGetTag_FileError(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{
+			VersionSource: &v1.VersionSourceConfig{
+				File: "testdata/does-not-exist",
+			},
+		},
+	})
+	context.Release.VersionSource = strategies.SourceFile
+
+	_, source, err := getTag(context)
+	assert.EqualError(t, err, "open testdata/does-not-exist: no such file or directory")
+	assert.Equal(t, "file (testdata/does-not-exist)", source)
+}
+
+func TestGetTag_Command(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{
+			VersionSource: &v1.VersionSourceConfig{
+				Command: "echo v3.1.0",
+			},
+		},
+	})
+	context.Release.VersionSource = strategies.SourceCommand
+
+	tag, source, err := getTag(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v3.1.0", tag)
+	assert.Equal(t, "command (echo v3.1.0)", source)
+}
+
+func TestGetTag_CommandEmptyOutput(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{
+			VersionSource: &v1.VersionSourceConfig{
+				Command: "true",
+			},
+		},
+	})
+	context.Release.VersionSource = strategies.SourceCommand
+
+	_, _, err := getTag(context)
+	assert.Equal(t, ErrEmptyVersion, err)
+}
+
+func TestGetTag_CommandNotSet(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.Release.VersionSource = strategies.SourceCommand
+
+	_, _, err := getTag(context)
+	assert.Equal(t, ErrNoVersionCommand, err)
+}
+
+func TestGetTag_GitHub(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.Release.VersionSource = strategies.SourceGitHub
+	context.SourceRepository = ctx.Repository{
+		Type:  ctx.RepoGithub,
+		Owner: "edaniszewski",
+		Name:  "app",
+	}
+	context.Client = &testutils.FakeClient{
+		ReleaseData: &client.Release{
+			Tag: "v0.5.0",
+		},
+	}
+
+	tag, source, err := getTag(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.5.0", tag)
+	assert.Equal(t, "github release (edaniszewski/app)", source)
+}
+
+func TestGetTag_GitHubError(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.Release.VersionSource = strategies.SourceGitHub
+	context.Client = &testutils.FakeClient{
+		GetReleaseError: []error{errors.New("test error")},
+	}
+
+	_, _, err := getTag(context)
+	assert.EqualError(t, err, "test error")
+}
+
+func TestGetTag_GitHubNoClient(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.Release.VersionSource = strategies.SourceGitHub
+
+	_, _, err := getTag(context)
+	assert.Equal(t, ErrClientNotSet, err)
+}
+
+func TestGetTag_UnsupportedSource(t *testing.T) {
+	context := ctx.New(&v1.Config{
+		Release: &v1.ReleaseConfig{},
+	})
+	context.Release.VersionSource = strategies.VersionSource("unknown")
+
+	_, _, err := getTag(context)
+	assert.Equal(t, ErrUnsupportedSource, err)
+}
+
+// newTestRepo creates a new git repository with a single commit and the given
+// tags, and changes the working directory to it for the duration of a test.
+func newTestRepo(t *testing.T, tags ...string) {
+	dir, err := ioutil.TempDir("", "chart-releaser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(cwd)
+		_ = os.RemoveAll(dir)
+	})
+
+	git(t, "init", "-q")
+	commit(t, "initial commit")
+	for _, tag := range tags {
+		git(t, "tag", tag)
+	}
+}
+
+// commit creates an empty commit in the git repository in the current
+// working directory.
+func commit(t *testing.T, msg string) {
+	git(t, "-c", "user.name=test", "-c", "user.email=test@test.dev", "commit", "-q", "--allow-empty", "-m", msg)
+}
+
+// git runs a git command, failing the test if it errors.
+func git(t *testing.T, args ...string) {
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+// setenv sets an environment variable for the duration of a test.
+func setenv(t *testing.T, key, value string) {
+	orig, found := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if found {
+			_ = os.Setenv(key, orig)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
 }
@@ -0,0 +1,158 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/strategies"
+	u "github.com/edaniszewski/chart-releaser/pkg/utils"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+)
+
+// Errors for resolving the application version.
+var (
+	ErrNoAppVersionFlag  = errors.New("version source is 'flag' but no --app-version was provided")
+	ErrNoTagInEnv        = errors.New("no release tag found in the configured environment variables")
+	ErrNoVersionCommand  = errors.New("version source is 'command' but no command is configured")
+	ErrEmptyVersion      = errors.New("version source produced an empty version")
+	ErrClientNotSet      = errors.New("repository client not set prior to running 'git' stage")
+	ErrUnsupportedSource = errors.New("unsupported version source")
+)
+
+// DefaultVersionEnv are the environment variables checked, in order, for the
+// release tag when using the "env" version source with no variables configured.
+var DefaultVersionEnv = []string{
+	"GITHUB_REF",
+	"CI_COMMIT_TAG",
+	"TAG_NAME",
+}
+
+// DefaultVersionFile is the file read when using the "file" version source with
+// no file configured.
+const DefaultVersionFile = "VERSION"
+
+// getTag gets the tag for the new application release from the version source
+// configured for the Context. In addition to the tag, it returns a description
+// of where the tag came from.
+//
+// An app version provided explicitly via the --app-version flag always takes
+// precedence over the configured version source.
+func getTag(ctx *context.Context) (string, string, error) {
+	if ctx.AppVersion != "" {
+		return ctx.AppVersion, "--app-version flag", nil
+	}
+
+	// In CI, the tag being built is used in place of git, since CI checkouts
+	// are often shallow or detached, so tags may not be fetched.
+	if ctx.Release.VersionSource == strategies.SourceGit || ctx.Release.VersionSource == "" {
+		if ctx.CI.Tag != "" {
+			return ctx.CI.Tag, fmt.Sprintf("%s CI environment", ctx.CI.Provider), nil
+		}
+	}
+
+	var tag, source string
+	var err error
+
+	switch ctx.Release.VersionSource {
+	case strategies.SourceGit, "":
+		tag, err = u.GetTag()
+		source = "git describe"
+	case strategies.SourceFlag:
+		err = ErrNoAppVersionFlag
+	case strategies.SourceEnv:
+		tag, source, err = tagFromEnv(ctx)
+	case strategies.SourceFile:
+		tag, source, err = tagFromFile(ctx)
+	case strategies.SourceCommand:
+		tag, source, err = tagFromCommand(ctx)
+	case strategies.SourceGitHub:
+		tag, source, err = tagFromGitHub(ctx)
+	default:
+		err = ErrUnsupportedSource
+	}
+	if err != nil {
+		return "", source, err
+	}
+
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", source, ErrEmptyVersion
+	}
+	return tag, source, nil
+}
+
+// tagFromEnv gets the tag from the first configured environment variable which
+// is set. Git ref prefixes are removed, so GITHUB_REF=refs/tags/v1.2.3 yields the
+// tag v1.2.3. Refs which are not tags (e.g. branches) are skipped.
+func tagFromEnv(ctx *context.Context) (string, string, error) {
+	vars := DefaultVersionEnv
+	if vs := ctx.Config.Release.VersionSource; vs != nil && len(vs.Env) != 0 {
+		vars = vs.Env
+	}
+
+	for _, name := range vars {
+		val := strings.TrimSpace(os.Getenv(name))
+		if val == "" {
+			continue
+		}
+		if strings.HasPrefix(val, "refs/") {
+			if !strings.HasPrefix(val, "refs/tags/") {
+				continue
+			}
+			val = strings.TrimPrefix(val, "refs/tags/")
+		}
+		return val, fmt.Sprintf("env (%s)", name), nil
+	}
+	return "", "env", ErrNoTagInEnv
+}
+
+// tagFromFile gets the tag from the contents of the configured version file.
+func tagFromFile(ctx *context.Context) (string, string, error) {
+	path := DefaultVersionFile
+	if vs := ctx.Config.Release.VersionSource; vs != nil && vs.File != "" {
+		path = vs.File
+	}
+	source := fmt.Sprintf("file (%s)", path)
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", source, err
+	}
+	return string(contents), source, nil
+}
+
+// tagFromCommand gets the tag from the output of the configured command.
+func tagFromCommand(ctx *context.Context) (string, string, error) {
+	vs := ctx.Config.Release.VersionSource
+	if vs == nil || vs.Command == "" {
+		return "", "command", ErrNoVersionCommand
+	}
+	source := fmt.Sprintf("command (%s)", vs.Command)
+
+	out, err := u.RunShellCommand(ctx.Context, vs.Command)
+	if err != nil {
+		return "", source, err
+	}
+	return out, source, nil
+}
+
+// tagFromGitHub gets the tag of the latest release for the source repository.
+func tagFromGitHub(ctx *context.Context) (string, string, error) {
+	source := fmt.Sprintf("github release (%s/%s)", ctx.SourceRepository.Owner, ctx.SourceRepository.Name)
+	if ctx.Client == nil {
+		return "", source, ErrClientNotSet
+	}
+
+	release, err := ctx.Client.GetLatestRelease(ctx.Context, &client.Options{
+		RepoName:  ctx.SourceRepository.Name,
+		RepoOwner: ctx.SourceRepository.Owner,
+	})
+	if err != nil {
+		return "", source, err
+	}
+	return release.Tag, source, nil
+}
@@ -0,0 +1,70 @@
+package notes
+
+import (
+	"errors"
+
+	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+)
+
+// Errors for the notes stage.
+var (
+	ErrClientNotSet = errors.New("repository client not set prior to running 'notes' stage")
+)
+
+// Stage for the "notes" step of the update pipeline.
+type Stage struct{}
+
+// Name of the stage.
+func (Stage) Name() string {
+	return "notes"
+}
+
+// String describes what the stage does.
+func (Stage) String() string {
+	return "fetching application release notes"
+}
+
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"client", "git"}
+}
+
+// Run the operations defined for the stage.
+func (Stage) Run(ctx *context.Context) error {
+	if !ctx.Config.Release.ReleaseNotes {
+		ctx.Log().Info("release notes not enabled - skipping")
+		return nil
+	}
+
+	if ctx.Client == nil {
+		return ErrClientNotSet
+	}
+
+	opts := &client.Options{
+		RepoName:  ctx.SourceRepository.Name,
+		RepoOwner: ctx.SourceRepository.Owner,
+	}
+	ctx.Log().WithFields(log.Fields{
+		"tag":       ctx.Git.Tag,
+		"repoName":  ctx.SourceRepository.Name,
+		"repoOwner": ctx.SourceRepository.Owner,
+	}).Debug("getting release for tag")
+
+	// The release notes are only used to enrich templates, so failure to get
+	// them should not prevent the chart from being updated.
+	release, err := ctx.Client.GetReleaseByTag(ctx.Context, opts, ctx.Git.Tag)
+	if err != nil {
+		ctx.Log().WithFields(log.Fields{
+			"error": err,
+			"tag":   ctx.Git.Tag,
+		}).Warn("failed to get release notes for tag -- skipping")
+		return nil
+	}
+
+	ctx.App.ReleaseNotes = release.Body
+	ctx.App.ReleaseURL = release.URL
+	ctx.App.ReleaseDate = release.PublishedAt
+	return nil
+}
@@ -0,0 +1,111 @@
+package notes
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStage_Name(t *testing.T) {
+	assert.Equal(t, "notes", Stage{}.Name())
+}
+
+func TestStage_String(t *testing.T) {
+	assert.Equal(t, "fetching application release notes", Stage{}.String())
+}
+
+func TestStage_Run(t *testing.T) {
+	published := time.Date(2020, 10, 2, 12, 30, 0, 0, time.UTC)
+	c := &testutils.FakeClient{
+		ReleaseData: &client.Release{
+			Tag:         "v1.2.3",
+			Body:        "* added a feature",
+			URL:         "https://github.com/edaniszewski/app/releases/tag/v1.2.3",
+			PublishedAt: published,
+		},
+	}
+	context := ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				ReleaseNotes: true,
+			},
+		},
+		Git: ctx.Git{
+			Tag: "v1.2.3",
+		},
+		Repository: ctx.Repository{
+			Owner: "edaniszewski",
+			Name:  "charts",
+		},
+		SourceRepository: ctx.Repository{
+			Owner: "edaniszewski",
+			Name:  "app",
+		},
+		Client: c,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	// The release is looked up in the source repository, not the chart repository.
+	assert.Equal(t, []testutils.ReleaseRequest{
+		{RepoOwner: "edaniszewski", RepoName: "app", Tag: "v1.2.3"},
+	}, c.ReleaseRequests)
+	assert.Equal(t, "* added a feature", context.App.ReleaseNotes)
+	assert.Equal(t, "https://github.com/edaniszewski/app/releases/tag/v1.2.3", context.App.ReleaseURL)
+	assert.Equal(t, published, context.App.ReleaseDate)
+}
+
+func TestStage_Run_NotEnabled(t *testing.T) {
+	context := ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "", context.App.ReleaseNotes)
+}
+
+func TestStage_Run_NoClient(t *testing.T) {
+	context := ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				ReleaseNotes: true,
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.Equal(t, ErrClientNotSet, err)
+}
+
+func TestStage_Run_ReleaseError(t *testing.T) {
+	c := &testutils.FakeClient{
+		GetReleaseError: []error{errors.New("test error")},
+	}
+	context := ctx.Context{
+		Config: &v1.Config{
+			Release: &v1.ReleaseConfig{
+				ReleaseNotes: true,
+			},
+		},
+		Git: ctx.Git{
+			Tag: "v1.2.3",
+		},
+		Client: c,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, []testutils.ReleaseRequest{{Tag: "v1.2.3"}}, c.ReleaseRequests)
+	assert.Equal(t, "", context.App.ReleaseNotes)
+	assert.Equal(t, "", context.App.ReleaseURL)
+	assert.True(t, context.App.ReleaseDate.IsZero())
+}
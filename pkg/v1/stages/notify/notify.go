@@ -0,0 +1,274 @@
+package notify
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/templates"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
+)
+
+// DefaultTimeout is the timeout for delivering a single notification.
+const DefaultTimeout = 10 * time.Second
+
+// httpClient is the client used to deliver notifications.
+var httpClient = &http.Client{Timeout: DefaultTimeout}
+
+// Stage for the "notify" step of the update pipeline.
+type Stage struct{}
+
+// Name of the stage.
+func (Stage) Name() string {
+	return "notify"
+}
+
+// String describes what the stage does.
+func (Stage) String() string {
+	return "sending notifications"
+}
+
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"publish"}
+}
+
+// Run the operations defined for the stage.
+func (Stage) Run(ctx *context.Context) error {
+	if ctx.Config == nil || len(ctx.Config.Notifications) == 0 {
+		ctx.Log().Info("no notifications configured - skipping")
+		return nil
+	}
+
+	// Only notify of success if changes were actually published.
+	if !ctx.DryRun && len(ctx.Publish.Commits) == 0 {
+		ctx.Log().Info("no changes published - skipping notifications")
+		return nil
+	}
+
+	Send(ctx, nil)
+	return nil
+}
+
+// Send the configured notifications for the outcome of an update, given the
+// error the update failed with, if any.
+//
+// Notifications are sent on a best-effort basis: failures to deliver them are
+// logged, but do not cause the update to fail.
+func Send(ctx *context.Context, err error) {
+	if ctx.Config == nil {
+		return
+	}
+
+	outcome := v1.NotifyOnSuccess
+	ctx.Result = context.Result{
+		Success: err == nil,
+		Drift:   drift(ctx),
+	}
+	if err != nil {
+		outcome = v1.NotifyOnFailure
+		ctx.Result.Error = err.Error()
+	}
+
+	for i, n := range ctx.Config.Notifications {
+		if n == nil {
+			continue
+		}
+		logger := ctx.Log().WithFields(log.Fields{
+			"index":   i,
+			"type":    n.Type,
+			"outcome": outcome,
+			"drift":   ctx.Result.Drift,
+		})
+		if !shouldSend(n, outcome, ctx.Result.Drift) {
+			logger.Debug("notification filtered - not sending")
+			continue
+		}
+
+		if sendErr := send(ctx, n, err); sendErr != nil {
+			logger.WithError(sendErr).Warn("failed to send notification")
+			continue
+		}
+		logger.Info("sent notification")
+	}
+}
+
+// shouldSend checks whether the notification should be sent for the outcome
+// and drift level of the update.
+func shouldSend(n *v1.NotificationConfig, outcome, drift string) bool {
+	if len(n.On) != 0 && !contains(n.On, outcome) {
+		return false
+	}
+	if len(n.Drift) != 0 && !contains(n.Drift, drift) {
+		return false
+	}
+	return true
+}
+
+// drift gets the level of drift between the previous and new application
+// versions. If either version is not known, the drift is "none".
+func drift(ctx *context.Context) string {
+	if ctx.Git.Tag == "" || ctx.Chart.File.PreviousContents == nil {
+		return "none"
+	}
+	level, _, err := ctx.App.NewVersion.FindDrift(&ctx.App.PreviousVersion)
+	if err != nil {
+		return "none"
+	}
+	return level.String()
+}
+
+// send a single notification.
+func send(ctx *context.Context, n *v1.NotificationConfig, err error) error {
+	url, rerr := render(ctx, "notification-url", n.URL)
+	if rerr != nil {
+		return rerr
+	}
+	body, rerr := payload(ctx, n, err)
+	if rerr != nil {
+		return rerr
+	}
+
+	if ctx.DryRun {
+		ctx.Log().WithFields(log.Fields{
+			"type": n.Type,
+			"body": string(body),
+		}).Info("dry-run: not sending notification")
+		return nil
+	}
+
+	// The context for the update may have been cancelled or timed out, which
+	// could be why it failed, so notifications get their own.
+	c, cancel := gocontext.WithTimeout(gocontext.Background(), DefaultTimeout)
+	defer cancel()
+
+	req, rerr := http.NewRequestWithContext(c, http.MethodPost, url, bytes.NewReader(body))
+	if rerr != nil {
+		return rerr
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var keys []string
+	for k := range n.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, rerr := render(ctx, "notification-header", n.Headers[k])
+		if rerr != nil {
+			return rerr
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, rerr := httpClient.Do(req)
+	if rerr != nil {
+		return rerr
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
+
+// payload gets the JSON body of the notification.
+func payload(ctx *context.Context, n *v1.NotificationConfig, err error) ([]byte, error) {
+	switch n.Type {
+	case v1.NotifySlack:
+		msg, rerr := render(ctx, "notification-template", message(n))
+		if rerr != nil {
+			return nil, rerr
+		}
+		return marshal(map[string]string{
+			"text": msg,
+		})
+
+	case v1.NotifyTeams:
+		msg, rerr := render(ctx, "notification-template", message(n))
+		if rerr != nil {
+			return nil, rerr
+		}
+		return marshal(map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  fmt.Sprintf("chart-releaser: %s", ctx.Chart.Name),
+			"text":     msg,
+		})
+
+	case v1.NotifyWebhook:
+		// Generic webhooks get the run report, unless a template is configured
+		// to render the body.
+		if n.Template == "" {
+			buf := bytes.Buffer{}
+			if rerr := report.New(ctx, err).Write(&buf); rerr != nil {
+				return nil, rerr
+			}
+			return buf.Bytes(), nil
+		}
+		msg, rerr := render(ctx, "notification-template", n.Template)
+		if rerr != nil {
+			return nil, rerr
+		}
+		return []byte(msg), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported notification type '%s', should be one of: %v", n.Type, v1.NotificationTypes)
+	}
+}
+
+// message gets the message template for the notification.
+func message(n *v1.NotificationConfig) string {
+	if n.Template != "" {
+		return n.Template
+	}
+	return templates.DefaultNotificationMessage
+}
+
+// render a template for a notification. Unlike utils.RenderTemplate, errors
+// are not ignored in dry-run mode, so they are reported when the notification
+// is sent.
+func render(ctx *context.Context, name, tmpl string) (string, error) {
+	t, err := utils.ParseTemplate(ctx, name, tmpl)
+	if err != nil {
+		return "", err
+	}
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, ctx); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// marshal a payload to JSON, without escaping HTML characters.
+func marshal(v interface{}) ([]byte, error) {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// contains checks whether the string is in the list.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,488 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
+	"github.com/stretchr/testify/assert"
+)
+
+// request is a request received by the test receiver.
+type request struct {
+	Path   string
+	Header http.Header
+	Body   string
+}
+
+// receiver is a test HTTP server which records the notifications it receives.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []request
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	r := &receiver{status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, request{Path: req.URL.Path, Header: req.Header, Body: string(body)})
+		r.mu.Unlock()
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestStage_Name(t *testing.T) {
+	assert.Equal(t, "notify", Stage{}.Name())
+}
+
+func TestStage_String(t *testing.T) {
+	assert.Equal(t, "sending notifications", Stage{}.String())
+}
+
+func TestStage_Requires(t *testing.T) {
+	assert.Equal(t, []string{"publish"}, Stage{}.Requires())
+}
+
+func TestStage_RunNoNotifications(t *testing.T) {
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config:  &v1.Config{},
+	}
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+}
+
+func TestStage_RunSlack(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{
+					Type: v1.NotifySlack,
+					URL:  r.URL + "/slack",
+				},
+			},
+		},
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v1.3.0"),
+			PreviousVersion: testutils.NewSemver(t, "v1.2.4"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+		Publish: ctx.Publish{
+			Commits: []ctx.PublishedCommit{
+				{Path: "charts/foo/Chart.yaml", SHA: "abc123", Message: "bump"},
+			},
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/example/charts/pull/7",
+			},
+		},
+	}
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Len(t, r.requests, 1)
+	assert.Equal(t, "/slack", r.requests[0].Path)
+	assert.Equal(t, "application/json", r.requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, `{"text":"Published foo chart 0.2.0 for application release v1.3.0: https://github.com/example/charts/pull/7"}`, r.requests[0].Body)
+	assert.True(t, context.Result.Success)
+	assert.Equal(t, "minor", context.Result.Drift)
+}
+
+func TestStage_RunTeams(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{
+					Type:     v1.NotifyTeams,
+					URL:      r.URL,
+					Template: "{{ .Chart.Name }} {{ .Result.Drift }} release <{{ .Chart.NewVersion }}>",
+				},
+			},
+		},
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v1.3.0"),
+			PreviousVersion: testutils.NewSemver(t, "v1.2.4"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+		Publish: ctx.Publish{
+			Commits: []ctx.PublishedCommit{
+				{Path: "charts/foo/Chart.yaml", SHA: "abc123", Message: "bump"},
+			},
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/example/charts/pull/7",
+			},
+		},
+	}
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Len(t, r.requests, 1)
+	assert.Equal(t, `{"@context":"https://schema.org/extensions","@type":"MessageCard","summary":"chart-releaser: foo","text":"foo minor release <0.2.0>"}`, r.requests[0].Body)
+}
+
+func TestStage_RunWebhook(t *testing.T) {
+	r := newReceiver(t, http.StatusAccepted)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{
+					Type: v1.NotifyWebhook,
+					URL:  r.URL,
+					Headers: map[string]string{
+						"Authorization": "Bearer {{ .Chart.Name }}-token",
+					},
+				},
+			},
+		},
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v1.3.0"),
+			PreviousVersion: testutils.NewSemver(t, "v1.2.4"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+		Publish: ctx.Publish{
+			Commits: []ctx.PublishedCommit{
+				{Path: "charts/foo/Chart.yaml", SHA: "abc123", Message: "bump"},
+			},
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/example/charts/pull/7",
+			},
+		},
+	}
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Len(t, r.requests, 1)
+	assert.Equal(t, "Bearer foo-token", r.requests[0].Header.Get("Authorization"))
+
+	// Without a template, the body is the run report.
+	var rep report.Report
+	assert.NoError(t, json.Unmarshal([]byte(r.requests[0].Body), &rep))
+	assert.True(t, rep.Success)
+	assert.Equal(t, "0.2.0", rep.Chart.New)
+	assert.Equal(t, 7, rep.PullRequest.Number)
+}
+
+func TestStage_RunWebhookTemplate(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{
+					Type:     v1.NotifyWebhook,
+					URL:      r.URL,
+					Template: `{"chart": {{ .Chart.Name | toJson }}, "version": "{{ .Chart.NewVersion }}"}`,
+				},
+			},
+		},
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v1.3.0"),
+			PreviousVersion: testutils.NewSemver(t, "v1.2.4"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+		Publish: ctx.Publish{
+			Commits: []ctx.PublishedCommit{
+				{Path: "charts/foo/Chart.yaml", SHA: "abc123", Message: "bump"},
+			},
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/example/charts/pull/7",
+			},
+		},
+	}
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Len(t, r.requests, 1)
+	assert.Equal(t, `{"chart": "foo", "version": "0.2.0"}`, r.requests[0].Body)
+}
+
+func TestStage_RunNothingPublished(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{
+					Type: v1.NotifySlack,
+					URL:  r.URL,
+				},
+			},
+		},
+		Publish: ctx.Publish{
+			SkipReason: "tag v1.3.0 matches release ignore constraint 'v1.*'",
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+	}
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Empty(t, r.requests)
+}
+
+func TestStage_RunDryRun(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{
+					Type: v1.NotifySlack,
+					URL:  r.URL,
+				},
+			},
+		},
+		DryRun: true,
+		Chart: ctx.Chart{
+			Name: "foo",
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+	}
+
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Empty(t, r.requests)
+}
+
+func TestStage_RunDeliveryFailure(t *testing.T) {
+	failing := newReceiver(t, http.StatusInternalServerError)
+	r := newReceiver(t, http.StatusOK)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{Type: v1.NotifySlack, URL: failing.URL},
+				{Type: v1.NotifySlack, URL: "http://127.0.0.1:0"},
+				{Type: v1.NotifySlack, URL: "{{ .Invalid }}"},
+				{Type: v1.NotifySlack, URL: r.URL},
+			},
+		},
+		Publish: ctx.Publish{
+			Commits: []ctx.PublishedCommit{
+				{Path: "charts/foo/Chart.yaml", SHA: "abc123", Message: "bump"},
+			},
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+	}
+
+	// Failures to deliver notifications do not fail the stage, and do not
+	// prevent other notifications from being sent.
+	err := Stage{}.Run(context)
+	assert.NoError(t, err)
+	assert.Len(t, failing.requests, 1)
+	assert.Len(t, r.requests, 1)
+}
+
+func TestSend_Failure(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{
+					Type: v1.NotifySlack,
+					URL:  r.URL,
+				},
+			},
+		},
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v1.3.0"),
+			PreviousVersion: testutils.NewSemver(t, "v1.2.4"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+	}
+
+	Send(context, errors.New("no chart changes"))
+	assert.Len(t, r.requests, 1)
+	assert.Equal(t, `{"text":"Failed to update foo chart for application release v1.3.0: no chart changes"}`, r.requests[0].Body)
+	assert.False(t, context.Result.Success)
+	assert.Equal(t, "no chart changes", context.Result.Error)
+}
+
+func TestSend_ContextExpired(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Minute))
+	defer cancel()
+
+	context := &ctx.Context{
+		Context: expired,
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{
+					Type: v1.NotifySlack,
+					URL:  r.URL,
+				},
+			},
+		},
+		App: ctx.App{
+			NewVersion: testutils.NewSemver(t, "v1.3.0"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+	}
+
+	Send(context, expired.Err())
+	assert.Len(t, r.requests, 1)
+	assert.Equal(t, `{"text":"Failed to update foo chart for application release v1.3.0: context deadline exceeded"}`, r.requests[0].Body)
+}
+
+func TestSend_Filters(t *testing.T) {
+	onSuccess := newReceiver(t, http.StatusOK)
+	onFailure := newReceiver(t, http.StatusOK)
+	onMajor := newReceiver(t, http.StatusOK)
+	onMinor := newReceiver(t, http.StatusOK)
+	context := &ctx.Context{
+		Context: context.Background(),
+		Config: &v1.Config{
+			Notifications: []*v1.NotificationConfig{
+				{Type: v1.NotifySlack, URL: onSuccess.URL, On: []string{v1.NotifyOnSuccess}},
+				{Type: v1.NotifySlack, URL: onFailure.URL, On: []string{v1.NotifyOnFailure}},
+				{Type: v1.NotifySlack, URL: onMajor.URL, Drift: []string{"major"}},
+				{Type: v1.NotifySlack, URL: onMinor.URL, Drift: []string{"major", "minor"}},
+			},
+		},
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "v1.3.0"),
+			PreviousVersion: testutils.NewSemver(t, "v1.2.4"),
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			NewVersion:      testutils.NewSemver(t, "0.2.0"),
+		},
+		Git: ctx.Git{
+			Tag: "v1.3.0",
+		},
+		Publish: ctx.Publish{
+			Commits: []ctx.PublishedCommit{
+				{Path: "charts/foo/Chart.yaml", SHA: "abc123", Message: "bump"},
+			},
+			PullRequest: &client.PullRequest{
+				Number: 7,
+				URL:    "https://github.com/example/charts/pull/7",
+			},
+		},
+	}
+
+	Send(context, nil)
+	assert.Len(t, onSuccess.requests, 1)
+	assert.Len(t, onFailure.requests, 0)
+	assert.Len(t, onMajor.requests, 0)
+	assert.Len(t, onMinor.requests, 1)
+
+	Send(context, errors.New("failed"))
+	assert.Len(t, onSuccess.requests, 1)
+	assert.Len(t, onFailure.requests, 1)
+	assert.Len(t, onMajor.requests, 0)
+	assert.Len(t, onMinor.requests, 2)
+}
+
+func TestDrift_Unknown(t *testing.T) {
+	assert.Equal(t, "none", drift(&ctx.Context{}))
+}
@@ -1,9 +1,7 @@
 package publish
 
 import (
-	"bytes"
 	"errors"
-	"text/template"
 
 	"github.com/apex/log"
 	"github.com/davecgh/go-spew/spew"
@@ -33,6 +31,11 @@ func (Stage) String() string {
 	return "publishing changes"
 }
 
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"client", "render"}
+}
+
 // Run the operations defined for the stage.
 func (Stage) Run(ctx *context.Context) error {
 	var err error
@@ -62,50 +65,39 @@ func (Stage) Run(ctx *context.Context) error {
 		return err
 	}
 
-	log.Debugf("chart-release context:\n%v", spew.Sdump(ctx))
-
-	// Check that the tag matches the release constraints.
-	for _, m := range ctx.Release.Matches {
-		if !m.MatchString(ctx.Git.Tag) {
-			if ctx.DryRun {
-				log.Warnf("dry-run: tag release (%s) does not match release constraint '%s'", ctx.Git.Tag, m.String())
-				continue
-			} else {
-				log.Infof("tag release (%s) does not match release constraint '%s': will not update", ctx.Git.Tag, m.String())
-				return nil
-			}
-		}
-	}
+	ctx.Log().Debugf("chart-release context:\n%v", spew.Sdump(ctx))
 
-	// Check that the tag does not match an the ignore constraints.
-	for _, i := range ctx.Release.Ignores {
-		if i.MatchString(ctx.Git.Tag) {
-			if ctx.DryRun {
-				log.Warnf("dry-run: tag release (%s) matches release ignore constraint '%s'", ctx.Git.Tag, i.String())
-				continue
-			} else {
-				log.Infof("tag release (%s) matches release ignore constraint '%s': will not update", ctx.Git.Tag, i.String())
-				return nil
-			}
-		}
+	// The release constraints are checked when rendering, so the update is
+	// not confirmed or published if the tag does not satisfy them.
+	if ctx.Publish.SkipReason != "" {
+		ctx.Log().Infof("%s: will not update", ctx.Publish.SkipReason)
+		return nil
 	}
 
 	if ctx.DryRun {
-		log.Info("dry-run: skipping publish")
+		ctx.Log().Info("dry-run: skipping publish")
+		ctx.Publish.SkipReason = "dry-run"
 		return nil
 	}
 
 	switch ctx.PublishStrategy {
 	case strategies.PublishCommit:
-		return publishCommit(ctx)
+		err = publishCommit(ctx)
 	case strategies.PublishPullRequest:
-		return publishPullRequest(ctx)
+		err = publishPullRequest(ctx)
 	default:
-		log.WithFields(log.Fields{
+		ctx.Log().WithFields(log.Fields{
 			"strategy": ctx.PublishStrategy,
 		}).Error("unsupported publish strategy specified")
 		return ErrUnsupportedPublishStrategy
 	}
+
+	// If publishing fails part way, roll back the changes which were made
+	// so they do not block the next update.
+	if err != nil {
+		Rollback(ctx)
+	}
+	return err
 }
 
 func publishCommit(ctx *context.Context) error {
@@ -124,32 +116,58 @@ func publishCommit(ctx *context.Context) error {
 		if err := ctx.Client.CreateRef(ctx.Context, opts); err != nil {
 			return err
 		}
+		ctx.Publish.Journal = append(ctx.Publish.Journal, context.Mutation{
+			Kind: context.MutationCreateRef,
+			Ref:  ctx.Git.Ref,
+		})
 	}
 
 	// Update the Chart
 	if ctx.Chart.File.HasChanges() {
-		if err := ctx.Client.UpdateFile(ctx.Context, opts, ctx.Chart.File.Path, ctx.Release.ChartCommitMsg, ctx.Chart.File.NewContents); err != nil {
+		sha, err := ctx.Client.UpdateFile(ctx.Context, opts, ctx.Chart.File.Path, ctx.Release.ChartCommitMsg, ctx.Chart.File.NewContents)
+		if err != nil {
 			return err
 		}
+		ctx.Publish.Commits = append(ctx.Publish.Commits, context.PublishedCommit{
+			Path:    ctx.Chart.File.Path,
+			SHA:     sha,
+			Message: ctx.Release.ChartCommitMsg,
+		})
+		ctx.Publish.Journal = append(ctx.Publish.Journal, context.Mutation{
+			Kind: context.MutationUpdateFile,
+			Ref:  ctx.Git.Ref,
+			Path: ctx.Chart.File.Path,
+			SHA:  sha,
+		})
 	} else {
-		log.Error("chart has no changes - will not update")
+		ctx.Log().Error("chart has no changes - will not update")
 		return ErrNoChartChanges
 	}
 
 	// Update each of the extra files which have changes.
 	for _, f := range ctx.Files {
 		if f.HasChanges() {
-			ctx.CurrentFile = f
-
-			extrasCommitMsg, err := utils.RenderTemplate(ctx, f.Path, ctx.Release.ExtrasCommitMsg)
+			extrasCommitMsg, err := ExtrasCommitMessage(ctx, f)
 			if err != nil {
 				return err
 			}
-			if err := ctx.Client.UpdateFile(ctx.Context, opts, f.Path, extrasCommitMsg, f.NewContents); err != nil {
+			sha, err := ctx.Client.UpdateFile(ctx.Context, opts, f.Path, extrasCommitMsg, f.NewContents)
+			if err != nil {
 				return err
 			}
+			ctx.Publish.Commits = append(ctx.Publish.Commits, context.PublishedCommit{
+				Path:    f.Path,
+				SHA:     sha,
+				Message: extrasCommitMsg,
+			})
+			ctx.Publish.Journal = append(ctx.Publish.Journal, context.Mutation{
+				Kind: context.MutationUpdateFile,
+				Ref:  ctx.Git.Ref,
+				Path: f.Path,
+				SHA:  sha,
+			})
 		} else {
-			log.WithFields(log.Fields{
+			ctx.Log().WithFields(log.Fields{
 				"path": f.Path,
 			}).Warn("file has no changes - will not update")
 		}
@@ -157,6 +175,13 @@ func publishCommit(ctx *context.Context) error {
 	return nil
 }
 
+// ExtrasCommitMessage renders the commit message for updating an extras file.
+// The file is set as the Context's CurrentFile so it is available to the template.
+func ExtrasCommitMessage(ctx *context.Context, f context.File) (string, error) {
+	ctx.CurrentFile = f
+	return utils.RenderTemplate(ctx, f.Path, ctx.Release.ExtrasCommitMsg)
+}
+
 func publishPullRequest(ctx *context.Context) error {
 	if err := publishCommit(ctx); err != nil {
 		return err
@@ -171,46 +196,39 @@ func publishPullRequest(ctx *context.Context) error {
 		AuthorEmail: ctx.Author.Email,
 	}
 
-	// Pull request title
-	titleTmpl := ctx.Release.PRTitle
-	if titleTmpl == "" {
-		titleTmpl = templates.DefaultPullRequestTitle
-	}
-
-	t, err := template.New("").Parse(titleTmpl)
-	if err != nil {
-		return err
+	// The title and body were rendered by Run, so they are not rendered again:
+	// they may hold text from outside of the config, such as release notes,
+	// which must not be executed as a template.
+	var err error
+	title := ctx.Release.PRTitle
+	if title == "" {
+		title, err = utils.RenderTemplate(ctx, "pr-title", templates.DefaultPullRequestTitle)
+		if err != nil {
+			return err
+		}
 	}
-	buf := bytes.Buffer{}
-	err = t.Execute(&buf, ctx)
-	if err != nil {
-		return err
+	body := ctx.Release.PRBody
+	if body == "" {
+		body, err = utils.RenderTemplate(ctx, "pr-body", templates.DefaultPullRequestBody)
+		if err != nil {
+			return err
+		}
 	}
-	title := buf.String()
 
-	// Pull request comment
-	commentTmpl := ctx.Release.PRBody
-	if commentTmpl == "" {
-		commentTmpl = templates.DefaultPullRequestBody
-	}
+	ctx.Log().WithFields(log.Fields{
+		"title": title,
+		"body":  body,
+	}).Debug("publish: creating pull request")
 
-	t, err = template.New("").Parse(commentTmpl)
+	pr, err := ctx.Client.CreatePullRequest(ctx.Context, opts, title, body)
 	if err != nil {
 		return err
 	}
-	buf = bytes.Buffer{}
-	err = t.Execute(&buf, ctx)
-	if err != nil {
-		return err
-	}
-	body := buf.String()
-
-	log.WithFields(log.Fields{
-		"titleTmpl": titleTmpl,
-		"bodyTmpl":  commentTmpl,
-		"title":     title,
-		"body":      body,
-	}).Debug("publish: creating pull request")
-
-	return ctx.Client.CreatePullRequest(ctx.Context, opts, title, body)
+	ctx.Publish.PullRequest = pr
+	ctx.Publish.Journal = append(ctx.Publish.Journal, context.Mutation{
+		Kind:        context.MutationCreatePullRequest,
+		Ref:         ctx.Git.Ref,
+		PullRequest: pr,
+	})
+	return nil
 }
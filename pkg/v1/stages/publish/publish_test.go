@@ -1,11 +1,12 @@
 package publish
 
 import (
-	"regexp"
 	"testing"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/client/memory"
 	"github.com/edaniszewski/chart-releaser/pkg/strategies"
 	"github.com/edaniszewski/chart-releaser/pkg/templates"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
@@ -85,6 +86,119 @@ func TestStage_Run_StrategyPR(t *testing.T) {
 	assert.Equal(t, "pr-body", context.Release.PRBody)
 }
 
+func TestStage_Run_StrategyPRMemoryClient(t *testing.T) {
+	c := memory.New(map[string]string{
+		"charts/foo/Chart.yaml": "version: 0.1.0\n",
+		"charts/foo/README.md":  "foo 0.1.0\n",
+	})
+	context := ctx.Context{
+		Client: c,
+		Repository: ctx.Repository{
+			Owner: "example",
+			Name:  "charts",
+		},
+		Author: ctx.Author{
+			Name:  "releaser",
+			Email: "releaser@example.com",
+		},
+		Git: ctx.Git{
+			Ref:  "chartreleaser/foo/0.2.0",
+			Base: "master",
+		},
+		Release: ctx.Release{
+			ChartCommitMsg: "bump foo",
+			PRTitle:        "Bump foo",
+			PRBody:         "Bumps foo to 0.2.0",
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "charts/foo/README.md",
+				PreviousContents: []byte("foo 0.1.0\n"),
+				NewContents:      []byte("foo 0.2.0\n"),
+			},
+		},
+		PublishStrategy: strategies.PublishPullRequest,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+
+	log := c.Log("chartreleaser/foo/0.2.0")
+	assert.Len(t, log, 3)
+	assert.Equal(t, "bump foo", log[1].Message)
+	assert.Equal(t, "releaser", log[1].AuthorName)
+	assert.Equal(t, map[string]string{
+		"charts/foo/Chart.yaml": "version: 0.2.0\n",
+		"charts/foo/README.md":  "foo 0.2.0\n",
+	}, log[0].Files)
+
+	contents, _ := c.File("master", "charts/foo/Chart.yaml")
+	assert.Equal(t, "version: 0.1.0\n", contents)
+
+	assert.Equal(t, []memory.PullRequest{
+		{
+			Number: 1,
+			URL:    "memory://example/charts/pull/1",
+			Title:  "Bump foo",
+			Body:   "Bumps foo to 0.2.0",
+			Head:   "chartreleaser/foo/0.2.0",
+			Base:   "master",
+		},
+	}, c.PullRequests())
+	assert.Equal(t, &client.PullRequest{Number: 1, URL: "memory://example/charts/pull/1"}, context.Publish.PullRequest)
+}
+
+func TestStage_Run_StrategyPRReleaseNotes(t *testing.T) {
+	c := memory.New(map[string]string{
+		"charts/foo/Chart.yaml": "version: 0.1.0\n",
+	})
+	context := ctx.Context{
+		Client: c,
+		Repository: ctx.Repository{
+			Owner: "example",
+			Name:  "charts",
+		},
+		Git: ctx.Git{
+			Ref:  "chartreleaser/foo/0.2.0",
+			Base: "master",
+		},
+		App: ctx.App{
+			// Release notes are written outside of the config, so any
+			// template actions in them must not be executed.
+			ReleaseNotes: `Fixes {{ env "GITHUB_TOKEN" }} in {{ .Chart.Name }}`,
+		},
+		Release: ctx.Release{
+			ChartCommitMsg: "bump foo",
+			PRTitle:        "Bump {{ .Chart.Name }}",
+			PRBody:         "Notes: {{ .App.ReleaseNotes }}",
+		},
+		Chart: ctx.Chart{
+			Name: "foo",
+			File: ctx.File{
+				Path:             "charts/foo/Chart.yaml",
+				PreviousContents: []byte("version: 0.1.0\n"),
+				NewContents:      []byte("version: 0.2.0\n"),
+			},
+		},
+		PublishStrategy: strategies.PublishPullRequest,
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+
+	pulls := c.PullRequests()
+	assert.Len(t, pulls, 1)
+	assert.Equal(t, "Bump foo", pulls[0].Title)
+	assert.Equal(t, `Notes: Fixes {{ env "GITHUB_TOKEN" }} in {{ .Chart.Name }}`, pulls[0].Body)
+}
+
 func TestStage_Run_StrategyPRDefaultTemplates(t *testing.T) {
 	context := ctx.Context{
 		Client: &testutils.FakeClient{},
@@ -146,106 +260,85 @@ func TestStage_Run_StrategyPRDefaultTemplates(t *testing.T) {
 	), context.Release.PRBody)
 }
 
-func TestStage_Run_DryRun(t *testing.T) {
+func TestStage_Run_StrategyPRDefaultTemplatesWithCommits(t *testing.T) {
 	context := ctx.Context{
-		DryRun: true,
+		Client: &testutils.FakeClient{},
 		Git: ctx.Git{
-			Ref:  "ref-branch",
-			Base: "base-branch",
+			Ref:  templates.DefaultBranchName,
+			Base: "master",
+			Commits: []ctx.Commit{
+				{
+					ShortSHA: "abc1234",
+					Author:   "Jane Doe",
+					Subject:  "Add a feature (#12)",
+					PR:       12,
+				},
+				{
+					ShortSHA: "def5678",
+					Author:   "John Doe",
+					Subject:  "Fix a bug",
+				},
+			},
+			OmittedCommits: 3,
 		},
 		Release: ctx.Release{
-			ChartCommitMsg: "update-msg",
-			PRTitle:        "pr-title",
-			PRBody:         "pr-body",
+			ChartCommitMsg: templates.DefaultUpdateCommitMessage,
+			PRTitle:        templates.DefaultPullRequestTitle,
+			PRBody:         templates.DefaultPullRequestBody,
 		},
-		PublishStrategy: strategies.PublishCommit,
-	}
-
-	err := Stage{}.Run(&context)
-	assert.NoError(t, err)
-
-	assert.Equal(t, "ref-branch", context.Git.Ref)
-	assert.Equal(t, "base-branch", context.Git.Base)
-	assert.Equal(t, "update-msg", context.Release.ChartCommitMsg)
-	assert.Equal(t, "pr-title", context.Release.PRTitle)
-	assert.Equal(t, "pr-body", context.Release.PRBody)
-}
-
-func TestStage_RunPublishStrategyError(t *testing.T) {
-	context := ctx.Context{
-		Git: ctx.Git{
-			Ref:  "ref-branch",
-			Base: "base-branch",
+		Chart: ctx.Chart{
+			Name:            "test-chart",
+			NewVersion:      testutils.NewSemver(t, "1.2.3"),
+			PreviousVersion: testutils.NewSemver(t, "1.2.2"),
+			File: ctx.File{
+				Path:             "path1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
 		},
-		Release: ctx.Release{
-			ChartCommitMsg: "update-msg",
-			PRTitle:        "pr-title",
-			PRBody:         "pr-body",
+		App: ctx.App{
+			NewVersion:      testutils.NewSemver(t, "1.0.0"),
+			PreviousVersion: testutils.NewSemver(t, "0.1.0"),
+			PreviousTag:     "0.1.0",
 		},
-		PublishStrategy: strategies.PublishStrategy("invalid"),
+		PublishStrategy: strategies.PublishPullRequest,
 	}
 
 	err := Stage{}.Run(&context)
-	assert.Equal(t, ErrUnsupportedPublishStrategy, err)
+	assert.NoError(t, err)
 
-	assert.Equal(t, "ref-branch", context.Git.Ref)
-	assert.Equal(t, "base-branch", context.Git.Base)
-	assert.Equal(t, "update-msg", context.Release.ChartCommitMsg)
-	assert.Equal(t, "pr-title", context.Release.PRTitle)
-	assert.Equal(t, "pr-body", context.Release.PRBody)
-}
+	assert.Equal(t, heredoc.Doc(`
+		Bumps the test-chart Helm Chart from 1.2.2 to 1.2.3.
 
-func TestStage_RunNoTagMatch(t *testing.T) {
-	context := ctx.Context{
-		Git: ctx.Git{
-			Ref:  "ref-branch",
-			Tag:  "dev",
-			Base: "base-branch",
-		},
-		Release: ctx.Release{
-			ChartCommitMsg: "update-msg",
-			PRTitle:        "pr-title",
-			PRBody:         "pr-body",
-			Matches: []*regexp.Regexp{
-				regexp.MustCompile(`(\.[0-9])+`),
-			},
-		},
-	}
+		
+		<details>
+		<summary>Application changes since 0.1.0</summary>
 
-	err := Stage{}.Run(&context)
-	assert.NoError(t, err)
+		- abc1234 Add a feature (#12) (Jane Doe)
+		- def5678 Fix a bug (John Doe)
+		- *...and 3 more*
 
-	assert.Equal(t, "ref-branch", context.Git.Ref)
-	assert.Equal(t, "base-branch", context.Git.Base)
-	assert.Equal(t, "update-msg", context.Release.ChartCommitMsg)
-	assert.Equal(t, "pr-title", context.Release.PRTitle)
-	assert.Equal(t, "pr-body", context.Release.PRBody)
+		</details>
+
+		---
+		*This PR was generated with [chart-releaser](https://github.com/edaniszewski/chart-releaser)*
+`,
+	), context.Release.PRBody)
 }
 
-func TestStage_RunNoTagMatchDryRun(t *testing.T) {
+func TestStage_Run_DryRun(t *testing.T) {
 	context := ctx.Context{
 		DryRun: true,
-		Client: &testutils.FakeClient{},
 		Git: ctx.Git{
 			Ref:  "ref-branch",
-			Tag:  "dev",
 			Base: "base-branch",
 		},
 		Release: ctx.Release{
 			ChartCommitMsg: "update-msg",
 			PRTitle:        "pr-title",
 			PRBody:         "pr-body",
-			Matches: []*regexp.Regexp{
-				regexp.MustCompile(`(\.[0-9])+`),
-			},
-		},
-		Chart: ctx.Chart{
-			File: ctx.File{
-				Path:             "path1",
-				PreviousContents: []byte("foo"),
-				NewContents:      []byte("bar"),
-			},
 		},
+		PublishStrategy: strategies.PublishCommit,
 	}
 
 	err := Stage{}.Run(&context)
@@ -258,25 +351,22 @@ func TestStage_RunNoTagMatchDryRun(t *testing.T) {
 	assert.Equal(t, "pr-body", context.Release.PRBody)
 }
 
-func TestStage_RunTagIgnoreMatch(t *testing.T) {
+func TestStage_RunPublishStrategyError(t *testing.T) {
 	context := ctx.Context{
 		Git: ctx.Git{
 			Ref:  "ref-branch",
-			Tag:  "dev",
 			Base: "base-branch",
 		},
 		Release: ctx.Release{
 			ChartCommitMsg: "update-msg",
 			PRTitle:        "pr-title",
 			PRBody:         "pr-body",
-			Ignores: []*regexp.Regexp{
-				regexp.MustCompile(`dev(.)*`),
-			},
 		},
+		PublishStrategy: strategies.PublishStrategy("invalid"),
 	}
 
 	err := Stage{}.Run(&context)
-	assert.NoError(t, err)
+	assert.Equal(t, ErrUnsupportedPublishStrategy, err)
 
 	assert.Equal(t, "ref-branch", context.Git.Ref)
 	assert.Equal(t, "base-branch", context.Git.Base)
@@ -285,40 +375,25 @@ func TestStage_RunTagIgnoreMatch(t *testing.T) {
 	assert.Equal(t, "pr-body", context.Release.PRBody)
 }
 
-func TestStage_RunTagIgnoreMatchDryRun(t *testing.T) {
+func TestStage_RunSkipped(t *testing.T) {
+	c := memory.New(map[string]string{"Chart.yaml": "version: 0.1.0"})
 	context := ctx.Context{
-		DryRun: true,
-		Client: &testutils.FakeClient{},
+		Client: c,
 		Git: ctx.Git{
 			Ref:  "ref-branch",
 			Tag:  "dev",
 			Base: "base-branch",
 		},
-		Release: ctx.Release{
-			ChartCommitMsg: "update-msg",
-			PRTitle:        "pr-title",
-			PRBody:         "pr-body",
-			Ignores: []*regexp.Regexp{
-				regexp.MustCompile(`dev(.)*`),
-			},
-		},
-		Chart: ctx.Chart{
-			File: ctx.File{
-				Path:             "path1",
-				PreviousContents: []byte("foo"),
-				NewContents:      []byte("bar"),
-			},
+		Publish: ctx.Publish{
+			SkipReason: "tag dev does not match release constraint '(\\.[0-9])+'",
 		},
+		PublishStrategy: strategies.PublishCommit,
 	}
 
 	err := Stage{}.Run(&context)
 	assert.NoError(t, err)
-
-	assert.Equal(t, "ref-branch", context.Git.Ref)
-	assert.Equal(t, "base-branch", context.Git.Base)
-	assert.Equal(t, "update-msg", context.Release.ChartCommitMsg)
-	assert.Equal(t, "pr-title", context.Release.PRTitle)
-	assert.Equal(t, "pr-body", context.Release.PRBody)
+	assert.Equal(t, []string{"master"}, c.Branches())
+	assert.Empty(t, context.Publish.Commits)
 }
 
 func Test_PublishCommit(t *testing.T) {
@@ -476,65 +551,19 @@ func Test_PublishPullRequest(t *testing.T) {
 
 }
 
-func Test_PublishPullRequest_PublishCommitError(t *testing.T) {
+func Test_PublishPullRequestResults(t *testing.T) {
 	context := ctx.Context{
 		Client: &testutils.FakeClient{
-			CreateRefError: []error{
-				errors.New("test error"),
-			},
-		},
-		Git: ctx.Git{
-			Ref: "testbranch",
-		},
-		Chart: ctx.Chart{
-			File: ctx.File{
-				Path:             "path1",
-				PreviousContents: []byte("hello"),
-				NewContents:      []byte("hi"),
-			},
-		},
-		Files: []ctx.File{
-			{
-				Path:             "extra1",
-				PreviousContents: []byte("foo"),
-				NewContents:      []byte("bar"),
-			},
-		},
-	}
-
-	err := publishPullRequest(&context)
-	assert.EqualError(t, err, "test error")
-}
-
-func Test_PublishPullRequestParseTitleError(t *testing.T) {
-	context := ctx.Context{
-		Client: &testutils.FakeClient{},
-		Chart: ctx.Chart{
-			File: ctx.File{
-				Path:             "path1",
-				PreviousContents: []byte("hello"),
-				NewContents:      []byte("hi"),
-			},
-		},
-		Files: []ctx.File{
-			{
-				Path:             "extra1",
-				PreviousContents: []byte("foo"),
-				NewContents:      []byte("bar"),
+			CommitSHA: "abc123",
+			PullRequestData: &client.PullRequest{
+				Number: 12,
+				URL:    "https://github.com/test/charts/pull/12",
 			},
 		},
 		Release: ctx.Release{
-			PRTitle: "{{",
+			ChartCommitMsg:  "update chart",
+			ExtrasCommitMsg: "update {{ .CurrentFile.Path }}",
 		},
-	}
-
-	err := publishPullRequest(&context)
-	assert.EqualError(t, err, "template: :1: unexpected unclosed action in command")
-}
-
-func Test_PublishPullRequestExecuteTitleError(t *testing.T) {
-	context := ctx.Context{
-		Client: &testutils.FakeClient{},
 		Chart: ctx.Chart{
 			File: ctx.File{
 				Path:             "path1",
@@ -549,44 +578,28 @@ func Test_PublishPullRequestExecuteTitleError(t *testing.T) {
 				NewContents:      []byte("bar"),
 			},
 		},
-		Release: ctx.Release{
-			PRTitle: "{{ .Not.Exists }}",
-		},
 	}
 
 	err := publishPullRequest(&context)
-	assert.EqualError(t, err, "template: :1:7: executing \"\" at <.Not.Exists>: can't evaluate field Not in type *ctx.Context")
+	assert.NoError(t, err)
+	assert.Equal(t, []ctx.PublishedCommit{
+		{Path: "path1", SHA: "abc123", Message: "update chart"},
+		{Path: "extra1", SHA: "abc123", Message: "update extra1"},
+	}, context.Publish.Commits)
+	assert.Equal(t, &client.PullRequest{Number: 12, URL: "https://github.com/test/charts/pull/12"}, context.Publish.PullRequest)
+	assert.Equal(t, "", context.Publish.SkipReason)
 }
 
-func Test_PublishPullRequestParseBodyError(t *testing.T) {
+func Test_PublishPullRequest_PublishCommitError(t *testing.T) {
 	context := ctx.Context{
-		Client: &testutils.FakeClient{},
-		Chart: ctx.Chart{
-			File: ctx.File{
-				Path:             "path1",
-				PreviousContents: []byte("hello"),
-				NewContents:      []byte("hi"),
-			},
-		},
-		Files: []ctx.File{
-			{
-				Path:             "extra1",
-				PreviousContents: []byte("foo"),
-				NewContents:      []byte("bar"),
+		Client: &testutils.FakeClient{
+			CreateRefError: []error{
+				errors.New("test error"),
 			},
 		},
-		Release: ctx.Release{
-			PRBody: "{{",
+		Git: ctx.Git{
+			Ref: "testbranch",
 		},
-	}
-
-	err := publishPullRequest(&context)
-	assert.EqualError(t, err, "template: :1: unexpected unclosed action in command")
-}
-
-func Test_PublishPullRequestExecuteBodyError(t *testing.T) {
-	context := ctx.Context{
-		Client: &testutils.FakeClient{},
 		Chart: ctx.Chart{
 			File: ctx.File{
 				Path:             "path1",
@@ -601,11 +614,8 @@ func Test_PublishPullRequestExecuteBodyError(t *testing.T) {
 				NewContents:      []byte("bar"),
 			},
 		},
-		Release: ctx.Release{
-			PRBody: "{{ .Not.Exists }}",
-		},
 	}
 
 	err := publishPullRequest(&context)
-	assert.EqualError(t, err, "template: :1:7: executing \"\" at <.Not.Exists>: can't evaluate field Not in type *ctx.Context")
+	assert.EqualError(t, err, "test error")
 }
@@ -0,0 +1,94 @@
+package publish
+
+import (
+	gocontext "context"
+	"fmt"
+	"time"
+
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+)
+
+// RollbackTimeout is the time allowed for rolling back a failed publish.
+const RollbackTimeout = 30 * time.Second
+
+// Rollback compensates for the changes recorded in the publish journal,
+// closing any pull request which was opened and deleting any branch which was
+// created, in the reverse order to which they were made. Commits made to a
+// branch which already existed can not be rolled back.
+//
+// The journal is printed once done, so that any changes left behind are known.
+// If rollback is disabled, the journal is printed without any changes being
+// rolled back.
+func Rollback(ctx *context.Context) {
+	journal := ctx.Publish.Journal
+	if len(journal) == 0 {
+		return
+	}
+	defer ctx.PrintJournal()
+
+	if ctx.NoRollback {
+		ctx.Log().Warn("publish failed: rollback disabled, leaving changes to the chart repository in place")
+		return
+	}
+	ctx.Log().Warn("publish failed: rolling back changes to the chart repository")
+
+	// The context for the update may have been cancelled or timed out, which
+	// could be why publishing failed, so rollback gets its own.
+	c, cancel := gocontext.WithTimeout(gocontext.Background(), RollbackTimeout)
+	defer cancel()
+
+	opts := &client.Options{
+		Ref:         ctx.Git.Ref,
+		Base:        ctx.Git.Base,
+		RepoName:    ctx.Repository.Name,
+		RepoOwner:   ctx.Repository.Owner,
+		AuthorName:  ctx.Author.Name,
+		AuthorEmail: ctx.Author.Email,
+	}
+
+	// Whether the branch was created, and if so, whether it was deleted.
+	var refCreated, refDeleted bool
+	for i := len(journal) - 1; i >= 0; i-- {
+		m := &journal[i]
+		switch m.Kind {
+		case context.MutationCreatePullRequest:
+			if m.PullRequest == nil {
+				m.RollbackError = "pull request number not known"
+				continue
+			}
+			if err := ctx.Client.ClosePullRequest(c, opts, m.PullRequest.Number); err != nil {
+				ctx.Log().WithError(err).WithField("number", m.PullRequest.Number).Error("failed to close pull request")
+				m.RollbackError = err.Error()
+				continue
+			}
+			m.RolledBack = true
+
+		case context.MutationCreateRef:
+			refCreated = true
+			if err := ctx.Client.DeleteRef(c, opts); err != nil {
+				ctx.Log().WithError(err).WithField("ref", m.Ref).Error("failed to delete branch")
+				m.RollbackError = err.Error()
+				continue
+			}
+			refDeleted = true
+			m.RolledBack = true
+		}
+	}
+
+	// Commits are rolled back with the branch they were made to.
+	for i := range journal {
+		m := &journal[i]
+		if m.Kind != context.MutationUpdateFile {
+			continue
+		}
+		switch {
+		case refDeleted:
+			m.RolledBack = true
+		case refCreated:
+			m.RollbackError = fmt.Sprintf("branch '%s' could not be deleted", m.Ref)
+		default:
+			m.RollbackError = fmt.Sprintf("commit made to existing branch '%s' can not be rolled back", m.Ref)
+		}
+	}
+}
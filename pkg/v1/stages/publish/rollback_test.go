@@ -0,0 +1,247 @@
+package publish
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
+	"github.com/edaniszewski/chart-releaser/pkg/strategies"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStage_Run_RollbackUpdateFileError(t *testing.T) {
+	c := &testutils.FakeClient{
+		CommitSHA:       "abc123",
+		UpdateFileError: []error{nil, errors.New("test error")},
+	}
+	context := &ctx.Context{
+		Client: c,
+		Out:    &bytes.Buffer{},
+		Git: ctx.Git{
+			Ref:  "chartreleaser/foo/0.2.0",
+			Base: "master",
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "path1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "extra1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		PublishStrategy: strategies.PublishPullRequest,
+	}
+
+	err := Stage{}.Run(context)
+	assert.EqualError(t, err, "test error")
+
+	assert.Equal(t, []string{"chartreleaser/foo/0.2.0"}, c.DeletedRefs)
+	assert.Empty(t, c.ClosedPullRequests)
+	assert.Equal(t, []ctx.Mutation{
+		{Kind: ctx.MutationCreateRef, Ref: "chartreleaser/foo/0.2.0", RolledBack: true},
+		{Kind: ctx.MutationUpdateFile, Ref: "chartreleaser/foo/0.2.0", Path: "path1", SHA: "abc123", RolledBack: true},
+	}, context.Publish.Journal)
+	assert.Contains(t, context.Out.(*bytes.Buffer).String(), "=== Publish Journal ===")
+}
+
+func TestStage_Run_RollbackNoRollback(t *testing.T) {
+	c := &testutils.FakeClient{
+		UpdateFileError: []error{errors.New("test error")},
+	}
+	context := &ctx.Context{
+		Client: c,
+		Out:    &bytes.Buffer{},
+		Git: ctx.Git{
+			Ref:  "chartreleaser/foo/0.2.0",
+			Base: "master",
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "path1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "extra1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		PublishStrategy: strategies.PublishPullRequest,
+	}
+	context.NoRollback = true
+
+	err := Stage{}.Run(context)
+	assert.EqualError(t, err, "test error")
+
+	assert.Empty(t, c.DeletedRefs)
+	assert.Equal(t, []ctx.Mutation{
+		{Kind: ctx.MutationCreateRef, Ref: "chartreleaser/foo/0.2.0"},
+	}, context.Publish.Journal)
+}
+
+func TestStage_Run_RollbackNothingPublished(t *testing.T) {
+	c := &testutils.FakeClient{
+		CreateRefError: []error{errors.New("test error")},
+	}
+	context := &ctx.Context{
+		Client: c,
+		Out:    &bytes.Buffer{},
+		Git: ctx.Git{
+			Ref:  "chartreleaser/foo/0.2.0",
+			Base: "master",
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "path1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "extra1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		PublishStrategy: strategies.PublishPullRequest,
+	}
+
+	err := Stage{}.Run(context)
+	assert.EqualError(t, err, "test error")
+
+	assert.Empty(t, c.DeletedRefs)
+	assert.Empty(t, context.Publish.Journal)
+	assert.Empty(t, context.Out.(*bytes.Buffer).String())
+}
+
+func TestRollback_PullRequest(t *testing.T) {
+	c := &testutils.FakeClient{}
+	context := &ctx.Context{
+		Client: c,
+		Out:    &bytes.Buffer{},
+		Git: ctx.Git{
+			Ref:  "chartreleaser/foo/0.2.0",
+			Base: "master",
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "path1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "extra1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		PublishStrategy: strategies.PublishPullRequest,
+	}
+	context.Publish.Journal = []ctx.Mutation{
+		{Kind: ctx.MutationCreateRef, Ref: "chartreleaser/foo/0.2.0"},
+		{Kind: ctx.MutationUpdateFile, Ref: "chartreleaser/foo/0.2.0", Path: "path1", SHA: "abc123"},
+		{Kind: ctx.MutationCreatePullRequest, Ref: "chartreleaser/foo/0.2.0", PullRequest: &client.PullRequest{Number: 7}},
+	}
+
+	Rollback(context)
+
+	assert.Equal(t, []int{7}, c.ClosedPullRequests)
+	assert.Equal(t, []string{"chartreleaser/foo/0.2.0"}, c.DeletedRefs)
+	for _, m := range context.Publish.Journal {
+		assert.True(t, m.RolledBack, m.String())
+	}
+}
+
+func TestRollback_Errors(t *testing.T) {
+	c := &testutils.FakeClient{
+		DeleteRefError:        []error{errors.New("delete error")},
+		ClosePullRequestError: []error{errors.New("close error")},
+	}
+	context := &ctx.Context{
+		Client: c,
+		Out:    &bytes.Buffer{},
+		Git: ctx.Git{
+			Ref:  "chartreleaser/foo/0.2.0",
+			Base: "master",
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "path1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "extra1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		PublishStrategy: strategies.PublishPullRequest,
+	}
+	context.Publish.Journal = []ctx.Mutation{
+		{Kind: ctx.MutationCreateRef, Ref: "chartreleaser/foo/0.2.0"},
+		{Kind: ctx.MutationUpdateFile, Ref: "chartreleaser/foo/0.2.0", Path: "path1", SHA: "abc123"},
+		{Kind: ctx.MutationCreatePullRequest, Ref: "chartreleaser/foo/0.2.0", PullRequest: &client.PullRequest{Number: 7}},
+	}
+
+	Rollback(context)
+
+	assert.Equal(t, []ctx.Mutation{
+		{Kind: ctx.MutationCreateRef, Ref: "chartreleaser/foo/0.2.0", RollbackError: "delete error"},
+		{Kind: ctx.MutationUpdateFile, Ref: "chartreleaser/foo/0.2.0", Path: "path1", SHA: "abc123", RollbackError: "branch 'chartreleaser/foo/0.2.0' could not be deleted"},
+		{Kind: ctx.MutationCreatePullRequest, Ref: "chartreleaser/foo/0.2.0", PullRequest: &client.PullRequest{Number: 7}, RollbackError: "close error"},
+	}, context.Publish.Journal)
+}
+
+func TestRollback_ExistingBranch(t *testing.T) {
+	c := &testutils.FakeClient{}
+	context := &ctx.Context{
+		Client: c,
+		Out:    &bytes.Buffer{},
+		Git: ctx.Git{
+			Ref:  "chartreleaser/foo/0.2.0",
+			Base: "master",
+		},
+		Chart: ctx.Chart{
+			File: ctx.File{
+				Path:             "path1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		Files: []ctx.File{
+			{
+				Path:             "extra1",
+				PreviousContents: []byte("foo"),
+				NewContents:      []byte("bar"),
+			},
+		},
+		PublishStrategy: strategies.PublishPullRequest,
+	}
+	context.Git.Ref = "master"
+	context.Publish.Journal = []ctx.Mutation{
+		{Kind: ctx.MutationUpdateFile, Ref: "master", Path: "path1", SHA: "abc123"},
+	}
+
+	Rollback(context)
+
+	assert.Empty(t, c.DeletedRefs)
+	assert.Equal(t, "commit made to existing branch 'master' can not be rolled back", context.Publish.Journal[0].RollbackError)
+}
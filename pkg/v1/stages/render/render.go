@@ -1,6 +1,8 @@
 package render
 
 import (
+	"fmt"
+
 	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
 )
@@ -18,6 +20,11 @@ func (Stage) String() string {
 	return "rendering templates"
 }
 
+// Requires gets the stages which must run before the stage.
+func (Stage) Requires() []string {
+	return []string{"config", "chart"}
+}
+
 // Run the operations defined for the stage.
 func (Stage) Run(ctx *context.Context) error {
 	var err error
@@ -47,5 +54,37 @@ func (Stage) Run(ctx *context.Context) error {
 		return err
 	}
 
+	checkConstraints(ctx)
 	return nil
 }
+
+// checkConstraints checks that the tag satisfies the release constraints. If
+// it does not, the reason the update will not be published is set on the
+// Context. In dry-run mode, unsatisfied constraints are only warned about.
+func checkConstraints(ctx *context.Context) {
+	// Check that the tag matches the release constraints.
+	for _, m := range ctx.Release.Matches {
+		if !m.MatchString(ctx.Git.Tag) {
+			if ctx.DryRun {
+				ctx.Log().Warnf("dry-run: tag release (%s) does not match release constraint '%s'", ctx.Git.Tag, m.String())
+				continue
+			}
+			ctx.Log().Infof("tag release (%s) does not match release constraint '%s'", ctx.Git.Tag, m.String())
+			ctx.Publish.SkipReason = fmt.Sprintf("tag %s does not match release constraint '%s'", ctx.Git.Tag, m.String())
+			return
+		}
+	}
+
+	// Check that the tag does not match any of the ignore constraints.
+	for _, i := range ctx.Release.Ignores {
+		if i.MatchString(ctx.Git.Tag) {
+			if ctx.DryRun {
+				ctx.Log().Warnf("dry-run: tag release (%s) matches release ignore constraint '%s'", ctx.Git.Tag, i.String())
+				continue
+			}
+			ctx.Log().Infof("tag release (%s) matches release ignore constraint '%s'", ctx.Git.Tag, i.String())
+			ctx.Publish.SkipReason = fmt.Sprintf("tag %s matches release ignore constraint '%s'", ctx.Git.Tag, i.String())
+			return
+		}
+	}
+}
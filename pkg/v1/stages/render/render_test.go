@@ -1,6 +1,7 @@
 package render
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
@@ -115,3 +116,93 @@ func TestStage_RunReleasePRBodyError(t *testing.T) {
 	assert.Equal(t, "pr-title", context.Release.PRTitle)
 	assert.Equal(t, "", context.Release.PRBody)
 }
+
+func TestStage_RunNoTagMatch(t *testing.T) {
+	context := ctx.Context{
+		Git: ctx.Git{
+			Tag: "dev",
+		},
+		Release: ctx.Release{
+			Matches: []*regexp.Regexp{
+				regexp.MustCompile(`(\.[0-9])+`),
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "tag dev does not match release constraint '(\\.[0-9])+'", context.Publish.SkipReason)
+}
+
+func TestStage_RunNoTagMatchDryRun(t *testing.T) {
+	context := ctx.Context{
+		DryRun: true,
+		Git: ctx.Git{
+			Tag: "dev",
+		},
+		Release: ctx.Release{
+			Matches: []*regexp.Regexp{
+				regexp.MustCompile(`(\.[0-9])+`),
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "", context.Publish.SkipReason)
+}
+
+func TestStage_RunTagMatch(t *testing.T) {
+	context := ctx.Context{
+		Git: ctx.Git{
+			Tag: "v1.2.3",
+		},
+		Release: ctx.Release{
+			Matches: []*regexp.Regexp{
+				regexp.MustCompile(`(\.[0-9])+`),
+			},
+			Ignores: []*regexp.Regexp{
+				regexp.MustCompile(`dev(.)*`),
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "", context.Publish.SkipReason)
+}
+
+func TestStage_RunTagIgnoreMatch(t *testing.T) {
+	context := ctx.Context{
+		Git: ctx.Git{
+			Tag: "dev",
+		},
+		Release: ctx.Release{
+			Ignores: []*regexp.Regexp{
+				regexp.MustCompile(`dev(.)*`),
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "tag dev matches release ignore constraint 'dev(.)*'", context.Publish.SkipReason)
+}
+
+func TestStage_RunTagIgnoreMatchDryRun(t *testing.T) {
+	context := ctx.Context{
+		DryRun: true,
+		Git: ctx.Git{
+			Tag: "dev",
+		},
+		Release: ctx.Release{
+			Ignores: []*regexp.Regexp{
+				regexp.MustCompile(`dev(.)*`),
+			},
+		},
+	}
+
+	err := Stage{}.Run(&context)
+	assert.NoError(t, err)
+	assert.Equal(t, "", context.Publish.SkipReason)
+}
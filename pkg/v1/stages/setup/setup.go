@@ -3,7 +3,6 @@ package setup
 import (
 	"errors"
 
-	"github.com/apex/log"
 	"github.com/edaniszewski/chart-releaser/pkg/utils"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 )
@@ -30,25 +29,38 @@ func (Stage) String() string {
 
 // Run the operations defined for the stage.
 func (Stage) Run(ctx *ctx.Context) error {
-	log.Debug("checking if git exists on PATH")
+	// In CI, the release tag is read from the CI environment, so a local git
+	// repository is not required. A tag alone is not enough to relax the
+	// checks, unless a CI provider was detected.
+	relaxed := ctx.CI.IsCI() && ctx.CI.Tag != ""
+
+	ctx.Log().Debug("checking if git exists on PATH")
 	if !utils.BinExists("git") {
+		if relaxed {
+			ctx.Log().Warn("git not found on PATH - using tag from CI environment")
+			return nil
+		}
 		return ErrGitNotFound
 	}
 
-	log.Debug("checking if directory is a git repo")
+	ctx.Log().Debug("checking if directory is a git repo")
 	if !utils.InRepo() {
+		if relaxed {
+			ctx.Log().Warn("current directory is not a git repository - using tag from CI environment")
+			return nil
+		}
 		if err := ctx.CheckDryRun(ErrNotInRepo); err != nil {
 			return err
 		}
 	}
 
-	log.Debug("checking if git is in a clean state")
+	ctx.Log().Debug("checking if git is in a clean state")
 	if isDirty, out := utils.IsDirty(); isDirty {
 		if ctx.AllowDirty {
-			log.Info("allowing git to be in a dirty state")
+			ctx.Log().Info("allowing git to be in a dirty state")
 		} else {
 			if err := ctx.CheckDryRun(ErrDirtyGit); err != nil {
-				log.Errorf("dirty git state detected\n" + out)
+				ctx.Log().Errorf("dirty git state detected\n" + out)
 				return err
 			}
 		}
@@ -1,8 +1,12 @@
 package setup
 
 import (
+	"os"
+	"os/exec"
 	"testing"
 
+	"github.com/edaniszewski/chart-releaser/pkg/ci"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,3 +21,59 @@ func TestStage_String(t *testing.T) {
 func TestStage_Run(t *testing.T) {
 	// todo
 }
+
+func TestStage_Run_NotInRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found on PATH")
+	}
+	chdir(t, t.TempDir())
+
+	tests := []struct {
+		name     string
+		ci       ci.Environment
+		expected error
+	}{
+		{
+			name:     "not in CI",
+			expected: ErrNotInRepo,
+		},
+		{
+			// A tag without a detected CI provider does not relax the checks.
+			name:     "tag only",
+			ci:       ci.Environment{Tag: "v1.2.0"},
+			expected: ErrNotInRepo,
+		},
+		{
+			name:     "CI without tag",
+			ci:       ci.Environment{Provider: ci.GitLab},
+			expected: ErrNotInRepo,
+		},
+		{
+			name: "CI with tag",
+			ci:   ci.Environment{Provider: ci.GitLab, Tag: "v1.2.0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			context := ctx.Context{CI: test.ci}
+			assert.Equal(t, test.expected, Stage{}.Run(&context))
+		})
+	}
+}
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
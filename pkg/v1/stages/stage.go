@@ -3,6 +3,7 @@ package stages
 import (
 	"fmt"
 
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 )
 
@@ -14,3 +15,21 @@ type V1Stage interface {
 	Name() string
 	Run(ctx *ctx.Context) error
 }
+
+// Dependent is implemented by stages which require other stages to have run
+// before them in a pipeline, e.g. because they use values those stages load
+// into the Context.
+type Dependent interface {
+	// Requires gets the names of the stages which must run before the stage.
+	Requires() []string
+}
+
+// ConfigDependent is implemented by stages which require other stages only
+// for some configurations, e.g. because a configured strategy uses values
+// another stage loads into the Context.
+type ConfigDependent interface {
+	// RequiresFor gets the names of the stages which must run before the
+	// stage with the given configuration, in addition to those it always
+	// requires.
+	RequiresFor(cfg *v1.Config) []string
+}
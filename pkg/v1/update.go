@@ -1,18 +1,25 @@
 package v1
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
-	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/logging"
+	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/hooks"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/chart"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/client"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/config"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/confirm"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/diff"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/env"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/extras"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/git"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/notes"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/notify"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/publish"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/render"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/setup"
@@ -22,14 +29,44 @@ import (
 // Pipeline defines a list of V1Stages to run in order.
 type Pipeline []stages.V1Stage
 
+// Contains checks whether the Pipeline contains the named stage.
+func (p Pipeline) Contains(name string) bool {
+	for _, stage := range p {
+		if stage.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Run the stages defined by the Pipeline.
-func (p Pipeline) Run(ctx *ctx.Context) error {
+func (p Pipeline) Run(ctx *context.Context) error {
+	base := ctx.Log()
+	defer ctx.SetLog(base)
+
 	for _, stage := range p {
-		log.Info(color.New(color.Bold).Sprintf("%s - %s", strings.ToUpper(stage.Name()), stage.String()))
+		ctx.SetLog(base.WithFields(stageFields(ctx, stage)))
+		header := fmt.Sprintf("%s - %s", strings.ToUpper(stage.Name()), stage.String())
+		if !ctx.NoColor {
+			header = color.New(color.Bold).Sprint(header)
+		}
+		ctx.Log().Info(header)
 
-		err := stage.Run(ctx)
+		start := time.Now()
+		err := hooks.Run(ctx, stage.Name(), hooks.Before)
+		if err == nil {
+			err = stage.Run(ctx)
+		}
+		if err == nil {
+			err = hooks.Run(ctx, stage.Name(), hooks.After)
+		}
+		ctx.Stages = append(ctx.Stages, context.StageRun{
+			Name:     stage.Name(),
+			Duration: time.Since(start),
+			Err:      err,
+		})
 		if err != nil {
-			log.WithFields(log.Fields{
+			ctx.Log().WithFields(log.Fields{
 				"error": err,
 				"stage": stage.Name(),
 			}).Error("failed running update pipeline stage")
@@ -44,17 +81,35 @@ func (p Pipeline) Run(ctx *ctx.Context) error {
 	return nil
 }
 
+// stageFields gets the fields set on each line logged by the stage. The ref
+// is only set once it has been rendered, since until then it holds the branch
+// template from the configuration.
+func stageFields(ctx *context.Context, stage stages.V1Stage) log.Fields {
+	var repo string
+	if ctx.Repository.Owner != "" {
+		repo = ctx.Repository.Owner + "/" + ctx.Repository.Name
+	}
+	ref := ctx.Git.Ref
+	if strings.Contains(ref, "{{") {
+		ref = ""
+	}
+	return logging.StageFields(stage.Name(), repo, ref, ctx.Chart.SubPath)
+}
+
 // UpdatePipeline defines the stages and the order in which to execute them
 // in order to perform an update to a Chart.
 var UpdatePipeline Pipeline = []stages.V1Stage{
 	setup.Stage{},
 	config.Stage{},
 	env.Stage{},
-	git.Stage{},
 	client.Stage{},
+	git.Stage{},
+	notes.Stage{},
 	chart.Stage{},
 	extras.Stage{},
 	render.Stage{},
-	publish.Stage{},
 	diff.Stage{},
+	confirm.Stage{},
+	publish.Stage{},
+	notify.Stage{},
 }
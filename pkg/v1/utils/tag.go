@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+
+	version "github.com/edaniszewski/chart-releaser/pkg/semver"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
+)
+
+// VersionFromTag gets the semantic version of the application from a git tag.
+//
+// If a pattern is provided, the version is extracted from the tag using the
+// pattern's named "version" capture group, allowing tags such as "api/v1.4.2"
+// or "release-2024.3" to be used. If normalize is set, the extracted version is
+// coerced into a valid semantic version (e.g. "2024.3" -> "2024.3.0") before it
+// is parsed.
+func VersionFromTag(tag string, pattern *regexp.Regexp, normalize bool) (version.Semver, error) {
+	v := tag
+	if pattern != nil {
+		match := pattern.FindStringSubmatch(tag)
+		idx := pattern.SubexpIndex(v1.TagPatternGroup)
+		if match == nil || idx == -1 || match[idx] == "" {
+			return version.Semver{}, fmt.Errorf("tag '%s' does not match release tag pattern '%s'", tag, pattern.String())
+		}
+		v = match[idx]
+	}
+
+	if normalize {
+		v = version.Normalize(v)
+	}
+	return version.Load(v)
+}
+
+// PreviousTag finds the tag with the highest version which is lower than the
+// current version. Tags are parsed in the same manner as VersionFromTag; any
+// tags which do not parse to a version are ignored.
+//
+// If no such tag exists, the returned bool is false.
+func PreviousTag(tags []string, current version.Semver, pattern *regexp.Regexp, normalize bool) (string, version.Semver, bool) {
+	var prevTag string
+	var prevVersion version.Semver
+	var found bool
+
+	for _, tag := range tags {
+		v, err := VersionFromTag(tag, pattern, normalize)
+		if err != nil {
+			continue
+		}
+		if v.Compare(&current) >= 0 {
+			continue
+		}
+		if !found || v.Compare(&prevVersion) > 0 {
+			prevTag = tag
+			prevVersion = v
+			found = true
+		}
+	}
+	return prevTag, prevVersion, found
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionFromTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		tag       string
+		pattern   *regexp.Regexp
+		normalize bool
+		expected  string
+	}{
+		{
+			name:     "plain tag",
+			tag:      "1.2.3",
+			expected: "1.2.3",
+		},
+		{
+			name:     "prefixed tag",
+			tag:      "v1.2.3",
+			expected: "v1.2.3",
+		},
+		{
+			name:     "monorepo tag",
+			tag:      "api/v1.4.2",
+			pattern:  regexp.MustCompile(`^api/(?P<version>v.+)$`),
+			expected: "v1.4.2",
+		},
+		{
+			name:      "legacy tag",
+			tag:       "release-2024.3",
+			pattern:   regexp.MustCompile(`^release-(?P<version>.+)$`),
+			normalize: true,
+			expected:  "2024.3.0",
+		},
+		{
+			name:      "normalize without pattern",
+			tag:       "v2.01",
+			normalize: true,
+			expected:  "v2.1.0",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := VersionFromTag(test.tag, test.pattern, test.normalize)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, v.String())
+		})
+	}
+}
+
+func TestVersionFromTag_NoMatch(t *testing.T) {
+	_, err := VersionFromTag("web/v1.0.0", regexp.MustCompile(`^api/(?P<version>.+)$`), false)
+	assert.EqualError(t, err, "tag 'web/v1.0.0' does not match release tag pattern '^api/(?P<version>.+)$'")
+}
+
+func TestVersionFromTag_NoGroup(t *testing.T) {
+	_, err := VersionFromTag("api/v1.0.0", regexp.MustCompile(`^api/(.+)$`), false)
+	assert.EqualError(t, err, "tag 'api/v1.0.0' does not match release tag pattern '^api/(.+)$'")
+}
+
+func TestVersionFromTag_NotSemver(t *testing.T) {
+	_, err := VersionFromTag("release-2024.3", regexp.MustCompile(`^release-(?P<version>.+)$`), false)
+	assert.EqualError(t, err, "No Major.Minor.Patch elements found")
+}
+
+func TestPreviousTag(t *testing.T) {
+	tags := []string{
+		"v0.9.0",
+		"v1.0.0",
+		"v1.1.0-rc.1",
+		"v1.1.0",
+		"v1.2.0",
+		"latest",
+		"v1.10.0",
+	}
+
+	tag, v, found := PreviousTag(tags, testutils.NewSemver(t, "v1.2.0"), nil, false)
+	assert.True(t, found)
+	assert.Equal(t, "v1.1.0", tag)
+	assert.Equal(t, "v1.1.0", v.String())
+}
+
+func TestPreviousTag_Pattern(t *testing.T) {
+	tags := []string{
+		"api/v1.4.1",
+		"api/v1.4.2",
+		"web/v1.4.1",
+		"web/v2.0.0",
+		"v1.4.1",
+	}
+	pattern := regexp.MustCompile(`^api/(?P<version>.+)$`)
+
+	tag, v, found := PreviousTag(tags, testutils.NewSemver(t, "v1.5.0"), pattern, false)
+	assert.True(t, found)
+	assert.Equal(t, "api/v1.4.2", tag)
+	assert.Equal(t, "v1.4.2", v.String())
+}
+
+func TestPreviousTag_Normalize(t *testing.T) {
+	tags := []string{
+		"release-2024.1",
+		"release-2024.2",
+		"release-2024.3",
+	}
+	pattern := regexp.MustCompile(`^release-(?P<version>.+)$`)
+
+	tag, v, found := PreviousTag(tags, testutils.NewSemver(t, "2024.3.0"), pattern, true)
+	assert.True(t, found)
+	assert.Equal(t, "release-2024.2", tag)
+	assert.Equal(t, "2024.2.0", v.String())
+}
+
+func TestPreviousTag_NotFound(t *testing.T) {
+	tags := []string{
+		"v1.0.0",
+		"v1.1.0",
+	}
+
+	_, _, found := PreviousTag(tags, testutils.NewSemver(t, "v1.0.0"), nil, false)
+	assert.False(t, found)
+}
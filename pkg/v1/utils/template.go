@@ -5,20 +5,32 @@ import (
 	"fmt"
 	"text/template"
 
-	"github.com/apex/log"
+	"github.com/edaniszewski/chart-releaser/pkg/errs"
+	"github.com/edaniszewski/chart-releaser/pkg/templates"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
 	context "github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 )
 
+// ParseTemplate parses a template for the Context. Named templates defined in the
+// template files loaded for the Context's Config are available to the template.
+func ParseTemplate(ctx *context.Context, name, tmpl string) (*template.Template, error) {
+	t, err := ctx.Config.NewTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.Parse(tmpl)
+}
+
 // RenderTemplate is a convenience method to render a template, handing the case
 // where dry-run is configured.
 func RenderTemplate(ctx *context.Context, name, tmpl string) (string, error) {
-	t, err := template.New(name).Parse(tmpl)
+	t, err := ParseTemplate(ctx, name, tmpl)
 	if err != nil {
 		if err := ctx.CheckDryRun(err); err != nil {
 			return "", err
 		}
-		t = template.Must(template.New("").Parse("dry-run"))
-		log.Warnf("dry-run: failed to parse template '%s', using stand-in", name)
+		t = template.Must(templates.New("").Parse("dry-run"))
+		ctx.Log().Warnf("dry-run: failed to parse template '%s', using stand-in", name)
 	}
 
 	buf := bytes.Buffer{}
@@ -28,7 +40,33 @@ func RenderTemplate(ctx *context.Context, name, tmpl string) (string, error) {
 			return "", err
 		}
 		_, _ = fmt.Fprint(&buf, "dry-run")
-		log.Warnf("dry-run: failed to execute template '%s', using stand-in", name)
+		ctx.Log().Warnf("dry-run: failed to execute template '%s', using stand-in", name)
 	}
 	return buf.String(), nil
 }
+
+// ValidateTemplates checks that all of the templates set in the Config can be
+// executed. Each template is executed against a sample Context, so this will
+// surface errors such as references to fields which do not exist on the Context.
+//
+// Templates which fail to parse are skipped, as those errors are reported by
+// the Config's Validate.
+func ValidateTemplates(config *v1.Config) error {
+	collector := errs.NewCollector()
+	sample := context.NewSample(config)
+
+	for _, f := range config.Templates() {
+		t, err := ParseTemplate(sample, f.Location, f.Value)
+		if err != nil {
+			continue
+		}
+		if err := t.Execute(&bytes.Buffer{}, sample); err != nil {
+			collector.Add(fmt.Errorf("invalid template at '%s': %v", f.Location, err))
+		}
+	}
+
+	if collector.HasErrors() {
+		return collector
+	}
+	return nil
+}
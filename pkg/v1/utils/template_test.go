@@ -1,8 +1,13 @@
 package utils
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
+	"github.com/edaniszewski/chart-releaser/internal/testutils"
+	"github.com/edaniszewski/chart-releaser/pkg/errs"
+	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 	"github.com/stretchr/testify/assert"
 )
@@ -58,3 +63,82 @@ func Test_RenderTemplateExecuteErrorDryRun(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "dry-run", rendered)
 }
+
+func Test_RenderTemplateFuncs(t *testing.T) {
+	context := &ctx.Context{
+		App: ctx.App{
+			NewVersion: testutils.NewSemver(t, "v1.2.3"),
+		},
+	}
+
+	rendered, err := RenderTemplate(context, "test-tmpl", `{{ .App.NewVersion | trimPrefix "v" }}/{{ semverMajor .App.NewVersion }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3/1", rendered)
+}
+
+func TestValidateTemplates(t *testing.T) {
+	cfg := &v1.Config{
+		Publish: &v1.PublishConfig{
+			PR: &v1.PublishPRConfig{
+				BranchTemplate: "chartreleaser/{{ .Chart.Name }}/{{ .Chart.NewVersion }}",
+				TitleTemplate:  `Bump {{ .Chart.Name }} to {{ .App.NewVersion | trimPrefix "v" }}`,
+				BodyTemplate:   "{{ range .Git.Commits }}- {{ .ShortSHA }} {{ .Subject }}\n{{ end }}{{ .App.ReleaseNotes }}",
+			},
+		},
+		Extras: []*v1.ExtrasConfig{
+			{
+				Path: "README.md",
+				Updates: []*v1.SearchReplace{
+					{Search: "v.*", Replace: "{{ .App.NewVersion }} ({{ .CurrentFile.Path }})"},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateTemplates(cfg))
+}
+
+func TestValidateTemplates_Error(t *testing.T) {
+	cfg := &v1.Config{
+		Publish: &v1.PublishConfig{
+			PR: &v1.PublishPRConfig{
+				TitleTemplate: "{{ .App.Version }}",
+				BodyTemplate:  "{{ if }}",
+			},
+		},
+		Commit: &v1.CommitConfig{
+			Templates: &v1.CommitTemplateConfig{
+				Update: `{{ semverBump "build" .App.NewVersion }}`,
+			},
+		},
+	}
+
+	err := ValidateTemplates(cfg)
+	assert.Error(t, err)
+
+	collector, ok := err.(*errs.Collector)
+	assert.True(t, ok, "error is an instance of errs.Collector")
+	assert.Equal(t, 2, collector.Count())
+	assert.Contains(t, err.Error(), "invalid template at 'commit.templates.update'")
+	assert.Contains(t, err.Error(), "invalid template at 'publish.pr.title_template'")
+	assert.Contains(t, err.Error(), "can't evaluate field Version")
+}
+
+func Test_RenderTemplateIncludes(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "partials.tmpl"), []byte(`{{ define "token" }}token:{{ .Token }}{{ end }}`), 0644))
+
+	cfg := &v1.Config{
+		Includes: []string{"partials.tmpl"},
+	}
+	assert.NoError(t, cfg.LoadTemplateFiles(dir))
+
+	context := &ctx.Context{
+		Config: cfg,
+		Token:  "foobar",
+	}
+
+	rendered, err := RenderTemplate(context, "test-tmpl", `{{ template "token" . }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "token:foobar", rendered)
+}
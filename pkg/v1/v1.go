@@ -1,15 +1,27 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/apex/log"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/edaniszewski/chart-releaser/pkg/ci"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
 	"github.com/edaniszewski/chart-releaser/pkg/templates"
+	pkgutils "github.com/edaniszewski/chart-releaser/pkg/utils"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/actions"
 	v1 "github.com/edaniszewski/chart-releaser/pkg/v1/cfg"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/report"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/diff"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/env"
+	"github.com/edaniszewski/chart-releaser/pkg/v1/stages/notify"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/utils"
 	"sigs.k8s.io/yaml"
 )
@@ -19,10 +31,16 @@ func ConfigVersion() string {
 	return v1.ConfigVersion
 }
 
+// Errors for running chart-releaser commands.
+var (
+	ErrLocalChartRepoNoDryRun = errors.New("a local chart repo can only be used with --dry-run")
+)
+
 // Updater runs chart updates.
 type Updater struct {
-	data []byte
-	opts UpdateOptions
+	data     []byte
+	opts     UpdateOptions
+	pipeline Pipeline
 }
 
 // NewUpdater creates a new Updater.
@@ -34,26 +52,77 @@ func NewUpdater(b []byte) *Updater {
 
 // UpdateOptions provide command line options to the Updater.
 type UpdateOptions struct {
-	AllowDirty bool
-	DryRun     bool
-	ShowDiff   bool
-	Timeout    time.Duration
+	AllowDirty     bool
+	AllowFeatures  []string
+	APIURL         string
+	AppVersion     string
+	AssumeYes      bool
+	ConfigPath     string
+	Confirm        bool
+	DiffContext    int
+	DiffFormat     string
+	DiffOutput     string
+	DryRun         bool
+	DryRunHooks    bool
+	LocalChartRepo string
+	NoColor        bool
+	NoGitHistory   bool
+	NoRollback     bool
+	Output         string
+	ReportFile     string
+	Retry          client.RetryOptions
+	ShowDiff       bool
+	SkipStages     []string
+	OnlyStages     []string
+	Timeout        time.Duration
+	Untrusted      bool
 }
 
 // AugmentCtx translates the update option values to their corresponding
 // fields in a v1 Context.
 func (opts *UpdateOptions) AugmentCtx(context *ctx.Context) {
 	context.AllowDirty = opts.AllowDirty
+	context.APIURL = opts.APIURL
+	context.AppVersion = opts.AppVersion
+	context.AssumeYes = opts.AssumeYes
+	context.Confirm = opts.Confirm
+	context.DiffContext = opts.DiffContext
+	context.DiffFormat = opts.DiffFormat
+	context.DiffOutput = opts.DiffOutput
 	context.DryRun = opts.DryRun
-	context.ShowDiff = opts.ShowDiff
+	context.DryRunHooks = opts.DryRunHooks
+	context.LocalChartRepo = opts.LocalChartRepo
+	context.NoColor = opts.NoColor
+	context.NoGitHistory = opts.NoGitHistory
+	context.NoRollback = opts.NoRollback
+	context.Retry = opts.Retry
+	// Changes are always shown before asking for confirmation to publish them.
+	context.ShowDiff = opts.ShowDiff || opts.Confirm
 }
 
 // Run the v1 chart updater.
 func (u *Updater) Run(opts UpdateOptions) error {
 	u.opts = opts
 
+	// The local chart repo client is read-only, so it can not publish changes.
+	if opts.LocalChartRepo != "" && !opts.DryRun {
+		return ErrLocalChartRepoNoDryRun
+	}
+	if opts.Output != "" && opts.Output != OutputText && opts.Output != OutputJSON {
+		return fmt.Errorf("unsupported output format '%s', should be one of: [%s %s]", opts.Output, OutputText, OutputJSON)
+	}
+	if !diff.IsValidFormat(opts.DiffFormat) {
+		return fmt.Errorf("unsupported diff format '%s', should be one of: %v", opts.DiffFormat, diff.Formats)
+	}
+
 	// Load the v1 configuration from the bytes provided to the updater.
-	cfg, err := v1.LoadFromBytes(u.data)
+	var cfg *v1.Config
+	var err error
+	if opts.Untrusted {
+		cfg, err = v1.LoadUntrusted(u.data, configDir(opts.ConfigPath), opts.AllowFeatures)
+	} else {
+		cfg, err = v1.Load(u.data, configDir(opts.ConfigPath))
+	}
 	if err != nil {
 		return err
 	}
@@ -66,17 +135,122 @@ func (u *Updater) Run(opts UpdateOptions) error {
 	// Set values from the update options onto the Context which will be
 	// used throughout the Update process.
 	u.opts.AugmentCtx(context)
+	context.Interactive = pkgutils.IsInteractive(context.In)
+
+	detectCI(context)
+	if context.CI.IsCI() {
+		log.WithFields(log.Fields{
+			"provider": context.CI.Provider,
+			"job":      context.CI.JobURL,
+		}).Debug("detected CI environment")
+	}
+
+	// When writing the report to stdout, any other output goes to stderr so
+	// the report can be consumed directly.
+	if opts.Output == OutputJSON {
+		context.Out = os.Stderr
+	}
 
+	err = u.update(cfg, context)
+	if reportErr := u.writeReport(context, err); reportErr != nil {
+		log.WithError(reportErr).Error("failed to write report")
+		if err == nil {
+			err = reportErr
+		}
+	}
+	if actionsErr := actions.Write(context, err); actionsErr != nil {
+		log.WithError(actionsErr).Warn("failed to write GitHub Actions outputs")
+	}
+	return err
+}
+
+// update validates the configuration and runs the update pipeline.
+func (u *Updater) update(cfg *v1.Config, context *ctx.Context) error {
 	if err := cfg.Validate(); err != nil {
-		if opts.DryRun {
+		if u.opts.DryRun {
 			log.WithError(err).Warn("dry-run: failed config validation")
 		} else {
 			return err
 		}
 	}
+	if err := utils.ValidateTemplates(cfg); err != nil {
+		if u.opts.DryRun {
+			log.WithError(err).Warn("dry-run: failed template validation")
+		} else {
+			return err
+		}
+	}
 
-	// Run the update pipeline.
-	return UpdatePipeline.Run(context)
+	pipeline, err := buildPipeline(u.pipeline, cfg, u.opts.SkipStages, u.opts.OnlyStages)
+	if err != nil {
+		return err
+	}
+
+	// Run the update pipeline. If it fails, any failure notifications are
+	// sent, unless notifications are disabled by skipping the notify stage.
+	err = pipeline.Run(context)
+	if err != nil && pipeline.Contains(notify.Stage{}.Name()) {
+		notify.Send(context, err)
+	}
+	return err
+}
+
+// WithPipeline sets the Pipeline run by the Updater, which defaults to the
+// UpdatePipeline. Stages selected by the update options and configuration
+// are applied to it when the Updater is run.
+func (u *Updater) WithPipeline(p Pipeline) *Updater {
+	u.pipeline = p
+	return u
+}
+
+// buildPipeline builds the Pipeline to run, selecting stages from the
+// configuration and the given skip and only stages. The skip stages are in
+// addition to those skipped by the configuration, and the only stages take
+// precedence over those configured.
+func buildPipeline(p Pipeline, cfg *v1.Config, skip, only []string) (Pipeline, error) {
+	if p == nil {
+		p = UpdatePipeline
+	}
+	b := NewPipelineBuilder(p...).Config(cfg)
+
+	// Hooks may be configured for any stage in the pipeline, even if it is
+	// not run, but not for stages which do not exist.
+	var unknown []string
+	for stage := range cfg.Hooks {
+		if !contains(b.Names(), stage) {
+			unknown = append(unknown, stage)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("hooks configured for unknown stages %v, should be one of: %v", unknown, b.Names())
+	}
+
+	if cfg.Pipeline != nil {
+		b.Skip(cfg.Pipeline.Skip...)
+		if len(only) == 0 {
+			only = cfg.Pipeline.Only
+		}
+	}
+	return b.Skip(skip...).Only(only...).Build()
+}
+
+// writeReport writes the run report, if configured by the update options.
+func (u *Updater) writeReport(context *ctx.Context, err error) error {
+	if u.opts.Output != OutputJSON && u.opts.ReportFile == "" {
+		return nil
+	}
+
+	r := report.New(context, err)
+	if u.opts.ReportFile != "" {
+		if err := r.WriteFile(u.opts.ReportFile); err != nil {
+			return err
+		}
+	}
+	if u.opts.Output == OutputJSON {
+		return r.Write(os.Stdout)
+	}
+	return nil
 }
 
 // Formatter runs chart-releaser config formatting.
@@ -134,12 +308,13 @@ func NewChecker(b []byte) *Checker {
 
 // CheckerOptions provide command line options to the Checker.
 type CheckerOptions struct {
-	SkipEnv bool
+	ConfigPath string
+	SkipEnv    bool
 }
 
 // Run the v1 config checker.
 func (c *Checker) Run(opts CheckerOptions) error {
-	cfg, err := v1.LoadFromBytes(c.data)
+	cfg, err := v1.Load(c.data, configDir(opts.ConfigPath))
 	if err != nil {
 		return err
 	}
@@ -163,5 +338,39 @@ func (c *Checker) Run(opts CheckerOptions) error {
 		log.Debug("skipping check for env vars")
 	}
 
-	return cfg.Validate()
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if _, err := buildPipeline(UpdatePipeline, cfg, nil, nil); err != nil {
+		return err
+	}
+	return utils.ValidateTemplates(cfg)
+}
+
+// detectCI detects the CI job the Context is running in. In GitHub Actions,
+// the release tag is read from the event which triggered the workflow, in
+// place of the tag from GITHUB_REF, so the CI environment holds the only tag
+// detected from CI.
+func detectCI(context *ctx.Context) {
+	context.Actions = actions.Detect()
+	context.CI = ci.Detect()
+
+	tag, err := actions.EventTag(context.Actions)
+	if err != nil {
+		log.WithError(err).Warn("failed to read GitHub Actions event")
+		return
+	}
+	if tag != "" {
+		context.CI.Tag = tag
+	}
+}
+
+// configDir gets the directory containing the config file at the given path.
+// Relative paths referenced by the config are resolved against this directory.
+// A config which was not loaded from a file has no directory.
+func configDir(path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Dir(path)
 }
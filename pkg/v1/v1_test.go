@@ -1,8 +1,15 @@
 package v1
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/edaniszewski/chart-releaser/pkg/ci"
+	"github.com/edaniszewski/chart-releaser/pkg/client"
 	"github.com/edaniszewski/chart-releaser/pkg/v1/ctx"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,23 +35,59 @@ func TestUpdater_Run_LoadError(t *testing.T) {
 	assert.EqualError(t, err, "error converting YAML to JSON: yaml: control characters are not allowed")
 }
 
+func TestUpdater_Run_LocalChartRepoNoDryRun(t *testing.T) {
+	u := NewUpdater([]byte{0x00, 0x01})
+
+	err := u.Run(UpdateOptions{LocalChartRepo: "./charts"})
+	assert.Equal(t, ErrLocalChartRepoNoDryRun, err)
+}
+
 func TestUpdateOptions_AugmentCtx(t *testing.T) {
 	context := ctx.Context{}
 	assert.False(t, context.AllowDirty)
+	assert.Equal(t, "", context.AppVersion)
 	assert.False(t, context.DryRun)
 	assert.False(t, context.ShowDiff)
 
 	opts := UpdateOptions{
-		AllowDirty: true,
-		ShowDiff:   true,
-		DryRun:     true,
+		AllowDirty:     true,
+		APIURL:         "http://127.0.0.1:8080",
+		AppVersion:     "v1.2.3",
+		ShowDiff:       true,
+		DryRun:         true,
+		LocalChartRepo: "./charts",
+		NoRollback:     true,
+		NoGitHistory:   true,
+		Retry:          client.RetryOptions{MaxRetries: 2},
 	}
 
 	opts.AugmentCtx(&context)
 
 	assert.True(t, context.AllowDirty)
+	assert.Equal(t, "http://127.0.0.1:8080", context.APIURL)
+	assert.Equal(t, "v1.2.3", context.AppVersion)
 	assert.True(t, context.DryRun)
 	assert.True(t, context.ShowDiff)
+	assert.Equal(t, "./charts", context.LocalChartRepo)
+	assert.True(t, context.NoRollback)
+	assert.True(t, context.NoGitHistory)
+	assert.Equal(t, client.RetryOptions{MaxRetries: 2}, context.Retry)
+}
+
+func TestUpdateOptions_AugmentCtxConfirm(t *testing.T) {
+	context := ctx.Context{}
+
+	opts := UpdateOptions{
+		AssumeYes: true,
+		Confirm:   true,
+	}
+
+	opts.AugmentCtx(&context)
+
+	assert.True(t, context.AssumeYes)
+	assert.True(t, context.Confirm)
+	// The diff is always shown when confirming changes.
+	assert.True(t, context.ShowDiff)
 }
 
 func TestNewFormatter(t *testing.T) {
@@ -80,3 +123,130 @@ func TestChecker_Run_LoadError(t *testing.T) {
 	err := c.Run(CheckerOptions{})
 	assert.EqualError(t, err, "error converting YAML to JSON: yaml: control characters are not allowed")
 }
+
+func TestDetectCI_GitHubActions(t *testing.T) {
+	event := filepath.Join(t.TempDir(), "event.json")
+	assert.NoError(t, ioutil.WriteFile(event, []byte(`{"action":"published","release":{"tag_name":"v1.3.0"}}`), 0644))
+	for k, v := range map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_EVENT_NAME": "release",
+		"GITHUB_EVENT_PATH": event,
+		"GITHUB_REF":        "refs/heads/master",
+	} {
+		orig, found := os.LookupEnv(k)
+		assert.NoError(t, os.Setenv(k, v))
+		k := k
+		t.Cleanup(func() {
+			if found {
+				_ = os.Setenv(k, orig)
+			} else {
+				_ = os.Unsetenv(k)
+			}
+		})
+	}
+
+	// The tag from the event is used in place of the tag from GITHUB_REF.
+	context := ctx.Context{}
+	detectCI(&context)
+	assert.Equal(t, ctx.Actions{Enabled: true, EventName: "release"}, context.Actions)
+	assert.Equal(t, ci.GitHubActions, context.CI.Provider)
+	assert.Equal(t, "v1.3.0", context.CI.Tag)
+	assert.Equal(t, "master", context.CI.Branch)
+}
+
+func TestNewRenderer(t *testing.T) {
+	r := NewRenderer([]byte{0x00, 0x01})
+	assert.NotNil(t, r)
+	assert.Equal(t, []byte{0x00, 0x01}, r.data)
+}
+
+func TestRenderer_Run(t *testing.T) {
+	cfg := []byte(`
+version: v1
+chart:
+  name: test-chart
+  repo: github.com/test/charts
+commit:
+  author:
+    name: test
+    email: test@example.com
+publish:
+  pr:
+    title_template: 'Release {{ .App.NewVersion | trimPrefix "v" }}'
+extras:
+- path: README.md
+  updates:
+  - search: 'v\d+'
+    replace: '{{ .App.NewVersion }}'
+`)
+	out := bytes.Buffer{}
+	r := NewRenderer(cfg)
+
+	err := r.Run(RenderOptions{
+		AppVersion:           "v1.3.0",
+		PreviousAppVersion:   "v1.2.0",
+		PreviousChartVersion: "0.4.0",
+		Output:               OutputJSON,
+		Out:                  &out,
+	})
+	assert.NoError(t, err)
+
+	var result RenderResult
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, "v1.3.0", result.AppVersion)
+	assert.Equal(t, "v1.2.0", result.PreviousAppVersion)
+	assert.Equal(t, "0.4.1", result.ChartVersion)
+	assert.Equal(t, "0.4.0", result.PreviousChartVersion)
+	assert.Equal(t, "chartreleaser/test-chart/0.4.1", result.Branch)
+	assert.Equal(t, "master", result.Base)
+	assert.Equal(t, "[test-chart] bump chart to 0.4.1 for new application release (v1.3.0)", result.ChartCommitMessage)
+	assert.Equal(t, []RenderedCommitMessage{
+		{Path: "README.md", Message: "[test-chart] update README.md for new application release (v1.3.0)"},
+	}, result.ExtrasCommitMessages)
+	assert.Equal(t, "Release 1.3.0", result.PRTitle)
+	assert.Contains(t, result.PRBody, "Bumps the test-chart Helm Chart from 0.4.0 to 0.4.1.")
+}
+
+func TestRenderer_Run_ChartVersion(t *testing.T) {
+	cfg := []byte(`
+version: v1
+chart:
+  name: test-chart
+  repo: github.com/test/charts
+commit:
+  author:
+    name: test
+    email: test@example.com
+publish:
+  commit:
+    branch: master
+`)
+	out := bytes.Buffer{}
+	r := NewRenderer(cfg)
+
+	err := r.Run(RenderOptions{
+		AppVersion:         "v1.3.0",
+		ChartVersion:       "2.0.0",
+		PreviousAppVersion: "v1.2.0",
+		Out:                &out,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "chart version:  0.1.0 -> 2.0.0")
+	assert.Contains(t, out.String(), "strategy:       commit")
+	assert.Contains(t, out.String(), "[test-chart] bump chart to 2.0.0 for new application release (v1.3.0)")
+	assert.NotContains(t, out.String(), "pull request title")
+}
+
+func TestRenderer_Run_LoadError(t *testing.T) {
+	r := NewRenderer([]byte{0x00, 0x01})
+
+	err := r.Run(RenderOptions{})
+	assert.EqualError(t, err, "error converting YAML to JSON: yaml: control characters are not allowed")
+}
+
+func TestRenderer_Run_OutputError(t *testing.T) {
+	r := NewRenderer([]byte{0x00, 0x01})
+
+	err := r.Run(RenderOptions{Output: "yaml"})
+	assert.EqualError(t, err, "unsupported output format 'yaml', should be one of: [text json]")
+}